@@ -0,0 +1,232 @@
+// Package bench generates load of concurrent clients against master and measures throughput and latency of
+// their calls, so performance changes of RemoteFS can be compared. It's used by dfsbench command and can be run
+// from Go benchmarks with Workload.Ops set to b.N
+package bench
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pattern - order in which clients go over records
+type Pattern string
+
+const (
+	// Sequential - every client reads and writes records one after another starting from its own place in the file
+	Sequential Pattern = "seq"
+	// Random - ids of records are uniformly distributed
+	Random Pattern = "random"
+)
+
+// prefillBatch - number of records written by one call while files are filled
+const prefillBatch = 64
+
+// Workload - settings of generated load
+type Workload struct {
+	FilePrefix string        `json:"file_prefix"` // files are named <prefix>-<n>; "bench" if empty
+	Files      int           `json:"files"`       // number of files; 1 if 0
+	Records    int64         `json:"records"`     // records per file; 1000 if 0
+	RecordSize int32         `json:"record_size"` // 64 if 0
+	ReadRatio  float64       `json:"read_ratio"`  // share of reads among calls
+	Pattern    Pattern       `json:"pattern"`     // Sequential if empty
+	Batch      int           `json:"batch"`       // records read or written by one call; 1 if 0. Written records are always consecutive
+	Clients    int           `json:"clients"`     // number of concurrent clients, every one has its own connection; 1 if 0
+	Duration   time.Duration `json:"duration"`    // how long clients work if Ops is 0; 10s if both are 0
+	Ops        int64         `json:"ops"`         // total number of calls made by all the clients; Duration is used if 0
+	Prefill    bool          `json:"prefill"`     // write every record before the load, so reads do not hit never written records
+	Keep       bool          `json:"keep"`        // do not delete files after the load
+	Seed       int64         `json:"seed"`
+}
+
+// NewClient - returns new client connected to master
+type NewClient func() (*utils.RemoteDFS, error)
+
+func (w *Workload) setDefaults() error {
+	if w.FilePrefix == "" {
+		w.FilePrefix = "bench"
+	}
+	if w.Files == 0 {
+		w.Files = 1
+	}
+	if w.Records == 0 {
+		w.Records = 1000
+	}
+	if w.RecordSize == 0 {
+		w.RecordSize = 64
+	}
+	if w.Pattern == "" {
+		w.Pattern = Sequential
+	}
+	if w.Batch == 0 {
+		w.Batch = 1
+	}
+	if w.Clients == 0 {
+		w.Clients = 1
+	}
+	if w.Duration == 0 && w.Ops == 0 {
+		w.Duration = 10 * time.Second
+	}
+
+	if w.Pattern != Sequential && w.Pattern != Random {
+		return utils.Errorf(utils.CodeInvalidArgument, "unknown pattern %q; use %s or %s", w.Pattern, Sequential, Random)
+	}
+	if w.ReadRatio < 0 || w.ReadRatio > 1 {
+		return utils.Errorf(utils.CodeInvalidArgument, "read ratio %v is not in [0, 1]", w.ReadRatio)
+	}
+	if w.Files < 0 || w.Records < 0 || w.RecordSize < 0 || w.Batch < 0 || w.Clients < 0 || w.Ops < 0 {
+		return utils.Errorf(utils.CodeInvalidArgument, "sizes of workload should not be negative")
+	}
+	if int64(w.Batch) > w.Records {
+		return utils.Errorf(utils.CodeInvalidArgument, "batch of %d records is larger than file of %d records", w.Batch, w.Records)
+	}
+	if w.Records > int64((1<<31-1)/w.RecordSize) {
+		return utils.Errorf(utils.CodeInvalidArgument, "%d records of %d bytes do not fit into file", w.Records, w.RecordSize)
+	}
+	return nil
+}
+
+func (w *Workload) fileName(i int) string {
+	return fmt.Sprintf("%s-%d", w.FilePrefix, i)
+}
+
+// Run - creates files of the workload, runs clients against them and returns measurements. Failed calls are
+// counted by error codes and do not stop the load
+func Run(newClient NewClient, w Workload) (*Result, error) {
+	if err := w.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	clients := make([]*utils.RemoteDFS, w.Clients)
+	for i := range clients {
+		dfs, err := newClient()
+		if err != nil {
+			return nil, err
+		}
+		defer dfs.Close()
+		clients[i] = dfs
+	}
+
+	if err := setUp(clients[0], &w); err != nil {
+		return nil, err
+	}
+	if !w.Keep {
+		defer tearDown(clients[0], &w)
+	}
+
+	// clients take calls from the common budget if number of calls is limited
+	budget := w.Ops
+	stop := make(chan struct{})
+
+	recorders := make([]*recorder, w.Clients)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i, dfs := range clients {
+		recorders[i] = newRecorder()
+		wg.Add(1)
+		go func(client int, dfs *utils.RemoteDFS) {
+			defer wg.Done()
+			runClient(client, dfs, &w, recorders[client], &budget, stop)
+		}(i, dfs)
+	}
+	if w.Ops == 0 {
+		time.Sleep(w.Duration)
+		close(stop)
+	}
+	wg.Wait()
+
+	return newResult(&w, time.Since(start), recorders), nil
+}
+
+// setUp - creates files of the workload and fills them if needed
+func setUp(dfs *utils.RemoteDFS, w *Workload) error {
+	for i := 0; i < w.Files; i++ {
+		fname := w.fileName(i)
+		if err := dfs.SetRecordSize(fname, w.RecordSize); err != nil {
+			return err
+		}
+		if err := dfs.CreateFile(fname); err != nil && utils.CodeOf(err) != utils.CodeAlreadyExists {
+			return fmt.Errorf("failed to create file(%s): %w", fname, err)
+		}
+		if !w.Prefill {
+			continue
+		}
+		for id := int64(1); id <= w.Records; id += prefillBatch {
+			count := w.Records - id + 1
+			if count > prefillBatch {
+				count = prefillBatch
+			}
+			data := make([]byte, count*int64(w.RecordSize))
+			if err := dfs.WriteBytes(fname, int32(id-1)*w.RecordSize, &data); err != nil {
+				return fmt.Errorf("failed to fill file(%s): %w", fname, err)
+			}
+		}
+	}
+	return nil
+}
+
+func tearDown(dfs *utils.RemoteDFS, w *Workload) {
+	for i := 0; i < w.Files; i++ {
+		dfs.DeleteFile(w.fileName(i))
+	}
+}
+
+func runClient(client int, dfs *utils.RemoteDFS, w *Workload, rec *recorder, budget *int64, stop chan struct{}) {
+	random := rand.New(rand.NewSource(w.Seed + int64(client)))
+	// sequential clients start from different places, so they do not go over the same records together
+	slots := w.Records - int64(w.Batch) + 1
+	next := int64(client) * slots / int64(w.Clients)
+	data := make([]byte, w.Batch*int(w.RecordSize))
+	ids := make([]int64, w.Batch)
+	file := client % w.Files
+
+	for {
+		if w.Ops > 0 {
+			if atomic.AddInt64(budget, -1) < 0 {
+				return
+			}
+		} else {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+
+		var first int64
+		if w.Pattern == Random {
+			first = random.Int63n(slots) + 1
+			file = random.Intn(w.Files)
+		} else {
+			first = next + 1
+			if next += int64(w.Batch); next >= slots {
+				next = 0
+				file = (file + 1) % w.Files
+			}
+		}
+		fname := w.fileName(file)
+		offset := int32(first-1) * w.RecordSize
+
+		var err error
+		read := random.Float64() < w.ReadRatio
+		started := time.Now()
+		switch {
+		case read && w.Batch > 1 && w.Pattern == Random:
+			for i := range ids {
+				ids[i] = random.Int63n(w.Records) + 1
+			}
+			started = time.Now()
+			_, err = dfs.ReadRecords(fname, ids)
+		case read:
+			_, err = dfs.ReadBytes(fname, offset, int32(len(data)))
+		default:
+			random.Read(data)
+			started = time.Now()
+			err = dfs.WriteBytes(fname, offset, &data)
+		}
+		rec.add(read, time.Since(started), w.Batch, err)
+	}
+}
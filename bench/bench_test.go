@@ -0,0 +1,73 @@
+package bench_test
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/bench"
+	"github.com/alikhil/distributed-fs/dfstest"
+	"github.com/alikhil/distributed-fs/utils"
+	"os"
+	"testing"
+)
+
+// benchRecords - number of records in the file of benchmarks
+const benchRecords = 1000
+
+// TestMain - keeps logs of in-process cluster out of benchmark results
+func TestMain(m *testing.M) {
+	for subsystem := range utils.LogLevels() {
+		utils.SetLogLevel(subsystem, "error")
+	}
+	os.Exit(m.Run())
+}
+
+// runBenchmark - runs b.N calls of the workload against in-process cluster, once through master and once
+// directly to peers. Files are filled before the timer starts, so reads do not hit never written records
+func runBenchmark(b *testing.B, w bench.Workload) {
+	for _, direct := range []bool{false, true} {
+		b.Run(fmt.Sprintf("direct=%v", direct), func(b *testing.B) {
+			c, err := dfstest.Start(dfstest.Options{Direct: direct})
+			if err != nil {
+				b.Fatalf("failed to start cluster: %v", err)
+			}
+			defer c.Stop()
+
+			w.Records = benchRecords
+			w.Keep = true
+			prefill := w
+			prefill.Prefill, prefill.Ops, prefill.ReadRatio = true, 1, 1
+			if _, err := bench.Run(c.NewClient, prefill); err != nil {
+				b.Fatalf("failed to fill files: %v", err)
+			}
+
+			w.Ops = int64(b.N)
+			b.ResetTimer()
+			res, err := bench.Run(c.NewClient, w)
+			b.StopTimer()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if res.FailedCalls > 0 {
+				b.Fatalf("%d calls failed: %v", res.FailedCalls, res.Errors)
+			}
+			b.ReportMetric(res.RecordsPerSec, "records/s")
+		})
+	}
+}
+
+func BenchmarkReadRecords(b *testing.B) {
+	b.Run("seq", func(b *testing.B) {
+		runBenchmark(b, bench.Workload{ReadRatio: 1, Clients: 4})
+	})
+	b.Run("random-batch-8", func(b *testing.B) {
+		runBenchmark(b, bench.Workload{ReadRatio: 1, Clients: 4, Pattern: bench.Random, Batch: 8})
+	})
+}
+
+func BenchmarkWriteRecords(b *testing.B) {
+	b.Run("seq", func(b *testing.B) {
+		runBenchmark(b, bench.Workload{Clients: 4})
+	})
+	b.Run("seq-batch-8", func(b *testing.B) {
+		runBenchmark(b, bench.Workload{Clients: 4, Batch: 8})
+	})
+}
@@ -0,0 +1,152 @@
+package bench
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"sort"
+	"time"
+)
+
+// recorder - latencies and errors of calls of one client, so clients do not share locks while load runs
+type recorder struct {
+	reads, writes []time.Duration
+	records       int64
+	errors        map[string]int64
+}
+
+func newRecorder() *recorder {
+	return &recorder{errors: make(map[string]int64)}
+}
+
+func (r *recorder) add(read bool, latency time.Duration, records int, err error) {
+	if err != nil {
+		r.errors[utils.CodeOf(err).String()]++
+		return
+	}
+	if read {
+		r.reads = append(r.reads, latency)
+	} else {
+		r.writes = append(r.writes, latency)
+	}
+	r.records += int64(records)
+}
+
+// Latency - distribution of latencies of succeeded calls
+type Latency struct {
+	Count int64         `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	P999  time.Duration `json:"p999"`
+	Max   time.Duration `json:"max"`
+}
+
+func newLatency(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+	return Latency{
+		Count: int64(len(latencies)),
+		Mean:  total / time.Duration(len(latencies)),
+		P50:   percentile(0.5),
+		P90:   percentile(0.9),
+		P99:   percentile(0.99),
+		P999:  percentile(0.999),
+		Max:   latencies[len(latencies)-1],
+	}
+}
+
+// Result - measurements of the load
+type Result struct {
+	Workload      Workload         `json:"workload"`
+	Elapsed       time.Duration    `json:"elapsed"`
+	Calls         int64            `json:"calls"`   // succeeded calls
+	Records       int64            `json:"records"` // records read or written by succeeded calls
+	Bytes         int64            `json:"bytes"`
+	CallsPerSec   float64          `json:"calls_per_sec"`
+	RecordsPerSec float64          `json:"records_per_sec"`
+	BytesPerSec   float64          `json:"bytes_per_sec"`
+	Reads         Latency          `json:"reads"`
+	Writes        Latency          `json:"writes"`
+	Errors        map[string]int64 `json:"errors,omitempty"` // failed calls by error codes
+	FailedCalls   int64            `json:"failed_calls"`
+}
+
+func newResult(w *Workload, elapsed time.Duration, recorders []*recorder) *Result {
+	res := &Result{Workload: *w, Elapsed: elapsed, Errors: make(map[string]int64)}
+	var reads, writes []time.Duration
+	for _, r := range recorders {
+		reads = append(reads, r.reads...)
+		writes = append(writes, r.writes...)
+		res.Records += r.records
+		for code, count := range r.errors {
+			res.Errors[code] += count
+			res.FailedCalls += count
+		}
+	}
+	res.Reads, res.Writes = newLatency(reads), newLatency(writes)
+	res.Calls = res.Reads.Count + res.Writes.Count
+	res.Bytes = res.Records * int64(w.RecordSize)
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		res.CallsPerSec = float64(res.Calls) / seconds
+		res.RecordsPerSec = float64(res.Records) / seconds
+		res.BytesPerSec = float64(res.Bytes) / seconds
+	}
+	return res
+}
+
+// Print - writes human readable report
+func (res *Result) Print(out io.Writer) {
+	w := res.Workload
+	fmt.Fprintf(out, "workload: %d clients, %d files of %d records of %d bytes, %s ids, batch %d, %.0f%% reads\n",
+		w.Clients, w.Files, w.Records, w.RecordSize, w.Pattern, w.Batch, w.ReadRatio*100)
+	fmt.Fprintf(out, "elapsed:  %v\n", res.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(out, "calls:    %d ok, %d failed\n", res.Calls, res.FailedCalls)
+	fmt.Fprintf(out, "throughput: %.0f calls/s, %.0f records/s, %.2f MB/s\n", res.CallsPerSec, res.RecordsPerSec, res.BytesPerSec/(1<<20))
+	fmt.Fprintf(out, "%-7s %9s %9s %9s %9s %9s %9s %9s\n", "latency", "count", "mean", "p50", "p90", "p99", "p99.9", "max")
+	for _, row := range []struct {
+		name    string
+		latency Latency
+	}{{"read", res.Reads}, {"write", res.Writes}} {
+		l := row.latency
+		if l.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "%-7s %9d %9v %9v %9v %9v %9v %9v\n", row.name, l.Count, round(l.Mean), round(l.P50),
+			round(l.P90), round(l.P99), round(l.P999), round(l.Max))
+	}
+	if len(res.Errors) > 0 {
+		codes := make([]string, 0, len(res.Errors))
+		for code := range res.Errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		fmt.Fprintln(out, "errors:")
+		for _, code := range codes {
+			fmt.Fprintf(out, "  %-16s %d\n", code, res.Errors[code])
+		}
+	}
+}
+
+// round - drops precision of latency which only makes columns wide
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	case d >= time.Microsecond:
+		return d.Round(100 * time.Nanosecond)
+	}
+	return d
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/bench"
+	"github.com/alikhil/distributed-fs/dfstest"
+	"github.com/alikhil/distributed-fs/peer"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Generates load of concurrent clients against master and reports throughput and latency percentiles.
+// Runs against in-process cluster if master endpoint is not set
+
+func main() {
+	masterEndpoint := flag.String("master", os.Getenv("DFS_MASTER"), "rpc endpoint of master (env DFS_MASTER); in-process cluster is started if not set")
+	peers := flag.Int("peers", 3, "number of peers of in-process cluster")
+	backend := flag.String("backend", "dir", "storage of in-process peers: "+strings.Join(peer.Backends(), ", "))
+	token := flag.String("token", os.Getenv("DFS_TOKEN"), "token of the client if master requires authentication (env DFS_TOKEN)")
+	tokenFile := flag.String("token-file", "", "file with token of the client; overrides -token")
+	direct := flag.Bool("direct", false, "read and write records on peers directly; peers should be started with -data-port")
+	tlsFiles := utils.RegisterTLSFlags()
+
+	var w bench.Workload
+	flag.StringVar(&w.FilePrefix, "prefix", "bench", "files are named <prefix>-<n>")
+	flag.IntVar(&w.Files, "files", 1, "number of files")
+	flag.Int64Var(&w.Records, "records", 10000, "records per file")
+	recordSize := flag.Int("record-size", 64, "record size in bytes")
+	flag.Float64Var(&w.ReadRatio, "read-ratio", 0.9, "share of reads among calls")
+	pattern := flag.String("pattern", string(bench.Sequential), "order of record ids: seq or random")
+	flag.IntVar(&w.Batch, "batch", 1, "records read or written by one call")
+	flag.IntVar(&w.Clients, "clients", 8, "number of concurrent clients")
+	flag.DurationVar(&w.Duration, "duration", 10*time.Second, "how long clients work")
+	flag.Int64Var(&w.Ops, "ops", 0, "total number of calls; overrides -duration if set")
+	flag.BoolVar(&w.Prefill, "prefill", true, "write every record before the load")
+	flag.BoolVar(&w.Keep, "keep", false, "do not delete files after the load")
+	flag.Int64Var(&w.Seed, "seed", time.Now().UnixNano(), "seed of random ids and data")
+	jsonOutput := flag.Bool("json", false, "print result as json")
+	verbose := flag.Bool("verbose", false, "print logs of in-process master and peers")
+	logFlags := utils.RegisterLogFlags()
+
+	flag.Parse()
+	w.RecordSize = int32(*recordSize)
+	w.Pattern = bench.Pattern(*pattern)
+
+	var logOutput io.Writer = ioutil.Discard
+	if *verbose {
+		logOutput = os.Stderr
+	}
+	if err := logFlags.Setup(logOutput); err != nil {
+		fail("invalid logging settings", err)
+	}
+
+	var err error
+	if *tokenFile != "" {
+		if *token, err = utils.ReadSecretFile(*tokenFile); err != nil {
+			fail("failed to read token", err)
+		}
+	}
+	var tlsConfig, peerTLS *tls.Config
+	if tlsFiles.Enabled() {
+		tlsConfig, err = tlsFiles.ClientConfig(utils.RoleMaster)
+		if err == nil {
+			peerTLS, err = tlsFiles.ClientConfig(utils.RolePeer)
+		}
+		if err != nil {
+			fail("failed to load TLS certificates", err)
+		}
+	}
+
+	endpoint := *masterEndpoint
+	if endpoint == "" {
+		cluster, err := dfstest.Start(dfstest.Options{Peers: *peers, Backend: *backend, Direct: *direct})
+		if err != nil {
+			fail("failed to start cluster", err)
+		}
+		defer cluster.Stop()
+		endpoint = cluster.MasterEndpoint
+	}
+
+	newClient := func() (*utils.RemoteDFS, error) {
+		client, ok := utils.GetRemoteClientTLS(endpoint, tlsConfig)
+		if !ok {
+			return nil, utils.Errorf(utils.CodeUnavailable, "failed to connect to master %s", endpoint)
+		}
+		return &utils.RemoteDFS{Client: client, Token: *token, Direct: *direct, PeerTLS: peerTLS}, nil
+	}
+
+	result, err := bench.Run(newClient, w)
+	if err != nil {
+		fail("benchmark failed", err)
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+		return
+	}
+	result.Print(os.Stdout)
+}
+
+// fail - prints error and exits. Logs are discarded unless -verbose is set, so they can not be used for it
+func fail(msg string, err error) {
+	fmt.Fprintf(os.Stderr, "dfsbench: %s: %v\n", msg, err)
+	os.Exit(1)
+}
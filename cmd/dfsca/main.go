@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Small certificate authority for local testing of the cluster with mutual TLS.
+// Generates CA of the cluster (or reuses existing one in out dir) and certificates
+// for master, peers and clients with roles used by nodes to check each other.
+
+func main() {
+	out := flag.String("out", "certs", "directory where certificates will be stored")
+	peers := flag.Int("peers", 3, "number of peer certificates")
+	clients := flag.Int("clients", 1, "number of client certificates")
+	hosts := flag.String("hosts", "localhost,127.0.0.1", "comma separated host names and ips added to master and peer certificates")
+	days := flag.Int("days", 365, "validity of certificates in days")
+
+	flag.Parse()
+
+	err := os.MkdirAll(*out, 0700)
+	if err != nil {
+		log.Fatalf("CA: failed to create directory %s: %v", *out, err)
+	}
+
+	validity := time.Duration(*days) * 24 * time.Hour
+	ca, caKey, err := loadOrCreateCA(*out, validity)
+	if err != nil {
+		log.Fatalf("CA: %v", err)
+	}
+
+	hostList := strings.Split(*hosts, ",")
+	if ip := utils.GetIPAddress(); ip != "" {
+		hostList = append(hostList, ip)
+	}
+
+	issue := func(name, role string, hosts []string) {
+		err := issueCert(*out, name, role, hosts, ca, caKey, validity)
+		if err != nil {
+			log.Fatalf("CA: failed to issue certificate %s: %v", name, err)
+		}
+		log.Printf("CA: issued %s certificate %s", role, filepath.Join(*out, name+".pem"))
+	}
+
+	issue("master", utils.RoleMaster, hostList)
+	for i := 1; i <= *peers; i++ {
+		issue(fmt.Sprintf("peer%d", i), utils.RolePeer, hostList)
+	}
+	for i := 1; i <= *clients; i++ {
+		issue(fmt.Sprintf("client%d", i), utils.RoleClient, nil)
+	}
+}
+
+func loadOrCreateCA(dir string, validity time.Duration) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath, keyPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+
+	if certPEM, err := ioutil.ReadFile(certPath); err == nil {
+		keyPEM, err := ioutil.ReadFile(keyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		certBlock, _ := pem.Decode(certPEM)
+		keyBlock, _ := pem.Decode(keyPEM)
+		if certBlock == nil || keyBlock == nil {
+			return nil, nil, fmt.Errorf("failed to decode existing CA in %s", dir)
+		}
+		cert, err := x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("CA: using existing CA %s", certPath)
+		return cert, key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: "distributed-fs cluster CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = writeCert(certPath, keyPath, der, key); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	log.Printf("CA: created CA %s", certPath)
+	return cert, key, err
+}
+
+func issueCert(dir, name, role string, hosts []string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, validity time.Duration) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	usages := []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	if role != utils.RoleClient {
+		// master and peers both accept connections and connect to each other
+		usages = append(usages, x509.ExtKeyUsageServerAuth)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: name, OrganizationalUnit: []string{role}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  usages,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if h != "" {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	return writeCert(filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem"), der, key)
+}
+
+func writeCert(certPath, keyPath string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
+
+func serialNumber() *big.Int {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		log.Fatalf("CA: failed to generate serial number: %v", err)
+	}
+	return n
+}
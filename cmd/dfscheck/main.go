@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/dfstest"
+	"github.com/alikhil/distributed-fs/master"
+	"github.com/alikhil/distributed-fs/peer"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Runs concurrent clients against in-process cluster while peers fail, records history of their reads and writes
+// and checks that every record behaves as linearizable register. Exits with code 1 if it does not
+
+func main() {
+	peers := flag.Int("peers", 3, "number of peers in the cluster")
+	backend := flag.String("backend", "dir", "storage of in-process peers: "+strings.Join(peer.Backends(), ", "))
+	clients := flag.Int("clients", 5, "number of concurrent clients")
+	records := flag.Int64("records", 5, "number of records used as registers; fewer records give more contention")
+	recordSize := flag.Int("record-size", 16, "record size in bytes; at least 8")
+	duration := flag.Duration("duration", 10*time.Second, "how long clients work")
+	readRatio := flag.Float64("read-ratio", 0.5, "share of reads among operations")
+	nemesisNames := flag.String("nemesis", "partition,restart", "failures injected one after another: partition, restart or crash; none if empty")
+	nemesisInterval := flag.Duration("nemesis-interval", time.Second, "how long every failure lasts and how long cluster works without it")
+	chaosSpec := flag.String("chaos", "", "faults injected into all calls to peers, e.g. latency=1ms,jitter=2ms,drop-rate=0.01")
+	direct := flag.Bool("direct", false, "clients read and write peers directly; chaos and crash affect only calls of master")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "seed of clients and failures")
+	historyFile := flag.String("history", "", "file where history of operations is written as json")
+	jsonOutput := flag.Bool("json", false, "print result of the check as json")
+	verbose := flag.Bool("verbose", false, "print logs of master, peers and injected failures")
+	logFlags := utils.RegisterLogFlags()
+
+	flag.Parse()
+	var logOutput io.Writer = ioutil.Discard
+	if *verbose {
+		logOutput = os.Stderr
+	}
+	if err := logFlags.Setup(logOutput); err != nil {
+		fail("invalid logging settings", err)
+	}
+
+	opts := dfstest.WorkloadOptions{
+		Clients:         *clients,
+		Records:         *records,
+		RecordSize:      int32(*recordSize),
+		Duration:        *duration,
+		ReadRatio:       *readRatio,
+		NemesisInterval: *nemesisInterval,
+		Seed:            *seed,
+	}
+	crash := false
+	for _, name := range strings.Split(*nemesisNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Nemesis = append(opts.Nemesis, dfstest.Nemesis(name))
+			crash = crash || dfstest.Nemesis(name) == dfstest.NemesisCrash
+		}
+	}
+
+	var chaos *master.Chaos
+	if *chaosSpec != "" || crash {
+		var err error
+		if chaos, err = master.ParseChaos(*chaosSpec + fmt.Sprintf(",seed=%d", *seed)); err != nil {
+			fail("invalid chaos settings", err)
+		}
+	}
+
+	cluster, err := dfstest.Start(dfstest.Options{Peers: *peers, Chaos: chaos, Backend: *backend, Direct: *direct})
+	if err != nil {
+		fail("failed to start cluster", err)
+	}
+	history, err := cluster.RunRegisters(opts)
+	cluster.Stop()
+	if err != nil {
+		fail("workload failed", err)
+	}
+
+	ops := history.Operations()
+	if *historyFile != "" {
+		data, _ := json.MarshalIndent(ops, "", "  ")
+		if err = ioutil.WriteFile(*historyFile, data, 0644); err != nil {
+			fail("failed to write history", err)
+		}
+	}
+
+	result := dfstest.CheckLinearizable(ops)
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printResult(ops, result, *seed)
+	}
+	if !result.Linearizable {
+		os.Exit(1)
+	}
+}
+
+// maxPrintedOps - how many operations of violation are printed
+const maxPrintedOps = 20
+
+func printResult(ops []dfstest.Operation, result dfstest.CheckResult, seed int64) {
+	counts := make(map[string]int)
+	for _, op := range ops {
+		counts[string(op.Kind)+" "+string(op.Outcome)]++
+	}
+	fmt.Printf("seed: %d\n", seed)
+	fmt.Printf("operations: %d\n", len(ops))
+	for _, kind := range []dfstest.OpKind{dfstest.OpRead, dfstest.OpWrite} {
+		fmt.Printf("  %-5s ok: %d, fail: %d, info: %d\n", kind,
+			counts[string(kind)+" "+string(dfstest.OutcomeOK)],
+			counts[string(kind)+" "+string(dfstest.OutcomeFail)],
+			counts[string(kind)+" "+string(dfstest.OutcomeInfo)])
+	}
+	fmt.Printf("checked: %d operations of %d records\n", result.Operations, result.Records)
+	if result.Linearizable {
+		fmt.Println("result: linearizable")
+		return
+	}
+	fmt.Printf("result: NOT linearizable, %d records violate it\n", len(result.Violations))
+	for _, violation := range result.Violations {
+		fmt.Printf("\nrecord %d: %s\n", violation.Record, violation.Reason)
+		for i, op := range violation.Ops {
+			if i == maxPrintedOps {
+				fmt.Printf("  ... %d more, see -history\n", len(violation.Ops)-i)
+				break
+			}
+			fmt.Printf("  client %d %-5s %#x [%v, %v] %s", op.Client, op.Kind, op.Value,
+				time.Duration(op.Call), time.Duration(op.Return), op.Outcome)
+			if op.Torn {
+				fmt.Print(" torn")
+			}
+			if op.Error != "" {
+				fmt.Printf(" (%s)", op.Error)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// fail - prints error and exits. Logs are discarded unless -verbose is set, so they can not be used for it
+func fail(msg string, err error) {
+	fmt.Fprintf(os.Stderr, "dfscheck: %s: %v\n", msg, err)
+	os.Exit(1)
+}
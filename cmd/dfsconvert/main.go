@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/peer"
+	"github.com/alikhil/distributed-fs/utils"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Copies files of stopped peer from one storage backend to another, e.g. from -fsdir layout to bolt database
+
+func main() {
+	backends := strings.Join(peer.Backends(), ", ")
+	from := flag.String("from", "dir", "backend peer was run with: "+backends)
+	fromDir := flag.String("from-dir", "peer-data", "-fsdir peer was run with")
+	to := flag.String("to", "bolt", "backend to convert files to: "+backends)
+	toDir := flag.String("to-dir", "", "-fsdir peer will be run with; should differ from -from-dir")
+	snapshot := flag.Bool("snapshot", false, "memory backend loads files from and saves them to snapshot")
+
+	flag.Parse()
+
+	if *toDir == "" {
+		utils.Fatal("-to-dir is required")
+	}
+	src, _ := filepath.Abs(*fromDir)
+	dst, _ := filepath.Abs(*toDir)
+	if src == dst {
+		// files of the new backend would be listed among files of the old one
+		utils.Fatal("-to-dir should differ from -from-dir", "dir", src)
+	}
+
+	srcStorage, err := peer.OpenStorage(*from, peer.StorageOptions{Dir: *fromDir, Snapshot: *snapshot})
+	if err != nil {
+		utils.Fatal("failed to open storage to convert", "err", err)
+	}
+	defer srcStorage.Close()
+	dstStorage, err := peer.OpenStorage(*to, peer.StorageOptions{Dir: *toDir, Snapshot: *snapshot})
+	if err != nil {
+		utils.Fatal("failed to open storage to convert to", "err", err)
+	}
+
+	start := time.Now()
+	files, bytes, err := peer.ConvertStorage(dstStorage, srcStorage)
+	if closeErr := dstStorage.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		utils.Fatal("failed to convert storage", "err", err)
+	}
+	fmt.Printf("converted %d files, %d bytes from %s storage in %s to %s storage in %s in %s\n",
+		files, bytes, *from, *fromDir, *to, *toDir, time.Since(start).Round(time.Millisecond))
+}
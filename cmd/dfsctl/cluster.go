@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"os"
+	"text/tabwriter"
+)
+
+func (c *ctl) cluster(args []string) error {
+	if len(args) == 0 || args[0] != "status" {
+		return usageError("use: cluster status")
+	}
+	flags := flag.NewFlagSet("cluster status", flag.ContinueOnError)
+	if err := parseFlags(flags, args[1:], 0, 0); err != nil {
+		return err
+	}
+	admin, err := c.admin()
+	if err != nil {
+		return err
+	}
+	status, err := admin.ClusterStatus()
+	if err != nil {
+		return err
+	}
+	c.printStatus(status)
+	return nil
+}
+
+func (c *ctl) peer(args []string) error {
+	if len(args) == 0 {
+		return usageError("use: peer drain|undrain|remove <endpoint>")
+	}
+	flags := flag.NewFlagSet("peer "+args[0], flag.ContinueOnError)
+	if err := parseFlags(flags, args[1:], 1, 1); err != nil {
+		return err
+	}
+	admin, err := c.admin()
+	if err != nil {
+		return err
+	}
+
+	var action func(endpoint string) (*utils.ClusterStatus, error)
+	switch args[0] {
+	case "drain":
+		action = admin.DrainPeer
+	case "undrain":
+		action = admin.UndrainPeer
+	case "remove":
+		action = admin.RemovePeer
+	default:
+		return usageError(fmt.Sprintf("unknown peer command %q", args[0]))
+	}
+	status, err := action(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	c.printStatus(status)
+	return nil
+}
+
+func (c *ctl) printStatus(status *utils.ClusterStatus) {
+	c.print(status, func() {
+		fmt.Printf("ready to use: %v, peers: %d of %d, consistency: %s\n\n", status.ReadyToUse, len(status.Peers), status.PeersCount, status.Consistency)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SLOT\tENDPOINT\tSTATUS\tDRAINING\tLAST PING\tLATENCY\tFILES\tDISK USAGE\tERROR")
+		for _, peer := range status.Peers {
+			lastPing := "never"
+			if peer.LastPing != nil {
+				lastPing = peer.LastPing.Local().Format("15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%v\t%s\t%.2fms\t%d\t%d\t%s\n", peer.Slot, peer.Endpoint, peer.Status, peer.Draining,
+				lastPing, peer.LatencySeconds*1000, peer.Files, peer.DiskUsageBytes, peer.Error)
+		}
+		w.Flush()
+	})
+}
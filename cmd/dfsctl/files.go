@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+)
+
+// chunkSize - approximate number of bytes sent to master in one call by put and get
+const chunkSize = 1 << 20
+
+// chunkRecords - returns size of chunk which holds whole records
+func chunkRecords(recordSize int32) int32 {
+	if recordSize >= chunkSize {
+		return recordSize
+	}
+	return chunkSize / recordSize * recordSize
+}
+
+func (c *ctl) ls(args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 0, 1); err != nil {
+		return err
+	}
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	files, err := dfs.ListFiles(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	c.print(files, func() {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSIZE\tRECORD SIZE")
+		for _, file := range files {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", file.Name, file.Size, recordSizeText(file.RecordSize))
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func recordSizeText(size int32) string {
+	if size == 0 {
+		return "unknown"
+	}
+	return fmt.Sprint(size)
+}
+
+func (c *ctl) stat(args []string) error {
+	flags := flag.NewFlagSet("stat", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	info, err := dfs.StatFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	c.print(info, func() {
+		fmt.Printf("name:        %s\n", info.Name)
+		fmt.Printf("size:        %d\n", info.Size)
+		fmt.Printf("record size: %s\n", recordSizeText(info.RecordSize))
+		if info.RecordSize > 0 {
+			fmt.Printf("records:     %d\n", info.Size/int64(info.RecordSize))
+		}
+	})
+	return nil
+}
+
+// resolveRecordSize - sets record size of the file if it's passed, otherwise returns record size known by master
+func resolveRecordSize(dfs *utils.RemoteDFS, fname string, size int) (int32, error) {
+	if size < 0 || size > math.MaxInt32 {
+		return 0, usageError(fmt.Sprintf("record size %d is out of range", size))
+	}
+	if size > 0 {
+		return int32(size), dfs.SetRecordSize(fname, int32(size))
+	}
+	recordSize, err := dfs.RecordSize(fname)
+	if utils.CodeOf(err) == utils.CodeInvalidArgument {
+		return 0, fmt.Errorf("%v; pass it with -record-size", err)
+	}
+	return recordSize, err
+}
+
+func (c *ctl) put(args []string) error {
+	flags := flag.NewFlagSet("put", flag.ContinueOnError)
+	rsize := flags.Int("record-size", 0, "record size of the file; record size known by master is used if 0")
+	pad := flags.Bool("pad", false, "pad the last record with zeros if size of local file is not multiple of record size")
+	if err := parseFlags(flags, args, 1, 2); err != nil {
+		return err
+	}
+	local, fname := flags.Arg(0), flags.Arg(1)
+	if fname == "" {
+		if local == "-" {
+			return usageError("name of the file is required when data is read from stdin")
+		}
+		fname = filepath.Base(local)
+	}
+
+	var in io.Reader
+	var size int64
+	if local == "-" {
+		// size of stdin is unknown untill it's read
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		in, size = bytes.NewReader(data), int64(len(data))
+	} else {
+		file, err := os.Open(local)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		stat, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		in, size = file, stat.Size()
+	}
+
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	recordSize, err := resolveRecordSize(dfs, fname, *rsize)
+	if err != nil {
+		return err
+	}
+	info, err := putFile(dfs, fname, in, size, recordSize, *pad)
+	if err != nil {
+		return err
+	}
+	c.print(info, func() {
+		fmt.Printf("%s: %d bytes written\n", info.Name, info.Size)
+	})
+	return nil
+}
+
+// putFile - creates the file in dfs and writes to it size bytes read from r.
+// If pad is set, the last record is padded with zeros
+func putFile(dfs *utils.RemoteDFS, fname string, r io.Reader, size int64, recordSize int32, pad bool) (utils.FileInfo, error) {
+	padded := size
+	if tail := size % int64(recordSize); tail != 0 {
+		if !pad {
+			return utils.FileInfo{}, utils.Errorf(utils.CodeInvalidArgument, "size %d of data is not multiple of record size %d of file(%s); use -pad to pad it", size, recordSize, fname)
+		}
+		padded += int64(recordSize) - tail
+	}
+	if padded > math.MaxInt32 {
+		return utils.FileInfo{}, utils.Errorf(utils.CodeInvalidArgument, "size %d of data is too big for dfs", size)
+	}
+
+	if err := dfs.CreateFile(fname); err != nil {
+		return utils.FileInfo{}, err
+	}
+	chunk := make([]byte, chunkRecords(recordSize))
+	for offset := int64(0); offset < padded; offset += int64(len(chunk)) {
+		if padded-offset < int64(len(chunk)) {
+			chunk = chunk[:padded-offset]
+		}
+		data := int64(len(chunk))
+		if offset+data > size {
+			data = size - offset
+		}
+		if _, err := io.ReadFull(r, chunk[:data]); err != nil {
+			return utils.FileInfo{}, err
+		}
+		for i := data; i < int64(len(chunk)); i++ {
+			chunk[i] = 0
+		}
+		if err := dfs.WriteBytes(fname, int32(offset), &chunk); err != nil {
+			return utils.FileInfo{}, err
+		}
+	}
+	return utils.FileInfo{Name: fname, Size: padded, RecordSize: recordSize}, nil
+}
+
+func (c *ctl) get(args []string, toStdout bool) error {
+	name := "get"
+	maxArgs := 2
+	if toStdout {
+		name, maxArgs = "cat", 1
+	}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	size := flags.Int("record-size", 0, "record size of the file if master does not know it")
+	if err := parseFlags(flags, args, 1, maxArgs); err != nil {
+		return err
+	}
+	fname, local := flags.Arg(0), flags.Arg(1)
+	if toStdout {
+		local = "-"
+	} else if local == "" {
+		local = filepath.Base(fname)
+	}
+
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	if _, err = resolveRecordSize(dfs, fname, *size); err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if local != "-" {
+		file, err := os.Create(local)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	info, err := getFile(dfs, fname, out)
+	if err != nil {
+		return err
+	}
+	if local != "-" {
+		c.print(info, func() {
+			fmt.Printf("%s: %d bytes read\n", info.Name, info.Size)
+		})
+	}
+	return nil
+}
+
+// getFile - reads the whole file from dfs to out
+func getFile(dfs *utils.RemoteDFS, fname string, out io.Writer) (utils.FileInfo, error) {
+	info, err := dfs.StatFile(fname)
+	if err != nil {
+		return info, err
+	}
+	if info.RecordSize == 0 {
+		if info.RecordSize, err = dfs.RecordSize(fname); err != nil {
+			return info, err
+		}
+	}
+
+	chunk := int64(chunkRecords(info.RecordSize))
+	for offset := int64(0); offset < info.Size; offset += chunk {
+		count := info.Size - offset
+		if count > chunk {
+			count = chunk
+		}
+		data, err := dfs.ReadBytes(fname, int32(offset), int32(count))
+		if err != nil {
+			return info, err
+		}
+		if _, err = out.Write(data); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
+
+func (c *ctl) rm(args []string) error {
+	flags := flag.NewFlagSet("rm", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1, -1); err != nil {
+		return err
+	}
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	deleted := make([]string, 0, flags.NArg())
+	for _, fname := range flags.Args() {
+		if err = dfs.DeleteFile(fname); err != nil {
+			break
+		}
+		deleted = append(deleted, fname)
+	}
+	c.print(deleted, func() {})
+	return err
+}
+
+func (c *ctl) mv(args []string) error {
+	flags := flag.NewFlagSet("mv", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 2, 2); err != nil {
+		return err
+	}
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	if err = dfs.RenameFile(flags.Arg(0), flags.Arg(1)); err != nil {
+		return err
+	}
+	c.print(map[string]string{"name": flags.Arg(0), "new_name": flags.Arg(1)}, func() {})
+	return nil
+}
+
+func (c *ctl) mkfile(args []string) error {
+	flags := flag.NewFlagSet("mkfile", flag.ContinueOnError)
+	size := flags.Int("record-size", 0, "record size of the file (required)")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	if *size <= 0 {
+		return usageError("mkfile requires positive -record-size")
+	}
+	fname := flags.Arg(0)
+
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	if _, err = resolveRecordSize(dfs, fname, *size); err != nil {
+		return err
+	}
+	if err = dfs.CreateFile(fname); err != nil {
+		return err
+	}
+	c.print(utils.FileInfo{Name: fname, RecordSize: int32(*size)}, func() {})
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Command line tool to work with files of dfs and to administer the cluster
+
+const usage = `usage: dfsctl [flags] <command> [args]
+
+Files:
+  ls [prefix]                                  list files
+  stat <file>                                  show size and record size of the file
+  put [-record-size N] [-pad] <local> [file]   upload local file ("-" is stdin)
+  get [-record-size N] <file> [local]          download file ("-" is stdout)
+  cat [-record-size N] <file>                  print file
+  rm <file>...                                 delete files
+  mv <file> <new-file>                         rename file
+  mkfile -record-size N <file>                 create empty file with record size
+
+Directories:
+  import [-record-size N] [-parallel N] <local-dir> [prefix]
+                                               copy files of the directory to dfs with names prefixed by prefix
+  export [-record-size N] [-parallel N] <local-dir> [prefix]
+                                               copy files imported with prefix back to the directory
+
+Cluster:
+  cluster status                               show state of master and peers
+  peer drain <endpoint>                        stop changes of records owned by the peer
+  peer undrain <endpoint>                      allow changes of records of the peer again
+  peer remove <endpoint>                       stop drained peer and free its slot
+
+Flags:
+`
+
+// ctl - connections and settings shared by all commands
+type ctl struct {
+	masterEndpoint string
+	adminURL       string
+	token          string
+	json           bool
+	direct         bool
+	tls            *tls.Config
+	peerTLS        *tls.Config
+
+	dfs *utils.RemoteDFS
+}
+
+// usageError - wrong arguments of command; dfsctl exits with code 2 on it
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+func main() {
+	c := &ctl{}
+	flag.StringVar(&c.masterEndpoint, "master", os.Getenv("DFS_MASTER"), "rpc endpoint of master, e.g. 10.91.41.109:5001 (env DFS_MASTER)")
+	flag.StringVar(&c.adminURL, "admin", os.Getenv("DFS_ADMIN"), "endpoint of master admin api, e.g. 10.91.41.109:5080 (env DFS_ADMIN)")
+	flag.StringVar(&c.token, "token", os.Getenv("DFS_TOKEN"), "token of the client if master requires authentication (env DFS_TOKEN)")
+	tokenFile := flag.String("token-file", "", "file with token of the client; overrides -token")
+	flag.BoolVar(&c.json, "json", false, "print results and errors as json")
+	flag.BoolVar(&c.direct, "direct", false, "read and write records on peers directly; peers should be started with -data-port")
+	tlsFiles := utils.RegisterTLSFlags()
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	if *tokenFile != "" {
+		if c.token, err = utils.ReadSecretFile(*tokenFile); err != nil {
+			c.fail(err)
+		}
+	}
+	if tlsFiles.Enabled() {
+		if c.tls, err = tlsFiles.ClientConfig(utils.RoleMaster); err != nil {
+			c.fail(err)
+		}
+		if c.peerTLS, err = tlsFiles.ClientConfig(utils.RolePeer); err != nil {
+			c.fail(err)
+		}
+	}
+
+	if err = c.run(flag.Arg(0), flag.Args()[1:]); err != nil {
+		c.fail(err)
+	}
+}
+
+func (c *ctl) run(command string, args []string) error {
+	switch command {
+	case "ls":
+		return c.ls(args)
+	case "stat":
+		return c.stat(args)
+	case "put":
+		return c.put(args)
+	case "get":
+		return c.get(args, false)
+	case "cat":
+		return c.get(args, true)
+	case "rm":
+		return c.rm(args)
+	case "mv":
+		return c.mv(args)
+	case "mkfile":
+		return c.mkfile(args)
+	case "import":
+		return c.importTree(args)
+	case "export":
+		return c.exportTree(args)
+	case "cluster":
+		return c.cluster(args)
+	case "peer":
+		return c.peer(args)
+	}
+	return usageError(fmt.Sprintf("unknown command %q", command))
+}
+
+// fail - prints error and exits
+func (c *ctl) fail(err error) {
+	code := 1
+	if _, ok := err.(usageError); ok {
+		code = 2
+	}
+	if c.json {
+		json.NewEncoder(os.Stderr).Encode(map[string]string{"code": utils.CodeOf(err).String(), "error": err.Error()})
+	} else {
+		fmt.Fprintf(os.Stderr, "dfsctl: %v\n", err)
+	}
+	if code == 2 && !c.json {
+		fmt.Fprintln(os.Stderr, "run dfsctl -h for usage")
+	}
+	os.Exit(code)
+}
+
+// remote - connects to master on the first use
+func (c *ctl) remote() (*utils.RemoteDFS, error) {
+	if c.dfs != nil {
+		return c.dfs, nil
+	}
+	if c.masterEndpoint == "" {
+		return nil, usageError("endpoint of master is not set; use -master or DFS_MASTER")
+	}
+	client, ok := utils.GetRemoteClientTLS(c.masterEndpoint, c.tls)
+	if !ok {
+		return nil, utils.Errorf(utils.CodeUnavailable, "failed to connect to master %s", c.masterEndpoint)
+	}
+	c.dfs = &utils.RemoteDFS{Client: client, Token: c.token, Direct: c.direct, PeerTLS: c.peerTLS}
+	return c.dfs, nil
+}
+
+// admin - returns client of admin api of master
+func (c *ctl) admin() (*utils.AdminClient, error) {
+	if c.adminURL == "" {
+		return nil, usageError("endpoint of master admin api is not set; use -admin or DFS_ADMIN")
+	}
+	admin := &utils.AdminClient{URL: c.adminURL, Token: c.token}
+	if c.tls != nil {
+		admin.Client = &http.Client{Transport: &http.Transport{TLSClientConfig: c.tls}}
+	}
+	if !strings.Contains(admin.URL, "://") {
+		if c.tls != nil {
+			admin.URL = "https://" + admin.URL
+		} else {
+			admin.URL = "http://" + admin.URL
+		}
+	}
+	return admin, nil
+}
+
+// print - prints result as json if -json is set, otherwise calls printText
+func (c *ctl) print(result interface{}, printText func()) {
+	if c.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+		return
+	}
+	printText()
+}
+
+// parseFlags - parses flags of the command and checks number of its positional arguments
+func parseFlags(flags *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	flags.SetOutput(os.Stderr)
+	if err := flags.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flags.NArg() < minArgs || (maxArgs >= 0 && flags.NArg() > maxArgs) {
+		return usageError(fmt.Sprintf("wrong number of arguments of %s", flags.Name()))
+	}
+	return nil
+}
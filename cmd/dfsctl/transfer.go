@@ -0,0 +1,570 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Trees of local files are copied to dfs as flat files: dfs has no directories, so slashes in paths are escaped.
+// Manifest with original paths, sizes, record sizes and checksums of the files is kept in dfs along with them
+// and in the local directory, so files are copied back with the same record sizes and verified on both sides
+
+const (
+	manifestFile      = "dfs-manifest.json"      // manifest in local directory
+	manifestName      = "%manifest"              // manifest in dfs after prefix; escaped names never contain %m
+	manifestRecord    = 512                      // record size of manifest in dfs
+	importJournalFile = ".dfsctl-import.journal" // files which are already copied by interrupted import
+	exportJournalFile = ".dfsctl-export.journal" // files which are already copied by interrupted export
+)
+
+var nameEscaper = strings.NewReplacer("%", "%25", "/", "%2F")
+var nameUnescaper = strings.NewReplacer("%2F", "/", "%25", "%")
+
+// manifestEntry - file of local tree stored in dfs
+type manifestEntry struct {
+	Path       string `json:"path"` // slash separated path relative to the tree root
+	Name       string `json:"name"` // name in dfs
+	Size       int64  `json:"size"` // size without padding of the last record
+	RecordSize int32  `json:"record_size"`
+	SHA256     string `json:"sha256,omitempty"`
+}
+
+type manifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+// transferSummary - result of import or export
+type transferSummary struct {
+	Files    int   `json:"files"`
+	Copied   int   `json:"copied"`
+	Skipped  int   `json:"skipped"` // copied before interruption
+	Bytes    int64 `json:"bytes"`
+	Verified int   `json:"verified"`
+}
+
+// journalEntry - file copied by import or export. Import skips the file if it's not changed since then
+type journalEntry struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"` // name in dfs, so copy with other prefix is not skipped
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time,omitempty"`
+	SHA256  string `json:"sha256"`
+}
+
+// journal - progress of import or export kept as json lines, so interrupted copy is resumed
+type journal struct {
+	path string
+	file *os.File
+	done map[string]journalEntry
+	lock sync.Mutex
+}
+
+func openJournal(path string) (*journal, error) {
+	j := &journal{path: path, done: make(map[string]journalEntry)}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var entry journalEntry
+			// the last line may be cut by interruption
+			if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+				j.done[entry.Path] = entry
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.file = file
+	return j, nil
+}
+
+func (j *journal) get(path string) (journalEntry, bool) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	entry, ok := j.done[path]
+	return entry, ok
+}
+
+func (j *journal) record(entry journalEntry) error {
+	line, _ := json.Marshal(entry)
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.done[entry.Path] = entry
+	_, err := j.file.Write(append(line, '\n'))
+	return err
+}
+
+// finish - removes journal after successful copy
+func (j *journal) finish() error {
+	j.file.Close()
+	return os.Remove(j.path)
+}
+
+func (j *journal) close() {
+	j.file.Close()
+}
+
+// forEach - calls do for items 0..n-1 by parallel workers and returns error describing all failed items
+func forEach(n, parallel int, do func(i int) error) error {
+	if parallel < 1 {
+		parallel = 1
+	}
+	items := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		failures []error
+	)
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				if err := do(i); err != nil {
+					lock.Lock()
+					failures = append(failures, err)
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		items <- i
+	}
+	close(items)
+	wg.Wait()
+
+	if len(failures) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(failures))
+	for i, err := range failures {
+		msgs[i] = err.Error()
+	}
+	sort.Strings(msgs)
+	return &utils.Error{Code: utils.CodeOf(failures[0]), Err: fmt.Errorf("%d of %d files failed: %s", len(failures), n, strings.Join(msgs, "; "))}
+}
+
+// readManifest - reads manifest from local directory. Missing manifest is empty
+func readManifest(path string) (*manifest, error) {
+	m := &manifest{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	return m, nil
+}
+
+func writeManifest(path string, m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readRemoteManifest - reads manifest kept in dfs. Returns nil if there is no manifest
+func readRemoteManifest(dfs *utils.RemoteDFS, prefix string) (*manifest, error) {
+	name := prefix + manifestName
+	if err := dfs.SetRecordSize(name, manifestRecord); err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	if _, err := getFile(dfs, name, &data); err != nil {
+		if utils.CodeOf(err) == utils.CodeNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	m := &manifest{}
+	// the last record is padded with zeros
+	if err := json.Unmarshal(bytes.TrimRight(data.Bytes(), "\x00"), m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", name, err)
+	}
+	return m, nil
+}
+
+func writeRemoteManifest(dfs *utils.RemoteDFS, prefix string, m *manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	name := prefix + manifestName
+	if err = dfs.SetRecordSize(name, manifestRecord); err != nil {
+		return err
+	}
+	_, err = putFile(dfs, name, bytes.NewReader(data), int64(len(data)), manifestRecord, true)
+	return err
+}
+
+// checksum - returns sha256 of first size bytes of the file in dfs
+func checksum(dfs *utils.RemoteDFS, name string, size int64) (string, error) {
+	hash := sha256.New()
+	if _, err := getFile(dfs, name, &limitedWriter{w: hash, n: size}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// limitedWriter - writes to w only first n bytes; the rest is dropped
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	if int64(len(p)) > lw.n {
+		p = p[:lw.n]
+	}
+	n, err := lw.w.Write(p)
+	lw.n -= int64(n)
+	if err != nil {
+		return n, err
+	}
+	return written, nil
+}
+
+func (c *ctl) importTree(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	defaultSize := flags.Int("record-size", 0, "record size of files which are not in manifest of the directory")
+	parallel := flags.Int("parallel", 4, "number of files copied at the same time")
+	if err := parseFlags(flags, args, 1, 2); err != nil {
+		return err
+	}
+	root, prefix := flags.Arg(0), flags.Arg(1)
+
+	known, err := readManifest(filepath.Join(root, manifestFile))
+	if err != nil {
+		return err
+	}
+	recordSizes := make(map[string]int32, len(known.Files))
+	for _, entry := range known.Files {
+		recordSizes[entry.Path] = entry.RecordSize
+	}
+
+	var files []manifestEntry
+	var missing []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == manifestFile || rel == importJournalFile || rel == exportJournalFile {
+			return nil
+		}
+		size, ok := recordSizes[rel]
+		if !ok {
+			size = int32(*defaultSize)
+		}
+		if size <= 0 {
+			missing = append(missing, rel)
+		}
+		files = append(files, manifestEntry{Path: rel, Name: prefix + nameEscaper.Replace(rel), Size: info.Size(), RecordSize: size})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return utils.Errorf(utils.CodeInvalidArgument, "record sizes of %s are unknown; add them to %s or pass -record-size", strings.Join(missing, ", "), manifestFile)
+	}
+
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	progress, err := openJournal(filepath.Join(root, importJournalFile))
+	if err != nil {
+		return err
+	}
+	defer progress.close()
+
+	summary := transferSummary{Files: len(files)}
+	var summaryLock sync.Mutex
+	err = forEach(len(files), *parallel, func(i int) error {
+		entry := &files[i]
+		stat, err := os.Stat(filepath.Join(root, filepath.FromSlash(entry.Path)))
+		if err != nil {
+			return err
+		}
+		if done, ok := progress.get(entry.Path); ok && done.Name == entry.Name && done.Size == stat.Size() && done.ModTime == stat.ModTime().UnixNano() {
+			entry.SHA256 = done.SHA256
+			summaryLock.Lock()
+			summary.Skipped++
+			summaryLock.Unlock()
+			return nil
+		}
+
+		sum, err := importFile(dfs, root, entry)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		entry.SHA256 = sum
+		summaryLock.Lock()
+		summary.Copied++
+		summary.Bytes += entry.Size
+		summaryLock.Unlock()
+		return progress.record(journalEntry{Path: entry.Path, Name: entry.Name, Size: stat.Size(), ModTime: stat.ModTime().UnixNano(), SHA256: sum})
+	})
+	if err != nil {
+		return err
+	}
+
+	result := &manifest{Files: files}
+	if err = writeRemoteManifest(dfs, prefix, result); err != nil {
+		return err
+	}
+	if err = writeManifest(filepath.Join(root, manifestFile), result); err != nil {
+		return err
+	}
+
+	// files are read back, so whatever is lost on the way is found
+	err = forEach(len(files), *parallel, func(i int) error {
+		entry := &files[i]
+		sum, err := checksum(dfs, entry.Name, entry.Size)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		if sum != entry.SHA256 {
+			return utils.Errorf(utils.CodeChecksumMismatch, "%s: checksum of file(%s) in dfs differs from local one", entry.Path, entry.Name)
+		}
+		summaryLock.Lock()
+		summary.Verified++
+		summaryLock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = progress.finish(); err != nil {
+		return err
+	}
+
+	c.printSummary("imported", summary)
+	return nil
+}
+
+// importFile - copies local file to dfs and returns its checksum
+func importFile(dfs *utils.RemoteDFS, root string, entry *manifestEntry) (string, error) {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(entry.Path)))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err = dfs.SetRecordSize(entry.Name, entry.RecordSize); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err = putFile(dfs, entry.Name, io.TeeReader(file, hash), entry.Size, entry.RecordSize, true); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *ctl) exportTree(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	defaultSize := flags.Int("record-size", 0, "record size of files which are not in manifest and which record size is unknown to master")
+	parallel := flags.Int("parallel", 4, "number of files copied at the same time")
+	if err := parseFlags(flags, args, 1, 2); err != nil {
+		return err
+	}
+	root, prefix := flags.Arg(0), flags.Arg(1)
+
+	dfs, err := c.remote()
+	if err != nil {
+		return err
+	}
+	m, err := readRemoteManifest(dfs, prefix)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		// files were not imported, so they are taken as they are
+		if m, err = listTree(dfs, prefix, int32(*defaultSize)); err != nil {
+			return err
+		}
+	}
+	files := m.Files
+	for _, entry := range files {
+		if !strings.HasPrefix(entry.Name, prefix) {
+			return utils.Errorf(utils.CodeInvalidArgument, "file(%s) of manifest is out of prefix %q", entry.Name, prefix)
+		}
+		clean := filepath.Clean(filepath.FromSlash(entry.Path))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return utils.Errorf(utils.CodeInvalidArgument, "path %s of file(%s) is out of directory", entry.Path, entry.Name)
+		}
+		if clean == "." {
+			// file named as the prefix would be written over the directory itself
+			return utils.Errorf(utils.CodeInvalidArgument, "file(%s) has no path after prefix %q", entry.Name, prefix)
+		}
+	}
+
+	if err = os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	progress, err := openJournal(filepath.Join(root, exportJournalFile))
+	if err != nil {
+		return err
+	}
+	defer progress.close()
+
+	summary := transferSummary{Files: len(files)}
+	var summaryLock sync.Mutex
+	err = forEach(len(files), *parallel, func(i int) error {
+		entry := &files[i]
+		if done, ok := progress.get(entry.Path); ok && done.Name == entry.Name && done.Size == entry.Size && (entry.SHA256 == "" || done.SHA256 == entry.SHA256) {
+			entry.SHA256 = done.SHA256
+			summaryLock.Lock()
+			summary.Skipped++
+			summaryLock.Unlock()
+			return nil
+		}
+
+		sum, err := exportFile(dfs, root, entry)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		if entry.SHA256 != "" && sum != entry.SHA256 {
+			return utils.Errorf(utils.CodeChecksumMismatch, "%s: checksum of file(%s) differs from the one in manifest", entry.Path, entry.Name)
+		}
+		entry.SHA256 = sum
+		summaryLock.Lock()
+		summary.Copied++
+		summary.Bytes += entry.Size
+		summaryLock.Unlock()
+		return progress.record(journalEntry{Path: entry.Path, Name: entry.Name, Size: entry.Size, SHA256: sum})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = writeManifest(filepath.Join(root, manifestFile), &manifest{Files: files}); err != nil {
+		return err
+	}
+
+	// local files are read back, so whatever is lost on the way is found
+	err = forEach(len(files), *parallel, func(i int) error {
+		entry := &files[i]
+		sum, err := localChecksum(filepath.Join(root, filepath.FromSlash(entry.Path)))
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		if sum != entry.SHA256 {
+			return utils.Errorf(utils.CodeChecksumMismatch, "%s: checksum of local file differs from file(%s) in dfs", entry.Path, entry.Name)
+		}
+		summaryLock.Lock()
+		summary.Verified++
+		summaryLock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = progress.finish(); err != nil {
+		return err
+	}
+
+	c.printSummary("exported", summary)
+	return nil
+}
+
+// listTree - makes manifest of files with prefix which were not imported
+func listTree(dfs *utils.RemoteDFS, prefix string, defaultSize int32) (*manifest, error) {
+	list, err := dfs.ListFiles(prefix)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{Files: []manifestEntry{}}
+	for _, file := range list {
+		if file.RecordSize == 0 {
+			if defaultSize <= 0 {
+				return nil, utils.Errorf(utils.CodeInvalidArgument, "record size of file(%s) is unknown; pass -record-size", file.Name)
+			}
+			file.RecordSize = defaultSize
+		}
+		path := nameUnescaper.Replace(strings.TrimPrefix(file.Name, prefix))
+		m.Files = append(m.Files, manifestEntry{Path: path, Name: file.Name, Size: file.Size, RecordSize: file.RecordSize})
+	}
+	return m, nil
+}
+
+// exportFile - copies file from dfs to local one and returns its checksum.
+// File is written to temporary file first, so interrupted copy never looks complete
+func exportFile(dfs *utils.RemoteDFS, root string, entry *manifestEntry) (string, error) {
+	// master forgets record sizes on restart
+	if err := dfs.SetRecordSize(entry.Name, entry.RecordSize); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(root, filepath.FromSlash(entry.Path))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	tmp := path + ".dfsctl-tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+
+	hash := sha256.New()
+	_, err = getFile(dfs, entry.Name, &limitedWriter{w: io.MultiWriter(file, hash), n: entry.Size})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func localChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *ctl) printSummary(verb string, summary transferSummary) {
+	c.print(summary, func() {
+		fmt.Printf("%s %d files (%d bytes), %d were copied before, %d verified\n", verb, summary.Copied, summary.Bytes, summary.Skipped, summary.Verified)
+	})
+}
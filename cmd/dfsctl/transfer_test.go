@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/alikhil/distributed-fs/dfstest"
+	"github.com/alikhil/distributed-fs/utils"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// startCtl - starts test cluster which is stopped when the test ends and returns dfsctl connected to it
+func startCtl(t *testing.T) *ctl {
+	t.Helper()
+	c, err := dfstest.Start(dfstest.Options{})
+	if err != nil {
+		t.Fatalf("failed to start cluster: %v", err)
+	}
+	t.Cleanup(func() { c.Stop() })
+	return &ctl{dfs: c.DFS, json: true}
+}
+
+// transfer - runs import or export and returns its summary
+func transfer(t *testing.T, c *ctl, command string, args ...string) (transferSummary, error) {
+	t.Helper()
+	out, err := ioutil.TempFile(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	err = c.run(command, args)
+	os.Stdout = stdout
+
+	var summary transferSummary
+	if err == nil {
+		out.Seek(0, 0)
+		if decodeErr := json.NewDecoder(out).Decode(&summary); decodeErr != nil {
+			t.Fatalf("failed to decode summary of %s: %v", command, decodeErr)
+		}
+	}
+	return summary, err
+}
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, data := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree - reads files of the directory except ones kept by import and export
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if rel == manifestFile || rel == importJournalFile || rel == exportJournalFile {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		files[rel] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func checkTree(t *testing.T, root string, want map[string]string) {
+	t.Helper()
+	if got := readTree(t, root); !reflect.DeepEqual(got, want) {
+		t.Fatalf("directory has files %q, want %q", got, want)
+	}
+}
+
+func TestImportExportEscapedNames(t *testing.T) {
+	c := startCtl(t)
+	tree := map[string]string{
+		"a/b":       "1111",
+		"a%2Fb":     "2222",
+		"a%252Fb":   "3333",
+		"%manifest": "4444",
+		"c%":        "5555",
+	}
+	src := t.TempDir()
+	writeTree(t, src, tree)
+	if _, err := transfer(t, c, "import", "-record-size", "4", src, "t-"); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	// names of files differ in dfs, so none of them overwrites another one or the manifest
+	list, err := c.dfs.ListFiles("t-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(tree)+1 {
+		t.Fatalf("dfs has files %v, want %d files and manifest", list, len(tree))
+	}
+
+	dst := t.TempDir()
+	if _, err = transfer(t, c, "export", dst, "t-"); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	checkTree(t, dst, tree)
+
+	// without manifest paths are taken from escaped names
+	if err = c.dfs.DeleteFile("t-" + manifestName); err != nil {
+		t.Fatal(err)
+	}
+	dst = t.TempDir()
+	if _, err = transfer(t, c, "export", "-record-size", "4", dst, "t-"); err != nil {
+		t.Fatalf("failed to export without manifest: %v", err)
+	}
+	checkTree(t, dst, tree)
+}
+
+func TestExportRejectsFileNamedAsPrefix(t *testing.T) {
+	c := startCtl(t)
+	if err := c.dfs.SetRecordSize("backup", 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := putFile(c.dfs, "backup", strings.NewReader("1111"), 4, 4, false); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "backup")
+	_, err := transfer(t, c, "export", root, "backup")
+	if code := utils.CodeOf(err); code != utils.CodeInvalidArgument {
+		t.Fatalf("export of file named as prefix failed with %v (%v), want %v", code, err, utils.CodeInvalidArgument)
+	}
+	// nothing is written next to the directory
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "backup" {
+			t.Errorf("export left %s next to the directory", entry.Name())
+		}
+	}
+}
+
+func TestImportResumesPartialCopy(t *testing.T) {
+	c := startCtl(t)
+	tree := map[string]string{"a": "1111", "b": "22222222", "c": "3333"}
+	src := t.TempDir()
+	writeTree(t, src, tree)
+
+	// interrupted import copied a, started to copy b and cut the last line of the journal
+	progress, err := openJournal(filepath.Join(src, importJournalFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := manifestEntry{Path: "a", Name: "p-a", Size: 4, RecordSize: 4}
+	sum, err := importFile(c.dfs, src, &entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(filepath.Join(src, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = progress.record(journalEntry{Path: "a", Name: "p-a", Size: 4, ModTime: stat.ModTime().UnixNano(), SHA256: sum}); err != nil {
+		t.Fatal(err)
+	}
+	progress.file.WriteString(`{"path":"b","na`)
+	progress.close()
+	if err = c.dfs.SetRecordSize("p-b", 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = putFile(c.dfs, "p-b", strings.NewReader("xxxxxxxxxxxx"), 12, 4, false); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := transfer(t, c, "import", "-record-size", "4", src, "p-")
+	if err != nil {
+		t.Fatalf("failed to resume import: %v", err)
+	}
+	if summary.Skipped != 1 || summary.Copied != 2 || summary.Verified != 3 {
+		t.Fatalf("resumed import %+v, want 1 file skipped, 2 copied and 3 verified", summary)
+	}
+	if _, err = os.Stat(filepath.Join(src, importJournalFile)); !os.IsNotExist(err) {
+		t.Fatalf("journal of finished import is kept: %v", err)
+	}
+	dst := t.TempDir()
+	if _, err = transfer(t, c, "export", dst, "p-"); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	checkTree(t, dst, tree)
+}
+
+func TestImportDoesNotSkipFilesCopiedWithOtherPrefix(t *testing.T) {
+	c := startCtl(t)
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a": "1111", "b": "2222"})
+	if _, err := transfer(t, c, "import", "-record-size", "4", src, "p-"); err != nil {
+		t.Fatal(err)
+	}
+
+	// journal of import interrupted right before it was removed
+	progress, err := openJournal(filepath.Join(src, importJournalFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"a", "b"} {
+		stat, err := os.Stat(filepath.Join(src, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum, err := localChecksum(filepath.Join(src, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = progress.record(journalEntry{Path: path, Name: "p-" + path, Size: stat.Size(), ModTime: stat.ModTime().UnixNano(), SHA256: sum}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	progress.close()
+
+	summary, err := transfer(t, c, "import", src, "q-")
+	if err != nil {
+		t.Fatalf("failed to import with other prefix: %v", err)
+	}
+	if summary.Skipped != 0 || summary.Copied != 2 {
+		t.Fatalf("import with other prefix %+v, want 2 files copied", summary)
+	}
+}
+
+func TestExportResumesPartialCopy(t *testing.T) {
+	c := startCtl(t)
+	tree := map[string]string{"a": "1111", "b": "2222", "d/c": "3333"}
+	src := t.TempDir()
+	writeTree(t, src, tree)
+	if _, err := transfer(t, c, "import", "-record-size", "4", src, "p-"); err != nil {
+		t.Fatal(err)
+	}
+
+	// b can not be written over the directory, so export stops with a and d/c copied
+	dst := t.TempDir()
+	writeTree(t, dst, map[string]string{"b/x": "x"})
+	if _, err := transfer(t, c, "export", "-parallel", "1", dst, "p-"); err == nil {
+		t.Fatal("export over the directory succeeded")
+	}
+	if err := os.RemoveAll(filepath.Join(dst, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := transfer(t, c, "export", dst, "p-")
+	if err != nil {
+		t.Fatalf("failed to resume export: %v", err)
+	}
+	if summary.Skipped != 2 || summary.Copied != 1 || summary.Verified != 3 {
+		t.Fatalf("resumed export %+v, want 2 files skipped, 1 copied and 3 verified", summary)
+	}
+	checkTree(t, dst, tree)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"io/ioutil"
+	"log"
+	"time"
+)
+
+// Issues token for client of the cluster signed by the same secret as master uses
+
+func main() {
+	secretFile := flag.String("secret-file", "", "file with secret shared with master")
+	subject := flag.String("subject", "", "name of the client used in ACL rules")
+	ttl := flag.Duration("ttl", 24*time.Hour, "how long token is valid; 0 means forever")
+
+	flag.Parse()
+
+	if *secretFile == "" || *subject == "" {
+		log.Fatalf("Token: both -secret-file and -subject are required")
+	}
+
+	secret, err := ioutil.ReadFile(*secretFile)
+	if err != nil {
+		log.Fatalf("Token: failed to read secret: %v", err)
+	}
+
+	token, err := utils.NewToken(bytes.TrimSpace(secret), *subject, *ttl)
+	if err != nil {
+		log.Fatalf("Token: failed to issue token: %v", err)
+	}
+	fmt.Println(token)
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
+	"github.com/alikhil/distributed-fs/dfspb"
+	"github.com/alikhil/distributed-fs/master"
 	"github.com/alikhil/distributed-fs/utils"
+	"google.golang.org/grpc"
+	"io"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 // Implements remote interface for IO
@@ -18,22 +24,108 @@ import (
 type masterServer struct {
 	running     bool
 	rpcListener *net.Listener
-	dfs         *DistributedFileSystem
+	grpcServer  *grpc.Server
+	dfs         *master.DistributedFileSystem
 }
 
 func main() {
 	peersCount := flag.Int("peers", 3, "numbers of peers in DFS")
 	silent := flag.Bool("silent", false, "if true no log will be printed")
+	grpcPort := flag.Int("grpc-port", 0, "port for grpc api of master; grpc is disabled if 0")
+	consistencyName := flag.String("consistency", "all", "how many peers should succeed in create, delete and file exists requests: all, quorum or any")
+	tlsFiles := utils.RegisterTLSFlags()
+	tracing := utils.RegisterTracingFlags()
+	logFlags := utils.RegisterLogFlags()
+	secretFile := flag.String("auth-secret-file", "", "file with secret used to verify client tokens; enables authentication of clients")
+	aclFile := flag.String("acl", "", "json file with access rules of clients; if not set any authenticated client can do everything")
+	auditFile := flag.String("audit-log", "", "file where denied calls are recorded; master log is used if not set")
+	metricsPort := flag.Int("metrics-port", 0, "port for prometheus /metrics endpoint; metrics are disabled if 0")
+	joinTokenFile := flag.String("join-token-file", "", "file with token which peers should present to join the cluster")
+	adminPort := flag.Int("admin-port", 0, "port for admin http api and status page; admin api is disabled if 0")
+	accessTokenTTL := flag.Duration("access-token-ttl", time.Minute, "how long tokens which let clients access peers directly are valid")
+	chaosSpec := flag.String("chaos", "", "faults injected into calls to peers, e.g. latency=5ms,jitter=5ms,error-rate=0.01,drop-rate=0.01,crash-rate=0.001,downtime=2s,seed=1; for testing only")
 
 	flag.Parse()
+	var logOutput io.Writer = os.Stderr
 	if *silent {
-		log.SetOutput(ioutil.Discard)
+		logOutput = ioutil.Discard
+	}
+	if err := logFlags.Setup(logOutput); err != nil {
+		utils.Fatal("invalid logging settings", "err", err)
+	}
+
+	consistency, err := master.ParseConsistency(*consistencyName)
+	if err != nil {
+		utils.Fatal("invalid consistency", "err", err)
+	}
+
+	if tracing.Enabled() {
+		flushSpans, err := tracing.Setup("dfs-master")
+		if err != nil {
+			utils.Fatal("failed to set up tracing", "err", err)
+		}
+		defer flushSpans()
+	}
+
+	mserver := &masterServer{dfs: &master.DistributedFileSystem{RemoteInterface: &master.RemoteFS{PeersCount: *peersCount, Consistency: consistency, AccessTokenTTL: *accessTokenTTL}}}
+
+	if *chaosSpec != "" {
+		mserver.dfs.RemoteInterface.Chaos, err = master.ParseChaos(*chaosSpec)
+		if err != nil {
+			utils.Fatal("invalid chaos settings", "err", err)
+		}
+		slog.Warn("chaos is enabled; calls to peers are delayed and failed on purpose", "chaos", *chaosSpec)
+	}
+
+	if *joinTokenFile != "" {
+		mserver.dfs.RemoteInterface.JoinToken, err = utils.ReadSecretFile(*joinTokenFile)
+		if err != nil {
+			utils.Fatal("failed to read join token", "err", err)
+		}
 	}
 
-	mserver := &masterServer{dfs: &DistributedFileSystem{RemoteInterface: &RemoteFS{PeersCount: *peersCount}}}
+	if *secretFile != "" {
+		mserver.dfs.RemoteInterface.Auth, err = master.LoadAuthorizer(*secretFile, *aclFile, *auditFile)
+		if err != nil {
+			utils.Fatal("failed to load authorization settings", "err", err)
+		}
+	}
+
+	// peers and clients connect to rpc endpoint, only clients use grpc and admin ones
+	var rpcTLS, grpcTLS *tls.Config
+	if tlsFiles.Enabled() {
+		rpcTLS, err = tlsFiles.ServerConfig(utils.RolePeer, utils.RoleClient)
+		if err == nil {
+			grpcTLS, err = tlsFiles.ServerConfig(utils.RoleClient)
+		}
+		if err == nil {
+			mserver.dfs.RemoteInterface.PeerTLS, err = tlsFiles.ClientConfig(utils.RolePeer)
+		}
+		if err != nil {
+			utils.Fatal("failed to load TLS certificates", "err", err)
+		}
+	}
+
+	var rpcMetrics *utils.RPCMetrics
+	if *metricsPort != 0 {
+		reg := utils.NewMetricsRegistry()
+		rpcMetrics = utils.NewRPCMetrics(reg)
+		mserver.dfs.RemoteInterface.Metrics = master.NewMetrics(reg)
+		go utils.RunMetrics(reg, *metricsPort)
+	}
+
+	if *grpcPort != 0 {
+		mserver.grpcServer = utils.NewGRPCServer(grpcTLS, rpcMetrics)
+		dfspb.RegisterRemoteIOServer(mserver.grpcServer, master.NewGRPCRemoteIO(mserver.dfs.RemoteInterface))
+		go utils.RunGRPC(mserver.grpcServer, *grpcPort)
+	}
+
+	if *adminPort != 0 {
+		go master.RunAdmin(mserver.dfs.RemoteInterface, *adminPort, grpcTLS)
+	}
 
 	handleSignals(mserver)
-	utils.RunRPC("RemoteIO", mserver.dfs.RemoteInterface, utils.GetRPCPort(), &mserver.running, &mserver.rpcListener)
+	utils.RunRPCWithTLS("RemoteIO", mserver.dfs.RemoteInterface, utils.GetRPCPort(), &mserver.running, &mserver.rpcListener, rpcTLS, rpcMetrics)
 }
 
 func handleSignals(server *masterServer) {
@@ -45,9 +137,12 @@ func handleSignals(server *masterServer) {
 
 	go func() {
 		s := <-sigc
-		log.Printf("Recived signal from keyboard: %s stopping master and peers", s.String())
+		slog.Info("received signal; stopping master and peers", "signal", s.String())
 		server.dfs.CloseConnections()
 		server.running = false
+		if server.grpcServer != nil {
+			server.grpcServer.Stop()
+		}
 		(*server.rpcListener).Close()
 	}()
 }
@@ -1,54 +1,117 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"github.com/alikhil/distributed-fs/dfspb"
+	"github.com/alikhil/distributed-fs/peer"
 	"github.com/alikhil/distributed-fs/utils"
+	"io"
 	"io/ioutil"
-	"log"
-	"net/rpc"
 	"os"
+	"strings"
 )
 
 func main() {
 	remoteEndpoint := flag.String("endpoint", "10.91.41.109:5001", "endpoint of master node")
 	port := flag.Int("port", 5002, "port for rpc connection from master node")
 	fsDir := flag.String("fsdir", "peer-data", "directory where all files of the peer will be stored")
+	backend := flag.String("backend", "dir", "storage of files of the peer: "+strings.Join(peer.Backends(), ", "))
+	memoryLimit := flag.Int64("memory-limit-mb", 0, "megabytes memory backend may take; writes which need more fail. Unlimited if 0")
+	snapshot := flag.Bool("snapshot", false, "memory backend saves files to -fsdir when peer stops and loads them on start")
+	segmentSize := flag.Int64("segment-size-mb", 64, "megabytes after which log backend starts new segment")
 	silent := flag.Bool("silent", false, "if true no log will be printed")
+	grpcPort := flag.Int("grpc-port", 0, "port for grpc api of peer; grpc is disabled if 0")
+	dataPort := flag.Int("data-port", 0, "port where clients read and write records directly with token given by master; disabled if 0")
+	metricsPort := flag.Int("metrics-port", 0, "port for prometheus /metrics endpoint; metrics are disabled if 0")
+	joinTokenFile := flag.String("join-token-file", "", "file with token required by master to join the cluster")
+	tlsFiles := utils.RegisterTLSFlags()
+	tracing := utils.RegisterTracingFlags()
+	logFlags := utils.RegisterLogFlags()
 
 	flag.Parse()
+	var logOutput io.Writer = os.Stderr
 	if *silent {
-		log.SetOutput(ioutil.Discard)
+		logOutput = ioutil.Discard
+	}
+	if err := logFlags.Setup(logOutput); err != nil {
+		utils.Fatal("invalid logging settings", "err", err)
 	}
 
-	log.Printf("Peer: Connecting to master with endpoint %v", *remoteEndpoint)
+	// only master is allowed to connect to peer, clients are allowed only to data port
+	var serverTLS, dataTLS, masterTLS *tls.Config
+	if tlsFiles.Enabled() {
+		var err error
+		serverTLS, err = tlsFiles.ServerConfig(utils.RoleMaster)
+		if err == nil {
+			dataTLS, err = tlsFiles.ServerConfig(utils.RoleClient, utils.RoleMaster)
+		}
+		if err == nil {
+			masterTLS, err = tlsFiles.ClientConfig(utils.RoleMaster)
+		}
+		if err != nil {
+			utils.Fatal("failed to load TLS certificates", "err", err)
+		}
+	}
 
-	client, ok := utils.GetRemoteClient(*remoteEndpoint)
-	if !ok {
-		log.Printf("RPC: cannot connect to endpoint %v", remoteEndpoint)
-		return
+	var joinToken string
+	if *joinTokenFile != "" {
+		var err error
+		joinToken, err = utils.ReadSecretFile(*joinTokenFile)
+		if err != nil {
+			utils.Fatal("failed to read join token", "err", err)
+		}
 	}
 
-	master := master{client: client}
-	err := master.connectAsPeer(*port)
+	if tracing.Enabled() {
+		flushSpans, err := tracing.Setup("dfs-peer")
+		if err != nil {
+			utils.Fatal("failed to set up tracing", "err", err)
+		}
+		defer flushSpans()
+	}
+
+	storage, err := peer.OpenStorage(*backend, peer.StorageOptions{Dir: *fsDir, MemoryLimit: *memoryLimit << 20, Snapshot: *snapshot, SegmentSize: *segmentSize << 20})
 	if err != nil {
-		log.Fatalf("RPC: failed to connect as a peer: %v", err)
+		utils.Fatal("failed to open storage of the peer", "err", err)
 	}
+	fs := peer.NewLocalFSWithStorage(storage)
 
-	os.MkdirAll(*fsDir, os.ModePerm)
+	utils.Logger(utils.LogHealth).Info("connecting to master", "master", *remoteEndpoint)
 
-	fs := localFS{fsDir: fsDir}
-	utils.RunRPC("PeerFS", &fs, *port, &fs.isRPCRunning, &fs.rpcListener)
-	return
-}
+	client, ok := utils.GetRemoteClientTLS(*remoteEndpoint, masterTLS)
+	if !ok {
+		utils.Logger(utils.LogHealth).Error("cannot connect to master", "master", *remoteEndpoint)
+		return
+	}
 
-type master struct {
-	client *rpc.Client
-}
+	endpoint := fmt.Sprintf("%s:%d", utils.GetIPAddress(), *port)
+	if *dataPort != 0 {
+		fs.DataEndpoint = fmt.Sprintf("%s:%d", utils.GetIPAddress(), *dataPort)
+	}
+	if err = fs.Join(client, endpoint, joinToken); err != nil {
+		utils.Fatal("failed to connect as a peer", "err", err)
+	}
 
-func (m *master) connectAsPeer(port int) error {
-	endpoint := fmt.Sprintf("%s:%d", utils.GetIPAddress(), port)
-	var ok bool
-	err := m.client.Call("RemoteIO.AddPeer", &endpoint, &ok)
-	return err
+	var rpcMetrics *utils.RPCMetrics
+	if *metricsPort != 0 {
+		reg := utils.NewMetricsRegistry()
+		rpcMetrics = utils.NewRPCMetrics(reg)
+		fs.Metrics = peer.NewMetrics(reg, storage)
+		go utils.RunMetrics(reg, *metricsPort)
+	}
+
+	if *grpcPort != 0 {
+		fs.GRPCServer = utils.NewGRPCServer(serverTLS, rpcMetrics)
+		dfspb.RegisterPeerFSServer(fs.GRPCServer, peer.NewGRPCPeerFS(fs))
+		go utils.RunGRPC(fs.GRPCServer, *grpcPort)
+	}
+	if *dataPort != 0 {
+		go fs.RunDataRPC(*dataPort, dataTLS, rpcMetrics)
+	}
+	fs.RunRPC(*port, serverTLS, rpcMetrics)
+	if err = fs.CloseStorage(); err != nil {
+		utils.Fatal("failed to close storage of the peer", "err", err)
+	}
 }
@@ -0,0 +1,1131 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: dfs.proto
+
+package dfspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_dfs_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{0}
+}
+
+type RecordMappings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RecordSizes   map[string]int32       `protobuf:"bytes,1,rep,name=record_sizes,json=recordSizes,proto3" json:"record_sizes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordMappings) Reset() {
+	*x = RecordMappings{}
+	mi := &file_dfs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordMappings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordMappings) ProtoMessage() {}
+
+func (x *RecordMappings) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordMappings.ProtoReflect.Descriptor instead.
+func (*RecordMappings) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RecordMappings) GetRecordSizes() map[string]int32 {
+	if x != nil {
+		return x.RecordSizes
+	}
+	return nil
+}
+
+type FileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileRequest) Reset() {
+	*x = FileRequest{}
+	mi := &file_dfs_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileRequest) ProtoMessage() {}
+
+func (x *FileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileRequest.ProtoReflect.Descriptor instead.
+func (*FileRequest) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FileRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+type ExistsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exists        bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExistsReply) Reset() {
+	*x = ExistsReply{}
+	mi := &file_dfs_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExistsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExistsReply) ProtoMessage() {}
+
+func (x *ExistsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExistsReply.ProtoReflect.Descriptor instead.
+func (*ExistsReply) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExistsReply) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+type FileSizeReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileSizeReply) Reset() {
+	*x = FileSizeReply{}
+	mi := &file_dfs_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileSizeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileSizeReply) ProtoMessage() {}
+
+func (x *FileSizeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileSizeReply.ProtoReflect.Descriptor instead.
+func (*FileSizeReply) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FileSizeReply) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type ReadRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filename string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Offset   int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Count    int64                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	// if true reading of never written records fails with NotWritten error
+	FailOnHole    bool `protobuf:"varint,4,opt,name=fail_on_hole,json=failOnHole,proto3" json:"fail_on_hole,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadRequest) Reset() {
+	*x = ReadRequest{}
+	mi := &file_dfs_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadRequest) ProtoMessage() {}
+
+func (x *ReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadRequest.ProtoReflect.Descriptor instead.
+func (*ReadRequest) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReadRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ReadRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReadRequest) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ReadRequest) GetFailOnHole() bool {
+	if x != nil {
+		return x.FailOnHole
+	}
+	return false
+}
+
+// Chunk - part of the file. Filename is required only in chunks sent by client
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_dfs_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Chunk) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Chunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Chunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type AppendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendRequest) Reset() {
+	*x = AppendRequest{}
+	mi := &file_dfs_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendRequest) ProtoMessage() {}
+
+func (x *AppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendRequest.ProtoReflect.Descriptor instead.
+func (*AppendRequest) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AppendRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *AppendRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type AppendReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FirstId       int64                  `protobuf:"varint,1,opt,name=first_id,json=firstId,proto3" json:"first_id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendReply) Reset() {
+	*x = AppendReply{}
+	mi := &file_dfs_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendReply) ProtoMessage() {}
+
+func (x *AppendReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendReply.ProtoReflect.Descriptor instead.
+func (*AppendReply) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AppendReply) GetFirstId() int64 {
+	if x != nil {
+		return x.FirstId
+	}
+	return 0
+}
+
+func (x *AppendReply) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type RecordsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Ids           []int64                `protobuf:"varint,2,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	FailOnHole    bool                   `protobuf:"varint,3,opt,name=fail_on_hole,json=failOnHole,proto3" json:"fail_on_hole,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordsRequest) Reset() {
+	*x = RecordsRequest{}
+	mi := &file_dfs_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordsRequest) ProtoMessage() {}
+
+func (x *RecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordsRequest.ProtoReflect.Descriptor instead.
+func (*RecordsRequest) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RecordsRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *RecordsRequest) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *RecordsRequest) GetFailOnHole() bool {
+	if x != nil {
+		return x.FailOnHole
+	}
+	return false
+}
+
+type Record struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	mi := &file_dfs_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Record) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Record) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type RecordSizeReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RecordSize    int32                  `protobuf:"varint,1,opt,name=record_size,json=recordSize,proto3" json:"record_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordSizeReply) Reset() {
+	*x = RecordSizeReply{}
+	mi := &file_dfs_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordSizeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordSizeReply) ProtoMessage() {}
+
+func (x *RecordSizeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordSizeReply.ProtoReflect.Descriptor instead.
+func (*RecordSizeReply) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RecordSizeReply) GetRecordSize() int32 {
+	if x != nil {
+		return x.RecordSize
+	}
+	return 0
+}
+
+type RecordRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          int64                  `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            int64                  `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordRange) Reset() {
+	*x = RecordRange{}
+	mi := &file_dfs_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordRange) ProtoMessage() {}
+
+func (x *RecordRange) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordRange.ProtoReflect.Descriptor instead.
+func (*RecordRange) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RecordRange) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *RecordRange) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+type DataRangesReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ranges        []*RecordRange         `protobuf:"bytes,1,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataRangesReply) Reset() {
+	*x = DataRangesReply{}
+	mi := &file_dfs_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataRangesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataRangesReply) ProtoMessage() {}
+
+func (x *DataRangesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataRangesReply.ProtoReflect.Descriptor instead.
+func (*DataRangesReply) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DataRangesReply) GetRanges() []*RecordRange {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+type SeekRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Id            int64                  `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeekRequest) Reset() {
+	*x = SeekRequest{}
+	mi := &file_dfs_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeekRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeekRequest) ProtoMessage() {}
+
+func (x *SeekRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeekRequest.ProtoReflect.Descriptor instead.
+func (*SeekRequest) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SeekRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *SeekRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type SeekReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SeekReply) Reset() {
+	*x = SeekReply{}
+	mi := &file_dfs_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SeekReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeekReply) ProtoMessage() {}
+
+func (x *SeekReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeekReply.ProtoReflect.Descriptor instead.
+func (*SeekReply) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SeekReply) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type Extent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int64                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int64                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Extent) Reset() {
+	*x = Extent{}
+	mi := &file_dfs_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Extent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Extent) ProtoMessage() {}
+
+func (x *Extent) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Extent.ProtoReflect.Descriptor instead.
+func (*Extent) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Extent) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Extent) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type ExtentsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Extents       []*Extent              `protobuf:"bytes,1,rep,name=extents,proto3" json:"extents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtentsReply) Reset() {
+	*x = ExtentsReply{}
+	mi := &file_dfs_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtentsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtentsReply) ProtoMessage() {}
+
+func (x *ExtentsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dfs_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtentsReply.ProtoReflect.Descriptor instead.
+func (*ExtentsReply) Descriptor() ([]byte, []int) {
+	return file_dfs_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ExtentsReply) GetExtents() []*Extent {
+	if x != nil {
+		return x.Extents
+	}
+	return nil
+}
+
+var File_dfs_proto protoreflect.FileDescriptor
+
+const file_dfs_proto_rawDesc = "" +
+	"\n" +
+	"\tdfs.proto\x12\x03dfs\"\a\n" +
+	"\x05Empty\"\x99\x01\n" +
+	"\x0eRecordMappings\x12G\n" +
+	"\frecord_sizes\x18\x01 \x03(\v2$.dfs.RecordMappings.RecordSizesEntryR\vrecordSizes\x1a>\n" +
+	"\x10RecordSizesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\")\n" +
+	"\vFileRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\"%\n" +
+	"\vExistsReply\x12\x16\n" +
+	"\x06exists\x18\x01 \x01(\bR\x06exists\"#\n" +
+	"\rFileSizeReply\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\"y\n" +
+	"\vReadRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\x12 \n" +
+	"\ffail_on_hole\x18\x04 \x01(\bR\n" +
+	"failOnHole\"O\n" +
+	"\x05Chunk\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"?\n" +
+	"\rAppendRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"@\n" +
+	"\vAppendReply\x12\x19\n" +
+	"\bfirst_id\x18\x01 \x01(\x03R\afirstId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\"`\n" +
+	"\x0eRecordsRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x10\n" +
+	"\x03ids\x18\x02 \x03(\x03R\x03ids\x12 \n" +
+	"\ffail_on_hole\x18\x03 \x01(\bR\n" +
+	"failOnHole\",\n" +
+	"\x06Record\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"2\n" +
+	"\x0fRecordSizeReply\x12\x1f\n" +
+	"\vrecord_size\x18\x01 \x01(\x05R\n" +
+	"recordSize\"1\n" +
+	"\vRecordRange\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\x03R\x02to\";\n" +
+	"\x0fDataRangesReply\x12(\n" +
+	"\x06ranges\x18\x01 \x03(\v2\x10.dfs.RecordRangeR\x06ranges\"9\n" +
+	"\vSeekRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x03R\x02id\"\x1b\n" +
+	"\tSeekReply\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"0\n" +
+	"\x06Extent\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x03R\x03end\"5\n" +
+	"\fExtentsReply\x12%\n" +
+	"\aextents\x18\x01 \x03(\v2\v.dfs.ExtentR\aextents2\xd2\x04\n" +
+	"\bRemoteIO\x125\n" +
+	"\x12InitRecordMappings\x12\x13.dfs.RecordMappings\x1a\n" +
+	".dfs.Empty\x120\n" +
+	"\n" +
+	"FileExists\x12\x10.dfs.FileRequest\x1a\x10.dfs.ExistsReply\x12*\n" +
+	"\n" +
+	"CreateFile\x12\x10.dfs.FileRequest\x1a\n" +
+	".dfs.Empty\x12*\n" +
+	"\n" +
+	"DeleteFile\x12\x10.dfs.FileRequest\x1a\n" +
+	".dfs.Empty\x12+\n" +
+	"\tReadBytes\x12\x10.dfs.ReadRequest\x1a\n" +
+	".dfs.Chunk0\x01\x12&\n" +
+	"\n" +
+	"WriteBytes\x12\n" +
+	".dfs.Chunk\x1a\n" +
+	".dfs.Empty(\x01\x125\n" +
+	"\rAppendRecords\x12\x12.dfs.AppendRequest\x1a\x10.dfs.AppendReply\x121\n" +
+	"\vReadRecords\x12\x13.dfs.RecordsRequest\x1a\v.dfs.Record0\x01\x124\n" +
+	"\n" +
+	"RecordSize\x12\x10.dfs.FileRequest\x1a\x14.dfs.RecordSizeReply\x124\n" +
+	"\n" +
+	"DataRanges\x12\x10.dfs.FileRequest\x1a\x14.dfs.DataRangesReply\x12,\n" +
+	"\bSeekData\x12\x10.dfs.SeekRequest\x1a\x0e.dfs.SeekReply\x12,\n" +
+	"\bSeekHole\x12\x10.dfs.SeekRequest\x1a\x0e.dfs.SeekReply2\x8a\x03\n" +
+	"\x06PeerFS\x12\x1e\n" +
+	"\x04Ping\x12\n" +
+	".dfs.Empty\x1a\n" +
+	".dfs.Empty\x12\x1f\n" +
+	"\x05Close\x12\n" +
+	".dfs.Empty\x1a\n" +
+	".dfs.Empty\x120\n" +
+	"\n" +
+	"FileExists\x12\x10.dfs.FileRequest\x1a\x10.dfs.ExistsReply\x120\n" +
+	"\bFileSize\x12\x10.dfs.FileRequest\x1a\x12.dfs.FileSizeReply\x12*\n" +
+	"\n" +
+	"CreateFile\x12\x10.dfs.FileRequest\x1a\n" +
+	".dfs.Empty\x12*\n" +
+	"\n" +
+	"DeleteFile\x12\x10.dfs.FileRequest\x1a\n" +
+	".dfs.Empty\x12+\n" +
+	"\tReadBytes\x12\x10.dfs.ReadRequest\x1a\n" +
+	".dfs.Chunk0\x01\x12&\n" +
+	"\n" +
+	"WriteBytes\x12\n" +
+	".dfs.Chunk\x1a\n" +
+	".dfs.Empty(\x01\x12.\n" +
+	"\aExtents\x12\x10.dfs.FileRequest\x1a\x11.dfs.ExtentsReplyB)Z'github.com/alikhil/distributed-fs/dfspbb\x06proto3"
+
+var (
+	file_dfs_proto_rawDescOnce sync.Once
+	file_dfs_proto_rawDescData []byte
+)
+
+func file_dfs_proto_rawDescGZIP() []byte {
+	file_dfs_proto_rawDescOnce.Do(func() {
+		file_dfs_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dfs_proto_rawDesc), len(file_dfs_proto_rawDesc)))
+	})
+	return file_dfs_proto_rawDescData
+}
+
+var file_dfs_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_dfs_proto_goTypes = []any{
+	(*Empty)(nil),           // 0: dfs.Empty
+	(*RecordMappings)(nil),  // 1: dfs.RecordMappings
+	(*FileRequest)(nil),     // 2: dfs.FileRequest
+	(*ExistsReply)(nil),     // 3: dfs.ExistsReply
+	(*FileSizeReply)(nil),   // 4: dfs.FileSizeReply
+	(*ReadRequest)(nil),     // 5: dfs.ReadRequest
+	(*Chunk)(nil),           // 6: dfs.Chunk
+	(*AppendRequest)(nil),   // 7: dfs.AppendRequest
+	(*AppendReply)(nil),     // 8: dfs.AppendReply
+	(*RecordsRequest)(nil),  // 9: dfs.RecordsRequest
+	(*Record)(nil),          // 10: dfs.Record
+	(*RecordSizeReply)(nil), // 11: dfs.RecordSizeReply
+	(*RecordRange)(nil),     // 12: dfs.RecordRange
+	(*DataRangesReply)(nil), // 13: dfs.DataRangesReply
+	(*SeekRequest)(nil),     // 14: dfs.SeekRequest
+	(*SeekReply)(nil),       // 15: dfs.SeekReply
+	(*Extent)(nil),          // 16: dfs.Extent
+	(*ExtentsReply)(nil),    // 17: dfs.ExtentsReply
+	nil,                     // 18: dfs.RecordMappings.RecordSizesEntry
+}
+var file_dfs_proto_depIdxs = []int32{
+	18, // 0: dfs.RecordMappings.record_sizes:type_name -> dfs.RecordMappings.RecordSizesEntry
+	12, // 1: dfs.DataRangesReply.ranges:type_name -> dfs.RecordRange
+	16, // 2: dfs.ExtentsReply.extents:type_name -> dfs.Extent
+	1,  // 3: dfs.RemoteIO.InitRecordMappings:input_type -> dfs.RecordMappings
+	2,  // 4: dfs.RemoteIO.FileExists:input_type -> dfs.FileRequest
+	2,  // 5: dfs.RemoteIO.CreateFile:input_type -> dfs.FileRequest
+	2,  // 6: dfs.RemoteIO.DeleteFile:input_type -> dfs.FileRequest
+	5,  // 7: dfs.RemoteIO.ReadBytes:input_type -> dfs.ReadRequest
+	6,  // 8: dfs.RemoteIO.WriteBytes:input_type -> dfs.Chunk
+	7,  // 9: dfs.RemoteIO.AppendRecords:input_type -> dfs.AppendRequest
+	9,  // 10: dfs.RemoteIO.ReadRecords:input_type -> dfs.RecordsRequest
+	2,  // 11: dfs.RemoteIO.RecordSize:input_type -> dfs.FileRequest
+	2,  // 12: dfs.RemoteIO.DataRanges:input_type -> dfs.FileRequest
+	14, // 13: dfs.RemoteIO.SeekData:input_type -> dfs.SeekRequest
+	14, // 14: dfs.RemoteIO.SeekHole:input_type -> dfs.SeekRequest
+	0,  // 15: dfs.PeerFS.Ping:input_type -> dfs.Empty
+	0,  // 16: dfs.PeerFS.Close:input_type -> dfs.Empty
+	2,  // 17: dfs.PeerFS.FileExists:input_type -> dfs.FileRequest
+	2,  // 18: dfs.PeerFS.FileSize:input_type -> dfs.FileRequest
+	2,  // 19: dfs.PeerFS.CreateFile:input_type -> dfs.FileRequest
+	2,  // 20: dfs.PeerFS.DeleteFile:input_type -> dfs.FileRequest
+	5,  // 21: dfs.PeerFS.ReadBytes:input_type -> dfs.ReadRequest
+	6,  // 22: dfs.PeerFS.WriteBytes:input_type -> dfs.Chunk
+	2,  // 23: dfs.PeerFS.Extents:input_type -> dfs.FileRequest
+	0,  // 24: dfs.RemoteIO.InitRecordMappings:output_type -> dfs.Empty
+	3,  // 25: dfs.RemoteIO.FileExists:output_type -> dfs.ExistsReply
+	0,  // 26: dfs.RemoteIO.CreateFile:output_type -> dfs.Empty
+	0,  // 27: dfs.RemoteIO.DeleteFile:output_type -> dfs.Empty
+	6,  // 28: dfs.RemoteIO.ReadBytes:output_type -> dfs.Chunk
+	0,  // 29: dfs.RemoteIO.WriteBytes:output_type -> dfs.Empty
+	8,  // 30: dfs.RemoteIO.AppendRecords:output_type -> dfs.AppendReply
+	10, // 31: dfs.RemoteIO.ReadRecords:output_type -> dfs.Record
+	11, // 32: dfs.RemoteIO.RecordSize:output_type -> dfs.RecordSizeReply
+	13, // 33: dfs.RemoteIO.DataRanges:output_type -> dfs.DataRangesReply
+	15, // 34: dfs.RemoteIO.SeekData:output_type -> dfs.SeekReply
+	15, // 35: dfs.RemoteIO.SeekHole:output_type -> dfs.SeekReply
+	0,  // 36: dfs.PeerFS.Ping:output_type -> dfs.Empty
+	0,  // 37: dfs.PeerFS.Close:output_type -> dfs.Empty
+	3,  // 38: dfs.PeerFS.FileExists:output_type -> dfs.ExistsReply
+	4,  // 39: dfs.PeerFS.FileSize:output_type -> dfs.FileSizeReply
+	0,  // 40: dfs.PeerFS.CreateFile:output_type -> dfs.Empty
+	0,  // 41: dfs.PeerFS.DeleteFile:output_type -> dfs.Empty
+	6,  // 42: dfs.PeerFS.ReadBytes:output_type -> dfs.Chunk
+	0,  // 43: dfs.PeerFS.WriteBytes:output_type -> dfs.Empty
+	17, // 44: dfs.PeerFS.Extents:output_type -> dfs.ExtentsReply
+	24, // [24:45] is the sub-list for method output_type
+	3,  // [3:24] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_dfs_proto_init() }
+func file_dfs_proto_init() {
+	if File_dfs_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dfs_proto_rawDesc), len(file_dfs_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_dfs_proto_goTypes,
+		DependencyIndexes: file_dfs_proto_depIdxs,
+		MessageInfos:      file_dfs_proto_msgTypes,
+	}.Build()
+	File_dfs_proto = out.File
+	file_dfs_proto_goTypes = nil
+	file_dfs_proto_depIdxs = nil
+}
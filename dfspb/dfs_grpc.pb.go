@@ -0,0 +1,958 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: dfs.proto
+
+package dfspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RemoteIO_InitRecordMappings_FullMethodName = "/dfs.RemoteIO/InitRecordMappings"
+	RemoteIO_FileExists_FullMethodName         = "/dfs.RemoteIO/FileExists"
+	RemoteIO_CreateFile_FullMethodName         = "/dfs.RemoteIO/CreateFile"
+	RemoteIO_DeleteFile_FullMethodName         = "/dfs.RemoteIO/DeleteFile"
+	RemoteIO_ReadBytes_FullMethodName          = "/dfs.RemoteIO/ReadBytes"
+	RemoteIO_WriteBytes_FullMethodName         = "/dfs.RemoteIO/WriteBytes"
+	RemoteIO_AppendRecords_FullMethodName      = "/dfs.RemoteIO/AppendRecords"
+	RemoteIO_ReadRecords_FullMethodName        = "/dfs.RemoteIO/ReadRecords"
+	RemoteIO_RecordSize_FullMethodName         = "/dfs.RemoteIO/RecordSize"
+	RemoteIO_DataRanges_FullMethodName         = "/dfs.RemoteIO/DataRanges"
+	RemoteIO_SeekData_FullMethodName           = "/dfs.RemoteIO/SeekData"
+	RemoteIO_SeekHole_FullMethodName           = "/dfs.RemoteIO/SeekHole"
+)
+
+// RemoteIOClient is the client API for RemoteIO service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RemoteIO - api of the master node for clients.
+// Errors are returned as grpc statuses, which messages start with dfs error code in square brackets.
+type RemoteIOClient interface {
+	InitRecordMappings(ctx context.Context, in *RecordMappings, opts ...grpc.CallOption) (*Empty, error)
+	FileExists(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*ExistsReply, error)
+	CreateFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error)
+	DeleteFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error)
+	// ReadBytes - streams requested range in chunks of whole records
+	ReadBytes(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error)
+	// WriteBytes - writes every received chunk at its offset
+	WriteBytes(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Empty], error)
+	AppendRecords(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendReply, error)
+	// ReadRecords - streams records in the same order as ids are requested
+	ReadRecords(ctx context.Context, in *RecordsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Record], error)
+	RecordSize(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*RecordSizeReply, error)
+	DataRanges(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*DataRangesReply, error)
+	SeekData(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekReply, error)
+	SeekHole(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekReply, error)
+}
+
+type remoteIOClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRemoteIOClient(cc grpc.ClientConnInterface) RemoteIOClient {
+	return &remoteIOClient{cc}
+}
+
+func (c *remoteIOClient) InitRecordMappings(ctx context.Context, in *RecordMappings, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, RemoteIO_InitRecordMappings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) FileExists(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*ExistsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExistsReply)
+	err := c.cc.Invoke(ctx, RemoteIO_FileExists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) CreateFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, RemoteIO_CreateFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) DeleteFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, RemoteIO_DeleteFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) ReadBytes(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoteIO_ServiceDesc.Streams[0], RemoteIO_ReadBytes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadRequest, Chunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteIO_ReadBytesClient = grpc.ServerStreamingClient[Chunk]
+
+func (c *remoteIOClient) WriteBytes(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Empty], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoteIO_ServiceDesc.Streams[1], RemoteIO_WriteBytes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Chunk, Empty]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteIO_WriteBytesClient = grpc.ClientStreamingClient[Chunk, Empty]
+
+func (c *remoteIOClient) AppendRecords(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendReply)
+	err := c.cc.Invoke(ctx, RemoteIO_AppendRecords_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) ReadRecords(ctx context.Context, in *RecordsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Record], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoteIO_ServiceDesc.Streams[2], RemoteIO_ReadRecords_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RecordsRequest, Record]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteIO_ReadRecordsClient = grpc.ServerStreamingClient[Record]
+
+func (c *remoteIOClient) RecordSize(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*RecordSizeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordSizeReply)
+	err := c.cc.Invoke(ctx, RemoteIO_RecordSize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) DataRanges(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*DataRangesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DataRangesReply)
+	err := c.cc.Invoke(ctx, RemoteIO_DataRanges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) SeekData(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SeekReply)
+	err := c.cc.Invoke(ctx, RemoteIO_SeekData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteIOClient) SeekHole(ctx context.Context, in *SeekRequest, opts ...grpc.CallOption) (*SeekReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SeekReply)
+	err := c.cc.Invoke(ctx, RemoteIO_SeekHole_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RemoteIOServer is the server API for RemoteIO service.
+// All implementations must embed UnimplementedRemoteIOServer
+// for forward compatibility.
+//
+// RemoteIO - api of the master node for clients.
+// Errors are returned as grpc statuses, which messages start with dfs error code in square brackets.
+type RemoteIOServer interface {
+	InitRecordMappings(context.Context, *RecordMappings) (*Empty, error)
+	FileExists(context.Context, *FileRequest) (*ExistsReply, error)
+	CreateFile(context.Context, *FileRequest) (*Empty, error)
+	DeleteFile(context.Context, *FileRequest) (*Empty, error)
+	// ReadBytes - streams requested range in chunks of whole records
+	ReadBytes(*ReadRequest, grpc.ServerStreamingServer[Chunk]) error
+	// WriteBytes - writes every received chunk at its offset
+	WriteBytes(grpc.ClientStreamingServer[Chunk, Empty]) error
+	AppendRecords(context.Context, *AppendRequest) (*AppendReply, error)
+	// ReadRecords - streams records in the same order as ids are requested
+	ReadRecords(*RecordsRequest, grpc.ServerStreamingServer[Record]) error
+	RecordSize(context.Context, *FileRequest) (*RecordSizeReply, error)
+	DataRanges(context.Context, *FileRequest) (*DataRangesReply, error)
+	SeekData(context.Context, *SeekRequest) (*SeekReply, error)
+	SeekHole(context.Context, *SeekRequest) (*SeekReply, error)
+	mustEmbedUnimplementedRemoteIOServer()
+}
+
+// UnimplementedRemoteIOServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRemoteIOServer struct{}
+
+func (UnimplementedRemoteIOServer) InitRecordMappings(context.Context, *RecordMappings) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InitRecordMappings not implemented")
+}
+func (UnimplementedRemoteIOServer) FileExists(context.Context, *FileRequest) (*ExistsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileExists not implemented")
+}
+func (UnimplementedRemoteIOServer) CreateFile(context.Context, *FileRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFile not implemented")
+}
+func (UnimplementedRemoteIOServer) DeleteFile(context.Context, *FileRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
+}
+func (UnimplementedRemoteIOServer) ReadBytes(*ReadRequest, grpc.ServerStreamingServer[Chunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadBytes not implemented")
+}
+func (UnimplementedRemoteIOServer) WriteBytes(grpc.ClientStreamingServer[Chunk, Empty]) error {
+	return status.Errorf(codes.Unimplemented, "method WriteBytes not implemented")
+}
+func (UnimplementedRemoteIOServer) AppendRecords(context.Context, *AppendRequest) (*AppendReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendRecords not implemented")
+}
+func (UnimplementedRemoteIOServer) ReadRecords(*RecordsRequest, grpc.ServerStreamingServer[Record]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadRecords not implemented")
+}
+func (UnimplementedRemoteIOServer) RecordSize(context.Context, *FileRequest) (*RecordSizeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordSize not implemented")
+}
+func (UnimplementedRemoteIOServer) DataRanges(context.Context, *FileRequest) (*DataRangesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DataRanges not implemented")
+}
+func (UnimplementedRemoteIOServer) SeekData(context.Context, *SeekRequest) (*SeekReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SeekData not implemented")
+}
+func (UnimplementedRemoteIOServer) SeekHole(context.Context, *SeekRequest) (*SeekReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SeekHole not implemented")
+}
+func (UnimplementedRemoteIOServer) mustEmbedUnimplementedRemoteIOServer() {}
+func (UnimplementedRemoteIOServer) testEmbeddedByValue()                  {}
+
+// UnsafeRemoteIOServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RemoteIOServer will
+// result in compilation errors.
+type UnsafeRemoteIOServer interface {
+	mustEmbedUnimplementedRemoteIOServer()
+}
+
+func RegisterRemoteIOServer(s grpc.ServiceRegistrar, srv RemoteIOServer) {
+	// If the following call pancis, it indicates UnimplementedRemoteIOServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RemoteIO_ServiceDesc, srv)
+}
+
+func _RemoteIO_InitRecordMappings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordMappings)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).InitRecordMappings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_InitRecordMappings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).InitRecordMappings(ctx, req.(*RecordMappings))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_FileExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).FileExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_FileExists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).FileExists(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_CreateFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).CreateFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_CreateFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).CreateFile(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).DeleteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_DeleteFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).DeleteFile(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_ReadBytes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RemoteIOServer).ReadBytes(m, &grpc.GenericServerStream[ReadRequest, Chunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteIO_ReadBytesServer = grpc.ServerStreamingServer[Chunk]
+
+func _RemoteIO_WriteBytes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RemoteIOServer).WriteBytes(&grpc.GenericServerStream[Chunk, Empty]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteIO_WriteBytesServer = grpc.ClientStreamingServer[Chunk, Empty]
+
+func _RemoteIO_AppendRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).AppendRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_AppendRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).AppendRecords(ctx, req.(*AppendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_ReadRecords_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RecordsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RemoteIOServer).ReadRecords(m, &grpc.GenericServerStream[RecordsRequest, Record]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoteIO_ReadRecordsServer = grpc.ServerStreamingServer[Record]
+
+func _RemoteIO_RecordSize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).RecordSize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_RecordSize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).RecordSize(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_DataRanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).DataRanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_DataRanges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).DataRanges(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_SeekData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SeekRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).SeekData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_SeekData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).SeekData(ctx, req.(*SeekRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteIO_SeekHole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SeekRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteIOServer).SeekHole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoteIO_SeekHole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteIOServer).SeekHole(ctx, req.(*SeekRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RemoteIO_ServiceDesc is the grpc.ServiceDesc for RemoteIO service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RemoteIO_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dfs.RemoteIO",
+	HandlerType: (*RemoteIOServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "InitRecordMappings",
+			Handler:    _RemoteIO_InitRecordMappings_Handler,
+		},
+		{
+			MethodName: "FileExists",
+			Handler:    _RemoteIO_FileExists_Handler,
+		},
+		{
+			MethodName: "CreateFile",
+			Handler:    _RemoteIO_CreateFile_Handler,
+		},
+		{
+			MethodName: "DeleteFile",
+			Handler:    _RemoteIO_DeleteFile_Handler,
+		},
+		{
+			MethodName: "AppendRecords",
+			Handler:    _RemoteIO_AppendRecords_Handler,
+		},
+		{
+			MethodName: "RecordSize",
+			Handler:    _RemoteIO_RecordSize_Handler,
+		},
+		{
+			MethodName: "DataRanges",
+			Handler:    _RemoteIO_DataRanges_Handler,
+		},
+		{
+			MethodName: "SeekData",
+			Handler:    _RemoteIO_SeekData_Handler,
+		},
+		{
+			MethodName: "SeekHole",
+			Handler:    _RemoteIO_SeekHole_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadBytes",
+			Handler:       _RemoteIO_ReadBytes_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteBytes",
+			Handler:       _RemoteIO_WriteBytes_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ReadRecords",
+			Handler:       _RemoteIO_ReadRecords_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "dfs.proto",
+}
+
+const (
+	PeerFS_Ping_FullMethodName       = "/dfs.PeerFS/Ping"
+	PeerFS_Close_FullMethodName      = "/dfs.PeerFS/Close"
+	PeerFS_FileExists_FullMethodName = "/dfs.PeerFS/FileExists"
+	PeerFS_FileSize_FullMethodName   = "/dfs.PeerFS/FileSize"
+	PeerFS_CreateFile_FullMethodName = "/dfs.PeerFS/CreateFile"
+	PeerFS_DeleteFile_FullMethodName = "/dfs.PeerFS/DeleteFile"
+	PeerFS_ReadBytes_FullMethodName  = "/dfs.PeerFS/ReadBytes"
+	PeerFS_WriteBytes_FullMethodName = "/dfs.PeerFS/WriteBytes"
+	PeerFS_Extents_FullMethodName    = "/dfs.PeerFS/Extents"
+)
+
+// PeerFSClient is the client API for PeerFS service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PeerFS - api of the peer node for master
+type PeerFSClient interface {
+	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	Close(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	FileExists(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*ExistsReply, error)
+	FileSize(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*FileSizeReply, error)
+	CreateFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error)
+	DeleteFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error)
+	ReadBytes(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error)
+	WriteBytes(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Empty], error)
+	Extents(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*ExtentsReply, error)
+}
+
+type peerFSClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPeerFSClient(cc grpc.ClientConnInterface) PeerFSClient {
+	return &peerFSClient{cc}
+}
+
+func (c *peerFSClient) Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, PeerFS_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerFSClient) Close(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, PeerFS_Close_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerFSClient) FileExists(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*ExistsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExistsReply)
+	err := c.cc.Invoke(ctx, PeerFS_FileExists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerFSClient) FileSize(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*FileSizeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileSizeReply)
+	err := c.cc.Invoke(ctx, PeerFS_FileSize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerFSClient) CreateFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, PeerFS_CreateFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerFSClient) DeleteFile(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, PeerFS_DeleteFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerFSClient) ReadBytes(ctx context.Context, in *ReadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PeerFS_ServiceDesc.Streams[0], PeerFS_ReadBytes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadRequest, Chunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PeerFS_ReadBytesClient = grpc.ServerStreamingClient[Chunk]
+
+func (c *peerFSClient) WriteBytes(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[Chunk, Empty], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PeerFS_ServiceDesc.Streams[1], PeerFS_WriteBytes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Chunk, Empty]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PeerFS_WriteBytesClient = grpc.ClientStreamingClient[Chunk, Empty]
+
+func (c *peerFSClient) Extents(ctx context.Context, in *FileRequest, opts ...grpc.CallOption) (*ExtentsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExtentsReply)
+	err := c.cc.Invoke(ctx, PeerFS_Extents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PeerFSServer is the server API for PeerFS service.
+// All implementations must embed UnimplementedPeerFSServer
+// for forward compatibility.
+//
+// PeerFS - api of the peer node for master
+type PeerFSServer interface {
+	Ping(context.Context, *Empty) (*Empty, error)
+	Close(context.Context, *Empty) (*Empty, error)
+	FileExists(context.Context, *FileRequest) (*ExistsReply, error)
+	FileSize(context.Context, *FileRequest) (*FileSizeReply, error)
+	CreateFile(context.Context, *FileRequest) (*Empty, error)
+	DeleteFile(context.Context, *FileRequest) (*Empty, error)
+	ReadBytes(*ReadRequest, grpc.ServerStreamingServer[Chunk]) error
+	WriteBytes(grpc.ClientStreamingServer[Chunk, Empty]) error
+	Extents(context.Context, *FileRequest) (*ExtentsReply, error)
+	mustEmbedUnimplementedPeerFSServer()
+}
+
+// UnimplementedPeerFSServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPeerFSServer struct{}
+
+func (UnimplementedPeerFSServer) Ping(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedPeerFSServer) Close(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Close not implemented")
+}
+func (UnimplementedPeerFSServer) FileExists(context.Context, *FileRequest) (*ExistsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileExists not implemented")
+}
+func (UnimplementedPeerFSServer) FileSize(context.Context, *FileRequest) (*FileSizeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FileSize not implemented")
+}
+func (UnimplementedPeerFSServer) CreateFile(context.Context, *FileRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateFile not implemented")
+}
+func (UnimplementedPeerFSServer) DeleteFile(context.Context, *FileRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
+}
+func (UnimplementedPeerFSServer) ReadBytes(*ReadRequest, grpc.ServerStreamingServer[Chunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadBytes not implemented")
+}
+func (UnimplementedPeerFSServer) WriteBytes(grpc.ClientStreamingServer[Chunk, Empty]) error {
+	return status.Errorf(codes.Unimplemented, "method WriteBytes not implemented")
+}
+func (UnimplementedPeerFSServer) Extents(context.Context, *FileRequest) (*ExtentsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Extents not implemented")
+}
+func (UnimplementedPeerFSServer) mustEmbedUnimplementedPeerFSServer() {}
+func (UnimplementedPeerFSServer) testEmbeddedByValue()                {}
+
+// UnsafePeerFSServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PeerFSServer will
+// result in compilation errors.
+type UnsafePeerFSServer interface {
+	mustEmbedUnimplementedPeerFSServer()
+}
+
+func RegisterPeerFSServer(s grpc.ServiceRegistrar, srv PeerFSServer) {
+	// If the following call pancis, it indicates UnimplementedPeerFSServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PeerFS_ServiceDesc, srv)
+}
+
+func _PeerFS_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerFSServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PeerFS_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerFSServer).Ping(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerFS_Close_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerFSServer).Close(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PeerFS_Close_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerFSServer).Close(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerFS_FileExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerFSServer).FileExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PeerFS_FileExists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerFSServer).FileExists(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerFS_FileSize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerFSServer).FileSize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PeerFS_FileSize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerFSServer).FileSize(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerFS_CreateFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerFSServer).CreateFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PeerFS_CreateFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerFSServer).CreateFile(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerFS_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerFSServer).DeleteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PeerFS_DeleteFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerFSServer).DeleteFile(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PeerFS_ReadBytes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PeerFSServer).ReadBytes(m, &grpc.GenericServerStream[ReadRequest, Chunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PeerFS_ReadBytesServer = grpc.ServerStreamingServer[Chunk]
+
+func _PeerFS_WriteBytes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PeerFSServer).WriteBytes(&grpc.GenericServerStream[Chunk, Empty]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PeerFS_WriteBytesServer = grpc.ClientStreamingServer[Chunk, Empty]
+
+func _PeerFS_Extents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerFSServer).Extents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PeerFS_Extents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerFSServer).Extents(ctx, req.(*FileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PeerFS_ServiceDesc is the grpc.ServiceDesc for PeerFS service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PeerFS_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dfs.PeerFS",
+	HandlerType: (*PeerFSServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _PeerFS_Ping_Handler,
+		},
+		{
+			MethodName: "Close",
+			Handler:    _PeerFS_Close_Handler,
+		},
+		{
+			MethodName: "FileExists",
+			Handler:    _PeerFS_FileExists_Handler,
+		},
+		{
+			MethodName: "FileSize",
+			Handler:    _PeerFS_FileSize_Handler,
+		},
+		{
+			MethodName: "CreateFile",
+			Handler:    _PeerFS_CreateFile_Handler,
+		},
+		{
+			MethodName: "DeleteFile",
+			Handler:    _PeerFS_DeleteFile_Handler,
+		},
+		{
+			MethodName: "Extents",
+			Handler:    _PeerFS_Extents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadBytes",
+			Handler:       _PeerFS_ReadBytes_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WriteBytes",
+			Handler:       _PeerFS_WriteBytes_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "dfs.proto",
+}
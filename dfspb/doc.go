@@ -0,0 +1,4 @@
+// Package dfspb contains grpc api of master and peer nodes generated from dfs.proto
+package dfspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative dfs.proto
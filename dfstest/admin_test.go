@@ -0,0 +1,59 @@
+package dfstest_test
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/dfstest"
+	"github.com/alikhil/distributed-fs/master"
+	"github.com/alikhil/distributed-fs/utils"
+	"net"
+	"testing"
+	"time"
+)
+
+// startAdmin - serves admin api of master of the cluster on a free port and returns client of it
+func startAdmin(t *testing.T, c *dfstest.Cluster) *utils.AdminClient {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	// server is left running untill tests end, since admin api can not be stopped
+	go master.RunAdmin(c.Master, port, nil)
+
+	admin := &utils.AdminClient{URL: fmt.Sprintf("http://127.0.0.1:%d", port)}
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		if _, err = admin.ClusterStatus(); err == nil {
+			return admin
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("admin api is not served: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClusterStatusDoesNotWaitForHungPeer(t *testing.T) {
+	chaos := master.NewChaos(master.Faults{}, 1)
+	c := startCluster(t, dfstest.Options{Chaos: chaos})
+	admin := startAdmin(t, c)
+
+	// calls to the peer hang much longer than status waits for it
+	chaos.SetPeerFaults(c.Peers[1].Endpoint, master.Faults{Latency: time.Minute})
+	start := time.Now()
+	status, err := admin.ClusterStatus()
+	if err != nil {
+		t.Fatalf("failed to get status of the cluster: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("status of the cluster took %v", elapsed)
+	}
+	for slot, peer := range status.Peers {
+		if hung := slot == 1; hung != (peer.Error != "") {
+			t.Errorf("peer %d has error %q in status", slot, peer.Error)
+		}
+	}
+	chaos.ClearPeerFaults(c.Peers[1].Endpoint)
+}
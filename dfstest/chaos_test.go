@@ -0,0 +1,164 @@
+package dfstest_test
+
+import (
+	"bytes"
+	"github.com/alikhil/distributed-fs/dfstest"
+	"github.com/alikhil/distributed-fs/master"
+	"github.com/alikhil/distributed-fs/utils"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Record 1 of files is kept by peer 1, so faults of the peer are seen by calls to the first record only
+
+// checkCode - checks that call failed with the code
+func checkCode(t *testing.T, what string, err error, code utils.ErrorCode) {
+	t.Helper()
+	if got := utils.CodeOf(err); got != code {
+		t.Fatalf("%s failed with %v (%v), want %v", what, got, err, code)
+	}
+}
+
+// checkDown - checks that call failed since peer is down. Once health check notices it, master is not ready
+func checkDown(t *testing.T, what string, err error) {
+	t.Helper()
+	if code := utils.CodeOf(err); code != utils.CodePeerUnavailable && code != utils.CodeNotReady {
+		t.Fatalf("%s failed with %v (%v), want %v or %v", what, code, err, utils.CodePeerUnavailable, utils.CodeNotReady)
+	}
+}
+
+func TestChaosErrorsAndDrops(t *testing.T) {
+	cases := []struct {
+		name    string
+		faults  master.Faults
+		written bool // peer does the write whose result is lost
+	}{
+		{name: "errors", faults: master.Faults{ErrorRate: 1}},
+		{name: "drops", faults: master.Faults{DropRate: 1}, written: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			chaos := master.NewChaos(master.Faults{}, 1)
+			c := startCluster(t, dfstest.Options{Chaos: chaos})
+			dfs := c.DFS
+			createFile(t, dfs, "f", 3)
+			endpoint := c.Peers[1].Endpoint
+
+			chaos.SetPeerFaults(endpoint, tc.faults)
+			checkCode(t, "write to faulty peer", dfs.WriteRecord("f", 1, []byte("XXXX")), utils.CodePeerUnavailable)
+			_, err := dfs.ReadBytes("f", 0, 4)
+			checkCode(t, "read from faulty peer", err, utils.CodePeerUnavailable)
+			// records of other peers are not affected
+			checkRead(t, dfs, "f", 4, []byte("bbbbcccc"))
+
+			chaos.ClearPeerFaults(endpoint)
+			if tc.written {
+				checkRead(t, dfs, "f", 0, []byte("XXXX"))
+			} else {
+				checkRead(t, dfs, "f", 0, []byte("aaaa"))
+			}
+			if err = dfs.WriteRecord("f", 1, []byte("YYYY")); err != nil {
+				t.Fatalf("failed to write after faults are cleared: %v", err)
+			}
+			checkRead(t, dfs, "f", 0, []byte("YYYYbbbbcccc"))
+		})
+	}
+}
+
+func TestChaosCrashTearsWrite(t *testing.T) {
+	chaos := master.NewChaos(master.Faults{}, 1)
+	c := startCluster(t, dfstest.Options{Chaos: chaos})
+	dfs := c.DFS
+	createFile(t, dfs, "f", 3)
+	endpoint := c.Peers[1].Endpoint
+
+	chaos.SetPeerFaults(endpoint, master.Faults{CrashRate: 1, Downtime: time.Minute})
+	checkCode(t, "write to crashing peer", dfs.WriteRecord("f", 1, []byte("XXXX")), utils.CodePeerUnavailable)
+	chaos.ClearPeerFaults(endpoint)
+	// crashed peer stays down for downtime even without faults
+	_, err := dfs.ReadBytes("f", 0, 4)
+	checkDown(t, "read from crashed peer", err)
+
+	chaos.Heal(endpoint)
+	if err = c.WaitPeer(1, master.Connected, waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	// only the beginning of the record reached the disk; seed 1 cuts it in the middle
+	torn, err := dfs.ReadBytes("f", 0, 4)
+	if err != nil {
+		t.Fatalf("failed to read from healed peer: %v", err)
+	}
+	written := len(torn) - len(bytes.TrimLeft(torn, "X"))
+	if written == 0 || written == len(torn) || !bytes.Equal(torn[written:], []byte("aaaa")[written:]) {
+		t.Fatalf("record is %q after torn write, want beginning of %q and the rest of %q", torn, "XXXX", "aaaa")
+	}
+	if err = dfs.WriteRecord("f", 1, []byte("YYYY")); err != nil {
+		t.Fatalf("failed to write to healed peer: %v", err)
+	}
+	checkRead(t, dfs, "f", 0, []byte("YYYYbbbbcccc"))
+}
+
+func TestChaosDowntimeEnds(t *testing.T) {
+	const downtime = 500 * time.Millisecond
+	chaos := master.NewChaos(master.Faults{}, 1)
+	c := startCluster(t, dfstest.Options{Chaos: chaos})
+	dfs := c.DFS
+	createFile(t, dfs, "f", 3)
+	endpoint := c.Peers[1].Endpoint
+
+	chaos.SetPeerFaults(endpoint, master.Faults{CrashRate: 1, Downtime: downtime})
+	crashed := time.Now()
+	checkCode(t, "write to crashing peer", dfs.WriteRecord("f", 1, []byte("XXXX")), utils.CodePeerUnavailable)
+	chaos.ClearPeerFaults(endpoint)
+
+	// peer comes back by itself, without Heal
+	for {
+		_, err := dfs.ReadBytes("f", 0, 4)
+		if err == nil {
+			break
+		}
+		checkDown(t, "read from crashed peer", err)
+		if time.Since(crashed) > waitTimeout {
+			t.Fatalf("peer is down %v after crash with downtime %v", time.Since(crashed), downtime)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if elapsed := time.Since(crashed); elapsed < downtime {
+		t.Fatalf("peer is back %v after crash with downtime %v", elapsed, downtime)
+	}
+	if err := dfs.WriteRecord("f", 1, []byte("YYYY")); err != nil {
+		t.Fatalf("failed to write after downtime: %v", err)
+	}
+	checkRead(t, dfs, "f", 0, []byte("YYYY"))
+}
+
+// faultsOfSeed - writes record of peer with random faults and returns which writes failed
+func faultsOfSeed(t *testing.T, seed int64) []bool {
+	t.Helper()
+	chaos := master.NewChaos(master.Faults{}, seed)
+	c := startCluster(t, dfstest.Options{Chaos: chaos})
+	createFile(t, c.DFS, "f", 3)
+
+	chaos.SetPeerFaults(c.Peers[1].Endpoint, master.Faults{ErrorRate: 0.3, DropRate: 0.3})
+	failed := make([]bool, 50)
+	for i := range failed {
+		err := c.DFS.WriteRecord("f", 1, []byte("XXXX"))
+		if err != nil {
+			checkCode(t, "write to faulty peer", err, utils.CodePeerUnavailable)
+		}
+		failed[i] = err != nil
+	}
+	c.Stop()
+	return failed
+}
+
+func TestChaosSameSeedGivesSameFaults(t *testing.T) {
+	first := faultsOfSeed(t, 1)
+	if second := faultsOfSeed(t, 1); !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed failed writes %v and %v", first, second)
+	}
+	if other := faultsOfSeed(t, 2); reflect.DeepEqual(first, other) {
+		t.Fatalf("seeds 1 and 2 failed same writes %v", first)
+	}
+}
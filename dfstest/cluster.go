@@ -0,0 +1,267 @@
+// Package dfstest starts master and peers of dfs in one process on loopback ports, so RemoteFS is tested
+// with real rpc calls without starting binaries. Peers join master one by one, so peer i owns records
+// which ids give i when divided by number of peers
+package dfstest
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/master"
+	"github.com/alikhil/distributed-fs/peer"
+	"github.com/alikhil/distributed-fs/utils"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Options - settings of test cluster
+type Options struct {
+	Peers               int                // number of peers; 3 if 0
+	Consistency         master.Consistency // rule applied to create, delete and file exists requests
+	Dir                 string             // directories of peers are created in it; temporary directory removed by Stop is used if empty
+	HealthCheckInterval time.Duration      // how often master pings peers; 50ms if 0
+	StartTimeout        time.Duration      // how long to wait for master to connect to all the peers; 10s if 0
+	Chaos               *master.Chaos      // injects faults into calls of master to peers if not nil
+	Backend             string             // storage of peers, see peer.Backends; "dir" if empty
+	Direct              bool               // peers serve clients directly and clients given by NewClient use it
+}
+
+// Cluster - master and peers running in the process
+type Cluster struct {
+	Master         *master.RemoteFS
+	MasterEndpoint string
+	Peers          []*Peer
+	DFS            *utils.RemoteDFS // client connected to master
+
+	dir            string
+	removeDir      bool
+	direct         bool
+	masterListener *listener
+}
+
+// Peer - peer of test cluster. It keeps its directory and endpoints when restarted
+type Peer struct {
+	Endpoint     string
+	DataEndpoint string // where clients call peer directly; empty if cluster is not direct
+	Dir          string
+	Backend      string
+	FS           *peer.LocalFS // nil while peer is stopped
+
+	listener     *listener
+	dataListener *listener
+}
+
+// Start - starts master and peers and waits untill master is connected to all of them
+func Start(opts Options) (*Cluster, error) {
+	if opts.Peers == 0 {
+		opts.Peers = 3
+	}
+	if opts.HealthCheckInterval == 0 {
+		opts.HealthCheckInterval = 50 * time.Millisecond
+	}
+	if opts.StartTimeout == 0 {
+		opts.StartTimeout = 10 * time.Second
+	}
+	if opts.Backend == "" {
+		opts.Backend = "dir"
+	}
+
+	c := &Cluster{dir: opts.Dir, direct: opts.Direct}
+	if c.dir == "" {
+		dir, err := ioutil.TempDir("", "dfstest")
+		if err != nil {
+			return nil, err
+		}
+		c.dir, c.removeDir = dir, true
+	}
+
+	c.Master = &master.RemoteFS{PeersCount: opts.Peers, Consistency: opts.Consistency, HealthCheckInterval: opts.HealthCheckInterval, Chaos: opts.Chaos}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		c.Stop()
+		return nil, err
+	}
+	c.masterListener = newListener(l)
+	c.MasterEndpoint = l.Addr().String()
+	go http.Serve(c.masterListener, utils.NewRPCHandler("RemoteIO", c.Master, nil))
+
+	for i := 0; i < opts.Peers; i++ {
+		p := &Peer{Endpoint: "127.0.0.1:0", Dir: filepath.Join(c.dir, fmt.Sprintf("peer%d", i+1)), Backend: opts.Backend}
+		if opts.Direct {
+			p.DataEndpoint = "127.0.0.1:0"
+		}
+		c.Peers = append(c.Peers, p)
+		if err = p.start(c.MasterEndpoint); err != nil {
+			c.Stop()
+			return nil, fmt.Errorf("failed to start peer %d: %v", i, err)
+		}
+	}
+
+	if err = c.WaitReady(opts.StartTimeout); err != nil {
+		c.Stop()
+		return nil, err
+	}
+	if c.DFS, err = c.NewClient(); err != nil {
+		c.Stop()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClient - returns new client connected to master. Client of direct cluster reads and writes peers directly
+func (c *Cluster) NewClient() (*utils.RemoteDFS, error) {
+	client, ok := utils.GetRemoteClient(c.MasterEndpoint)
+	if !ok {
+		return nil, utils.Errorf(utils.CodeUnavailable, "failed to connect to master %s", c.MasterEndpoint)
+	}
+	return &utils.RemoteDFS{Client: client, Direct: c.direct}, nil
+}
+
+// Stop - stops master and peers. Temporary directory of the cluster is removed
+func (c *Cluster) Stop() error {
+	if c.DFS != nil {
+		c.DFS.Close()
+	}
+	if c.Master != nil {
+		c.Master.StopHealthChecker()
+	}
+	if c.masterListener != nil {
+		c.masterListener.Close()
+	}
+	for _, p := range c.Peers {
+		p.stop()
+	}
+	if c.removeDir {
+		return os.RemoveAll(c.dir)
+	}
+	return nil
+}
+
+// StopPeer - stops peer i as if its process is killed. Its files are kept
+func (c *Cluster) StopPeer(i int) error {
+	p := c.Peers[i]
+	if p.FS == nil {
+		return fmt.Errorf("peer %d is already stopped", i)
+	}
+	p.stop()
+	return nil
+}
+
+// StartPeer - starts stopped peer i with the same directory and endpoint. It joins master again
+func (c *Cluster) StartPeer(i int) error {
+	p := c.Peers[i]
+	if p.FS != nil {
+		return fmt.Errorf("peer %d is already running", i)
+	}
+	return p.start(c.MasterEndpoint)
+}
+
+// RestartPeer - stops peer i and starts it again
+func (c *Cluster) RestartPeer(i int) error {
+	if err := c.StopPeer(i); err != nil {
+		return err
+	}
+	return c.StartPeer(i)
+}
+
+// Partition - breaks connections of master and clients to peer i and refuses new ones untill Heal is called.
+// Unlike stopped peer, partitioned one keeps its state
+func (c *Cluster) Partition(i int) {
+	for _, l := range []*listener{c.Peers[i].listener, c.Peers[i].dataListener} {
+		if l != nil {
+			l.setBlocked(true)
+		}
+	}
+}
+
+// Heal - lets master and clients connect to partitioned peer i again
+func (c *Cluster) Heal(i int) {
+	for _, l := range []*listener{c.Peers[i].listener, c.Peers[i].dataListener} {
+		if l != nil {
+			l.setBlocked(false)
+		}
+	}
+}
+
+// WaitReady - waits untill master is connected to all the peers
+func (c *Cluster) WaitReady(timeout time.Duration) error {
+	for i := range c.Peers {
+		if err := c.WaitPeer(i, master.Connected, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitPeer - waits untill master sees connection to peer i in the status, e.g. notices that peer is stopped
+func (c *Cluster) WaitPeer(i int, status master.ConnectionStatus, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		current, err := c.Master.PeerStatus(c.Peers[i].Endpoint)
+		if err == nil && current == status {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return utils.Errorf(utils.CodeNotReady, "peer %d(%s) is %v, not %v after %v", i, c.Peers[i].Endpoint, current, status, timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (p *Peer) start(masterEndpoint string) error {
+	storage, err := peer.OpenStorage(p.Backend, peer.StorageOptions{Dir: p.Dir})
+	if err != nil {
+		return err
+	}
+	fs := peer.NewLocalFSWithStorage(storage)
+	l, err := net.Listen("tcp", p.Endpoint)
+	if err != nil {
+		storage.Close()
+		return err
+	}
+	p.Endpoint = l.Addr().String()
+	p.listener = newListener(l)
+	p.FS = fs
+	go http.Serve(p.listener, utils.NewRPCHandler("PeerFS", fs, nil))
+
+	if p.DataEndpoint != "" {
+		l, err = net.Listen("tcp", p.DataEndpoint)
+		if err != nil {
+			p.stop()
+			return err
+		}
+		p.DataEndpoint = l.Addr().String()
+		p.dataListener = newListener(l)
+		fs.DataEndpoint = p.DataEndpoint
+		go http.Serve(p.dataListener, utils.NewRPCHandler("PeerData", peer.NewDataFS(fs), nil))
+	}
+
+	client, ok := utils.GetRemoteClient(masterEndpoint)
+	if !ok {
+		p.stop()
+		return utils.Errorf(utils.CodeUnavailable, "failed to connect to master %s", masterEndpoint)
+	}
+	defer client.Close()
+	if err = fs.Join(client, p.Endpoint, ""); err != nil {
+		p.stop()
+		return err
+	}
+	return nil
+}
+
+func (p *Peer) stop() {
+	if p.listener != nil {
+		p.listener.Close()
+		p.listener = nil
+	}
+	if p.dataListener != nil {
+		p.dataListener.Close()
+		p.dataListener = nil
+	}
+	if p.FS != nil {
+		p.FS.CloseStorage()
+		p.FS = nil
+	}
+}
@@ -0,0 +1,360 @@
+package dfstest_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/alikhil/distributed-fs/dfstest"
+	"github.com/alikhil/distributed-fs/master"
+	"github.com/alikhil/distributed-fs/peer"
+	"github.com/alikhil/distributed-fs/utils"
+	"math"
+	"testing"
+	"time"
+)
+
+// waitTimeout - how long tests wait for master to notice that peer is lost or back
+const waitTimeout = 5 * time.Second
+
+// startCluster - starts test cluster which is stopped when the test ends
+func startCluster(t *testing.T, opts dfstest.Options) *dfstest.Cluster {
+	t.Helper()
+	c, err := dfstest.Start(opts)
+	if err != nil {
+		t.Fatalf("failed to start cluster: %v", err)
+	}
+	t.Cleanup(func() { c.Stop() })
+	return c
+}
+
+// createFile - creates file with records of 4 bytes and writes records "aaaa", "bbbb", ... to it
+func createFile(t *testing.T, dfs *utils.RemoteDFS, fname string, records int) []byte {
+	t.Helper()
+	if err := dfs.SetRecordSize(fname, 4); err != nil {
+		t.Fatalf("failed to set record size: %v", err)
+	}
+	if err := dfs.CreateFile(fname); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	var data []byte
+	for i := 0; i < records; i++ {
+		data = append(data, bytes.Repeat([]byte{byte('a' + i)}, 4)...)
+	}
+	if err := dfs.WriteBytes(fname, 0, &data); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	return data
+}
+
+func checkRead(t *testing.T, dfs *utils.RemoteDFS, fname string, offset int32, want []byte) {
+	t.Helper()
+	got, err := dfs.ReadBytes(fname, offset, int32(len(want)))
+	if err != nil {
+		t.Fatalf("failed to read %d bytes at %d: %v", len(want), offset, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("read %q at %d, want %q", got, offset, want)
+	}
+}
+
+func TestCreateReadWriteAppend(t *testing.T) {
+	for _, backend := range peer.Backends() {
+		for _, direct := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/direct=%v", backend, direct), func(t *testing.T) {
+				c := startCluster(t, dfstest.Options{Backend: backend, Direct: direct})
+				dfs := c.DFS
+
+				data := createFile(t, dfs, "f", 4)
+				if err := dfs.FileExists("f"); err != nil {
+					t.Fatalf("file does not exist: %v", err)
+				}
+				checkRead(t, dfs, "f", 0, data)
+				checkRead(t, dfs, "f", 4, []byte("bbbbcccc"))
+
+				if err := dfs.WriteRecord("f", 2, []byte("BBBB")); err != nil {
+					t.Fatalf("failed to write record: %v", err)
+				}
+				appended := []byte("eeeeffff")
+				firstID, offset, err := dfs.AppendRecords("f", &appended)
+				if err != nil {
+					t.Fatalf("failed to append records: %v", err)
+				}
+				if firstID != 5 || offset != 16 {
+					t.Fatalf("records appended to id %d at offset %d, want 5 at 16", firstID, offset)
+				}
+				records, err := dfs.ReadRecords("f", []int64{6, 2, 1})
+				if err != nil {
+					t.Fatalf("failed to read records: %v", err)
+				}
+				if got := string(bytes.Join(records, nil)); got != "ffffBBBBaaaa" {
+					t.Fatalf("read records %q, want %q", got, "ffffBBBBaaaa")
+				}
+
+				info, err := dfs.StatFile("f")
+				if err != nil {
+					t.Fatalf("failed to stat file: %v", err)
+				}
+				if info.Size != 24 || info.RecordSize != 4 {
+					t.Fatalf("stat of file is %+v, want size 24 and record size 4", info)
+				}
+
+				if err := dfs.DeleteFile("f"); err != nil {
+					t.Fatalf("failed to delete file: %v", err)
+				}
+				if _, err := dfs.StatFile("f"); utils.CodeOf(err) != utils.CodeNotFound {
+					t.Fatalf("stat of deleted file returned %v, want %s", err, utils.CodeNotFound)
+				}
+			})
+		}
+	}
+}
+
+func TestRecordSizeChangedByOtherClient(t *testing.T) {
+	c := startCluster(t, dfstest.Options{})
+	createFile(t, c.DFS, "f", 4)
+	other, err := c.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.ReadRecord("f", 2); err != nil {
+		t.Fatalf("failed to read record: %v", err)
+	}
+
+	if err := c.DFS.SetRecordSize("f", 8); err != nil {
+		t.Fatalf("failed to set record size: %v", err)
+	}
+	// the other client has record size 4 cached, so its calls are misaligned once and then use the new size
+	if err := other.WriteRecord("f", 2, []byte("xxxx")); utils.CodeOf(err) != utils.CodeInvalidArgument {
+		t.Fatalf("write with stale record size returned %v, want %s", err, utils.CodeInvalidArgument)
+	}
+	if _, err := other.ReadRecord("f", 2); err != nil {
+		t.Fatalf("failed to read record after record size changed: %v", err)
+	}
+	if err := other.WriteRecord("f", 2, []byte("xxxxyyyy")); err != nil {
+		t.Fatalf("failed to write record after record size changed: %v", err)
+	}
+	checkRead(t, c.DFS, "f", 8, []byte("xxxxyyyy"))
+}
+
+func TestAppendAfterDirectWrite(t *testing.T) {
+	c := startCluster(t, dfstest.Options{Direct: true})
+	createFile(t, c.DFS, "f", 2)
+	appended := []byte("cccc")
+	if _, _, err := c.DFS.AppendRecords("f", &appended); err != nil {
+		t.Fatalf("failed to append records: %v", err)
+	}
+
+	// master knows the end of the file after append, but does not see direct writes after it
+	if err := c.DFS.WriteRecord("f", 4, []byte("dddd")); err != nil {
+		t.Fatalf("failed to write record: %v", err)
+	}
+	appended = []byte("eeee")
+	firstID, _, err := c.DFS.AppendRecords("f", &appended)
+	if err != nil {
+		t.Fatalf("failed to append records: %v", err)
+	}
+	if firstID != 5 {
+		t.Fatalf("records appended to id %d, want 5", firstID)
+	}
+	checkRead(t, c.DFS, "f", 8, []byte("ccccddddeeee"))
+}
+
+func TestSeekStripedRecords(t *testing.T) {
+	for _, backend := range peer.Backends() {
+		t.Run(backend, func(t *testing.T) {
+			c := startCluster(t, dfstest.Options{Backend: backend})
+			if err := c.DFS.SetRecordSize("f", 4); err != nil {
+				t.Fatalf("failed to set record size: %v", err)
+			}
+			if err := c.DFS.CreateFile("f"); err != nil {
+				t.Fatalf("failed to create file: %v", err)
+			}
+			// every peer keeps every third record, records 10-12 are a hole
+			for id := int64(1); id <= 15; id++ {
+				if id < 10 || id > 12 {
+					if err := c.DFS.WriteRecord("f", id, []byte("xxxx")); err != nil {
+						t.Fatalf("failed to write record %d: %v", id, err)
+					}
+				}
+			}
+			if id, err := c.DFS.SeekHole("f", 1); err != nil || id != 10 {
+				t.Fatalf("hole is found at %d, %v; want 10", id, err)
+			}
+			if id, err := c.DFS.SeekData("f", 10); err != nil || id != 13 {
+				t.Fatalf("data is found at %d, %v; want 13", id, err)
+			}
+			if _, err := c.DFS.SeekData("f", 0); utils.CodeOf(err) != utils.CodeInvalidArgument {
+				t.Fatalf("seek from record 0 returned %v, want InvalidArgument", err)
+			}
+		})
+	}
+}
+
+func TestRangesOutOfFileAreRejected(t *testing.T) {
+	c := startCluster(t, dfstest.Options{})
+	createFile(t, c.DFS, "f", 2)
+	fname := "f"
+	data := []byte("xxxx")
+	var read []byte
+	var records [][]byte
+	var ok bool
+	// calls are sent as they are, since client itself would not build them
+	calls := map[string]func() error{
+		"negative read offset": func() error {
+			return c.DFS.Client.Call("RemoteIO.ReadBytes", &utils.IOReadArgs{Filename: &fname, Offset: -4, Count: 4}, &read)
+		},
+		"negative read count": func() error {
+			return c.DFS.Client.Call("RemoteIO.ReadBytes", &utils.IOReadArgs{Filename: &fname, Offset: 0, Count: -4}, &read)
+		},
+		"read past int32 offsets": func() error {
+			return c.DFS.Client.Call("RemoteIO.ReadBytes", &utils.IOReadArgs{Filename: &fname, Offset: math.MaxInt32 - 3, Count: 8}, &read)
+		},
+		"negative write offset": func() error {
+			return c.DFS.Client.Call("RemoteIO.WriteBytes", &utils.IOWriteArgs{Filename: &fname, Offset: -4, Data: &data}, &ok)
+		},
+		"write past int32 offsets": func() error {
+			return c.DFS.Client.Call("RemoteIO.WriteBytes", &utils.IOWriteArgs{Filename: &fname, Offset: math.MaxInt32 - 3, Data: &data}, &ok)
+		},
+		"missing record ids": func() error {
+			return c.DFS.Client.Call("RemoteIO.ReadRecords", &utils.IORecordsArgs{Filename: &fname}, &records)
+		},
+	}
+	for name, call := range calls {
+		if err := utils.DecodeError(call()); utils.CodeOf(err) != utils.CodeInvalidArgument {
+			t.Errorf("%s returned %v, want InvalidArgument", name, err)
+		}
+	}
+	checkRead(t, c.DFS, "f", 0, []byte("aaaabbbb"))
+}
+
+func TestRecordSizesChangedWhileFilesAreRead(t *testing.T) {
+	c := startCluster(t, dfstest.Options{})
+	data := createFile(t, c.DFS, "f", 2)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if err := c.DFS.SetRecordSize(fmt.Sprintf("g%d", i), 4); err != nil {
+				t.Errorf("failed to set record size: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		checkRead(t, c.DFS, "f", 0, data)
+	}
+	<-done
+}
+
+func TestStoppedPeer(t *testing.T) {
+	c := startCluster(t, dfstest.Options{})
+	data := createFile(t, c.DFS, "f", 3)
+
+	if err := c.StopPeer(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitPeer(1, master.Disconnected, waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	// master is not ready while one of peers is lost
+	if _, err := c.DFS.ReadRecord("f", 2); utils.CodeOf(err) != utils.CodeNotReady {
+		t.Fatalf("read while peer is stopped returned %v, want %s", err, utils.CodeNotReady)
+	}
+	if err := c.DFS.WriteRecord("f", 1, []byte("xxxx")); utils.CodeOf(err) != utils.CodeNotReady {
+		t.Fatalf("write while peer is stopped returned %v, want %s", err, utils.CodeNotReady)
+	}
+
+	if err := c.StartPeer(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitReady(waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	checkRead(t, c.DFS, "f", 0, data)
+}
+
+func TestRestartedPeer(t *testing.T) {
+	for _, backend := range peer.Backends() {
+		t.Run(backend, func(t *testing.T) {
+			if backend == "memory" {
+				t.Skip("memory backend loses files on restart")
+			}
+			c := startCluster(t, dfstest.Options{Backend: backend})
+			data := createFile(t, c.DFS, "f", 6)
+
+			for i := range c.Peers {
+				if err := c.RestartPeer(i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.WaitReady(waitTimeout); err != nil {
+				t.Fatal(err)
+			}
+			checkRead(t, c.DFS, "f", 0, data)
+
+			// written ranges are kept by peers over restart
+			c.DFS.FailOnHoles = true
+			defer func() { c.DFS.FailOnHoles = false }()
+			if _, err := c.DFS.ReadRecord("f", 7); utils.CodeOf(err) != utils.CodeNotWritten {
+				t.Fatalf("read of never written record returned %v, want %s", err, utils.CodeNotWritten)
+			}
+			ranges, err := c.DFS.DataRanges("f")
+			if err != nil {
+				t.Fatalf("failed to get data ranges: %v", err)
+			}
+			if len(ranges) != 1 || ranges[0].From != 1 || ranges[0].To != 6 {
+				t.Fatalf("data ranges are %+v, want 1..6", ranges)
+			}
+		})
+	}
+}
+
+func TestPartitionedPeer(t *testing.T) {
+	for _, direct := range []bool{false, true} {
+		t.Run(fmt.Sprintf("direct=%v", direct), func(t *testing.T) {
+			c := startCluster(t, dfstest.Options{Direct: direct})
+			data := createFile(t, c.DFS, "f", 3)
+
+			c.Partition(2)
+			if err := c.WaitPeer(2, master.Disconnected, waitTimeout); err != nil {
+				t.Fatal(err)
+			}
+			// partitioned peer keeps running, but master is not ready untill it reaches the peer again
+			if err := c.DFS.WriteRecord("f", 2, []byte("xxxx")); utils.CodeOf(err) != utils.CodeNotReady {
+				t.Fatalf("write while peer is partitioned returned %v, want %s", err, utils.CodeNotReady)
+			}
+			if err := c.DFS.CreateFile("g"); utils.CodeOf(err) != utils.CodeNotReady {
+				t.Fatalf("create while peer is partitioned returned %v, want %s", err, utils.CodeNotReady)
+			}
+
+			c.Heal(2)
+			if err := c.WaitReady(waitTimeout); err != nil {
+				t.Fatal(err)
+			}
+			checkRead(t, c.DFS, "f", 0, data)
+			if err := c.DFS.WriteRecord("f", 2, []byte("xxxx")); err != nil {
+				t.Fatalf("failed to write record after heal: %v", err)
+			}
+			checkRead(t, c.DFS, "f", 4, []byte("xxxx"))
+		})
+	}
+}
+
+func TestRegistersAreLinearizable(t *testing.T) {
+	if testing.Short() {
+		t.Skip("workload takes seconds")
+	}
+	c := startCluster(t, dfstest.Options{})
+	history, err := c.RunRegisters(dfstest.WorkloadOptions{
+		Duration:        2 * time.Second,
+		ReadRatio:       0.5,
+		Nemesis:         []dfstest.Nemesis{dfstest.NemesisPartition, dfstest.NemesisRestart},
+		NemesisInterval: 300 * time.Millisecond,
+		Seed:            1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := dfstest.CheckLinearizable(history.Operations()); !res.Linearizable {
+		t.Fatalf("history is not linearizable: %+v", res.Violations)
+	}
+}
@@ -0,0 +1,96 @@
+package dfstest
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// OpKind - kind of operation of the history
+type OpKind string
+
+const (
+	OpRead  OpKind = "read"
+	OpWrite OpKind = "write"
+)
+
+// Outcome - what client knows about result of the operation
+type Outcome string
+
+const (
+	// OutcomeOK - operation succeeded
+	OutcomeOK Outcome = "ok"
+	// OutcomeFail - operation failed and surely took no effect
+	OutcomeFail Outcome = "fail"
+	// OutcomeInfo - operation failed, but it may still take effect at any time after its call, e.g. lost write
+	OutcomeInfo Outcome = "info"
+)
+
+// Operation - read or write of one record by client. Times are nanoseconds since start of the history
+type Operation struct {
+	Client  int     `json:"client"`
+	Record  int64   `json:"record"`
+	Kind    OpKind  `json:"kind"`
+	Value   uint64  `json:"value"`          // written value or value seen by read; 0 is the value of never written record
+	Torn    bool    `json:"torn,omitempty"` // read saw record which is not any written value
+	Call    int64   `json:"call"`
+	Return  int64   `json:"return"`
+	Outcome Outcome `json:"outcome"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// History - operations of concurrent clients. It's safe for concurrent use
+type History struct {
+	start time.Time
+	lock  sync.Mutex
+	ops   []Operation
+}
+
+// NewHistory - returns empty history which times start now
+func NewHistory() *History {
+	return &History{start: time.Now()}
+}
+
+// Now - returns time of the history
+func (h *History) Now() int64 {
+	return int64(time.Since(h.start))
+}
+
+// Add - records finished operation
+func (h *History) Add(op Operation) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.ops = append(h.ops, op)
+}
+
+// Operations - returns copy of recorded operations
+func (h *History) Operations() []Operation {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]Operation(nil), h.ops...)
+}
+
+// EncodeValue - returns record which holds the value. The value is repeated over whole record,
+// so record written only partially is never taken for another value
+func EncodeValue(value uint64, recordSize int32) []byte {
+	record := make([]byte, recordSize)
+	for off := 0; off+8 <= len(record); off += 8 {
+		binary.BigEndian.PutUint64(record[off:], value)
+	}
+	return record
+}
+
+// DecodeValue - returns value held by the record. It's not ok if the record is torn
+func DecodeValue(record []byte) (uint64, bool) {
+	if len(record) < 8 {
+		return 0, false
+	}
+	value := binary.BigEndian.Uint64(record)
+	expected := EncodeValue(value, int32(len(record)))
+	for i := range record {
+		if record[i] != expected[i] {
+			return value, false
+		}
+	}
+	return value, true
+}
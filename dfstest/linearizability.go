@@ -0,0 +1,195 @@
+package dfstest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Every record is checked as read/write register on its own: history is linearizable if there is an order of
+// its operations which agrees with real time and in which every read sees the last written value.
+// The order is searched by Wing & Gong algorithm with memoization of visited states (Lowe's optimization)
+
+// Violation - record whose operations can not be linearized
+type Violation struct {
+	Record int64       `json:"record"`
+	Reason string      `json:"reason"`
+	Ops    []Operation `json:"ops,omitempty"` // operations of the record which could not be ordered
+}
+
+// CheckResult - result of the check of the history
+type CheckResult struct {
+	Linearizable bool        `json:"linearizable"`
+	Records      int         `json:"records"`
+	Operations   int         `json:"operations"` // checked operations; failed ones are skipped
+	Violations   []Violation `json:"violations,omitempty"`
+}
+
+// CheckLinearizable - checks that every record of the history behaves as linearizable register
+func CheckLinearizable(ops []Operation) CheckResult {
+	byRecord := make(map[int64][]Operation)
+	for _, op := range ops {
+		// failed writes surely took no effect and failed reads saw nothing
+		if op.Outcome == OutcomeFail || (op.Kind == OpRead && op.Outcome != OutcomeOK) {
+			continue
+		}
+		byRecord[op.Record] = append(byRecord[op.Record], op)
+	}
+	records := make([]int64, 0, len(byRecord))
+	for record := range byRecord {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i] < records[j] })
+
+	result := CheckResult{Linearizable: true, Records: len(records)}
+	for _, record := range records {
+		recordOps := byRecord[record]
+		result.Operations += len(recordOps)
+		if violation := checkRegister(record, recordOps); violation != nil {
+			result.Linearizable = false
+			result.Violations = append(result.Violations, *violation)
+		}
+	}
+	return result
+}
+
+// entry - call or return of operation in the list of events ordered by time
+type entry struct {
+	op         int
+	call       bool
+	time       int64
+	match      *entry // return of the call
+	prev, next *entry
+}
+
+// lift - removes operation from the list, as it's linearized
+func (e *entry) lift() {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.match.prev.next = e.match.next
+	if e.match.next != nil {
+		e.match.next.prev = e.match.prev
+	}
+}
+
+// unlift - puts removed operation back
+func (e *entry) unlift() {
+	e.match.prev.next = e.match
+	if e.match.next != nil {
+		e.match.next.prev = e.match
+	}
+	e.prev.next = e
+	e.next.prev = e
+}
+
+type bitset []uint64
+
+func (b bitset) set(i int) bitset {
+	b[i/64] |= 1 << uint(i%64)
+	return b
+}
+
+func (b bitset) clear(i int) bitset {
+	b[i/64] &^= 1 << uint(i%64)
+	return b
+}
+
+func (b bitset) key(state uint64) string {
+	key := make([]byte, 0, len(b)*8+8)
+	for _, word := range b {
+		key = binary.LittleEndian.AppendUint64(key, word)
+	}
+	return string(binary.LittleEndian.AppendUint64(key, state))
+}
+
+// checkRegister - searches linearization of operations of one record. Returns nil if it's found
+func checkRegister(record int64, ops []Operation) *Violation {
+	for _, op := range ops {
+		if op.Torn {
+			return &Violation{Record: record, Reason: fmt.Sprintf("client %d read torn record", op.Client), Ops: []Operation{op}}
+		}
+	}
+
+	events := make([]*entry, 0, 2*len(ops))
+	for i, op := range ops {
+		ret := op.Return
+		if op.Outcome == OutcomeInfo {
+			// lost write may take effect at any moment after its call
+			ret = math.MaxInt64
+		}
+		call := &entry{op: i, call: true, time: op.Call}
+		call.match = &entry{op: i, time: ret}
+		events = append(events, call, call.match)
+	}
+	// calls go before returns of the same time, so such operations are taken as concurrent
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].time != events[j].time {
+			return events[i].time < events[j].time
+		}
+		return events[i].call && !events[j].call
+	})
+	head := &entry{op: -1}
+	prev := head
+	for _, e := range events {
+		prev.next, e.prev = e, prev
+		prev = e
+	}
+
+	type frame struct {
+		entry *entry
+		state uint64
+	}
+	var (
+		stack      []frame
+		state      uint64 // never written record holds zeros
+		linearized = make(bitset, (len(ops)+63)/64)
+		visited    = make(map[string]bool)
+		longest    int
+	)
+	e := head.next
+	for head.next != nil {
+		if e.call {
+			op := ops[e.op]
+			next, ok := state, true
+			if op.Kind == OpWrite {
+				next = op.Value
+			} else {
+				ok = op.Value == state
+			}
+			if ok {
+				key := linearized.set(e.op).key(next)
+				linearized.clear(e.op)
+				if !visited[key] {
+					visited[key] = true
+					stack = append(stack, frame{entry: e, state: state})
+					if len(stack) > longest {
+						longest = len(stack)
+					}
+					state = next
+					linearized.set(e.op)
+					e.lift()
+					e = head.next
+					continue
+				}
+			}
+			e = e.next
+			continue
+		}
+		// some operation returned before it could be linearized: the last choice is undone
+		if len(stack) == 0 {
+			return &Violation{
+				Record: record,
+				Reason: fmt.Sprintf("no order of %d operations agrees with real time; at most %d were ordered", len(ops), longest),
+				Ops:    ops,
+			}
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		state = top.state
+		linearized.clear(top.entry.op)
+		top.entry.unlift()
+		e = top.entry.next
+	}
+	return nil
+}
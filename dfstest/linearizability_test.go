@@ -0,0 +1,134 @@
+package dfstest_test
+
+import (
+	"github.com/alikhil/distributed-fs/dfstest"
+	"testing"
+)
+
+// writeOp - successful write of record 1
+func writeOp(client int, value uint64, call, ret int64) dfstest.Operation {
+	return dfstest.Operation{Client: client, Record: 1, Kind: dfstest.OpWrite, Value: value, Call: call, Return: ret, Outcome: dfstest.OutcomeOK}
+}
+
+// readOp - successful read of record 1
+func readOp(client int, value uint64, call, ret int64) dfstest.Operation {
+	return dfstest.Operation{Client: client, Record: 1, Kind: dfstest.OpRead, Value: value, Call: call, Return: ret, Outcome: dfstest.OutcomeOK}
+}
+
+func withOutcome(op dfstest.Operation, outcome dfstest.Outcome) dfstest.Operation {
+	op.Outcome = outcome
+	return op
+}
+
+func onRecord(op dfstest.Operation, record int64) dfstest.Operation {
+	op.Record = record
+	return op
+}
+
+func TestCheckLinearizable(t *testing.T) {
+	torn := readOp(1, 0, 20, 30)
+	torn.Torn = true
+
+	cases := []struct {
+		name       string
+		ops        []dfstest.Operation
+		violations []int64 // records which can not be linearized
+	}{
+		{
+			name: "sequential write and read",
+			ops:  []dfstest.Operation{writeOp(0, 1, 0, 10), readOp(1, 1, 20, 30)},
+		},
+		{
+			name: "read before the first write sees zeros",
+			ops:  []dfstest.Operation{readOp(1, 0, 0, 10), writeOp(0, 1, 20, 30), readOp(1, 1, 40, 50)},
+		},
+		{
+			name:       "stale read",
+			ops:        []dfstest.Operation{writeOp(0, 1, 0, 10), writeOp(0, 2, 20, 30), readOp(1, 1, 40, 50)},
+			violations: []int64{1},
+		},
+		{
+			name: "read concurrent with write sees the old value",
+			ops:  []dfstest.Operation{writeOp(0, 1, 0, 10), writeOp(0, 2, 20, 40), readOp(1, 1, 25, 30)},
+		},
+		{
+			name: "read concurrent with write sees the new value",
+			ops:  []dfstest.Operation{writeOp(0, 1, 0, 10), writeOp(0, 2, 20, 40), readOp(1, 2, 25, 30)},
+		},
+		{
+			name:       "torn read",
+			ops:        []dfstest.Operation{writeOp(0, 1, 0, 10), torn},
+			violations: []int64{1},
+		},
+		{
+			name: "info write takes effect late",
+			ops: []dfstest.Operation{
+				writeOp(0, 1, 0, 10), withOutcome(writeOp(0, 2, 20, 30), dfstest.OutcomeInfo),
+				readOp(1, 1, 40, 50), readOp(1, 2, 60, 70),
+			},
+		},
+		{
+			name: "info write never takes effect",
+			ops: []dfstest.Operation{
+				writeOp(0, 1, 0, 10), withOutcome(writeOp(0, 2, 20, 30), dfstest.OutcomeInfo), readOp(1, 1, 40, 50),
+			},
+		},
+		{
+			name: "info write is not undone",
+			ops: []dfstest.Operation{
+				writeOp(0, 1, 0, 10), withOutcome(writeOp(0, 2, 20, 30), dfstest.OutcomeInfo),
+				readOp(1, 2, 40, 50), readOp(1, 1, 60, 70),
+			},
+			violations: []int64{1},
+		},
+		{
+			name: "failed write takes no effect",
+			ops: []dfstest.Operation{
+				writeOp(0, 1, 0, 10), withOutcome(writeOp(0, 2, 20, 30), dfstest.OutcomeFail), readOp(1, 2, 40, 50),
+			},
+			violations: []int64{1},
+		},
+		{
+			name: "failed read is skipped",
+			ops:  []dfstest.Operation{writeOp(0, 1, 0, 10), withOutcome(readOp(1, 7, 20, 30), dfstest.OutcomeFail)},
+		},
+		{
+			name: "concurrent writes take effect in any order",
+			ops: []dfstest.Operation{
+				writeOp(0, 1, 0, 30), writeOp(1, 2, 10, 20), readOp(2, 1, 40, 50), readOp(2, 1, 60, 70),
+			},
+		},
+		{
+			name: "concurrent writes take effect once",
+			ops: []dfstest.Operation{
+				writeOp(0, 1, 0, 30), writeOp(1, 2, 10, 20), readOp(2, 1, 40, 50), readOp(2, 2, 60, 70),
+			},
+			violations: []int64{1},
+		},
+		{
+			name: "records are checked separately",
+			ops: []dfstest.Operation{
+				writeOp(0, 1, 0, 10), onRecord(writeOp(0, 2, 20, 30), 2), readOp(1, 1, 40, 50),
+				onRecord(readOp(1, 2, 40, 50), 2), onRecord(readOp(1, 5, 40, 50), 3),
+				onRecord(writeOp(0, 3, 0, 10), 4), onRecord(readOp(1, 0, 40, 50), 4),
+			},
+			violations: []int64{3, 4},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := dfstest.CheckLinearizable(tc.ops)
+			if res.Linearizable != (len(tc.violations) == 0) {
+				t.Fatalf("history is linearizable: %v, want %v; violations %+v", res.Linearizable, len(tc.violations) == 0, res.Violations)
+			}
+			if len(res.Violations) != len(tc.violations) {
+				t.Fatalf("violations %+v, want ones of records %v", res.Violations, tc.violations)
+			}
+			for i, violation := range res.Violations {
+				if violation.Record != tc.violations[i] || violation.Reason == "" || len(violation.Ops) == 0 {
+					t.Fatalf("violation %+v, want one of record %d with reason and operations", violation, tc.violations[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package dfstest
+
+import (
+	"net"
+	"sync"
+)
+
+// listener - keeps accepted connections, so they can be broken to simulate crash or network partition
+type listener struct {
+	net.Listener
+
+	lock    sync.Mutex
+	conns   map[net.Conn]struct{}
+	blocked bool // accepted connections are closed at once
+}
+
+func newListener(l net.Listener) *listener {
+	return &listener{Listener: l, conns: make(map[net.Conn]struct{})}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		l.lock.Lock()
+		if l.blocked {
+			l.lock.Unlock()
+			conn.Close()
+			continue
+		}
+		l.conns[conn] = struct{}{}
+		l.lock.Unlock()
+		return &trackedConn{Conn: conn, listener: l}, nil
+	}
+}
+
+// setBlocked - breaks open connections and refuses new ones if blocked is set, otherwise accepts them again
+func (l *listener) setBlocked(blocked bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.blocked = blocked
+	if blocked {
+		l.closeConns()
+	}
+}
+
+// Close - stops listening and breaks open connections
+func (l *listener) Close() error {
+	err := l.Listener.Close()
+	l.lock.Lock()
+	l.closeConns()
+	l.lock.Unlock()
+	return err
+}
+
+func (l *listener) closeConns() {
+	for conn := range l.conns {
+		conn.Close()
+		delete(l.conns, conn)
+	}
+}
+
+// trackedConn - connection which is forgotten by listener when closed
+type trackedConn struct {
+	net.Conn
+	listener *listener
+}
+
+func (c *trackedConn) Close() error {
+	c.listener.lock.Lock()
+	delete(c.listener.conns, c.Conn)
+	c.listener.lock.Unlock()
+	return c.Conn.Close()
+}
@@ -0,0 +1,212 @@
+package dfstest
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/master"
+	"github.com/alikhil/distributed-fs/utils"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Nemesis - kind of failures injected into the cluster while workload runs
+type Nemesis string
+
+const (
+	// NemesisPartition - master loses connection to a peer for a while
+	NemesisPartition Nemesis = "partition"
+	// NemesisRestart - a peer is stopped and started again
+	NemesisRestart Nemesis = "restart"
+	// NemesisCrash - a peer crashes in the middle of a write; requires Options.Chaos of the cluster
+	NemesisCrash Nemesis = "crash"
+)
+
+// WorkloadOptions - settings of registers workload
+type WorkloadOptions struct {
+	File            string        // file whose records are read and written; "registers" if empty
+	Clients         int           // number of concurrent clients; 5 if 0
+	Records         int64         // number of records used as registers; 5 if 0
+	RecordSize      int32         // 16 if 0; at least 8
+	Duration        time.Duration // how long clients work; 5s if 0
+	ReadRatio       float64       // share of reads among operations
+	Nemesis         []Nemesis     // failures injected one after another
+	NemesisInterval time.Duration // how long every failure lasts and how long cluster works without it; 1s if 0
+	Seed            int64
+}
+
+// RunRegisters - runs clients which read and write records of the file as registers, injects failures into
+// the cluster meanwhile and returns history of operations, which can be checked by CheckLinearizable
+func (c *Cluster) RunRegisters(opts WorkloadOptions) (*History, error) {
+	if opts.File == "" {
+		opts.File = "registers"
+	}
+	if opts.Clients == 0 {
+		opts.Clients = 5
+	}
+	if opts.Records == 0 {
+		opts.Records = 5
+	}
+	if opts.RecordSize == 0 {
+		opts.RecordSize = 16
+	}
+	if opts.Duration == 0 {
+		opts.Duration = 5 * time.Second
+	}
+	if opts.NemesisInterval == 0 {
+		opts.NemesisInterval = time.Second
+	}
+	if opts.RecordSize < 8 {
+		return nil, utils.Errorf(utils.CodeInvalidArgument, "record size %d is less than 8", opts.RecordSize)
+	}
+	for _, nemesis := range opts.Nemesis {
+		if nemesis == NemesisCrash && c.Master.Chaos == nil {
+			return nil, utils.Errorf(utils.CodeInvalidArgument, "%s nemesis requires chaos of the cluster", nemesis)
+		}
+		if nemesis != NemesisPartition && nemesis != NemesisRestart && nemesis != NemesisCrash {
+			return nil, utils.Errorf(utils.CodeInvalidArgument, "unknown nemesis %q", nemesis)
+		}
+	}
+
+	if err := c.DFS.SetRecordSize(opts.File, opts.RecordSize); err != nil {
+		return nil, err
+	}
+	if err := c.DFS.CreateFile(opts.File); err != nil {
+		return nil, err
+	}
+
+	clients := make([]*utils.RemoteDFS, opts.Clients)
+	for i := range clients {
+		dfs, err := c.NewClient()
+		if err != nil {
+			return nil, err
+		}
+		defer dfs.Close()
+		if err = dfs.SetRecordSize(opts.File, opts.RecordSize); err != nil {
+			return nil, err
+		}
+		clients[i] = dfs
+	}
+
+	history := NewHistory()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, dfs := range clients {
+		wg.Add(1)
+		go func(client int, dfs *utils.RemoteDFS, random *rand.Rand) {
+			defer wg.Done()
+			runRegisterClient(client, dfs, opts, random, history, stop)
+		}(i, dfs, rand.New(rand.NewSource(opts.Seed+int64(i))))
+	}
+
+	var nemesisErr error
+	if len(opts.Nemesis) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nemesisErr = c.runNemesis(opts, rand.New(rand.NewSource(opts.Seed-1)), stop)
+		}()
+	}
+
+	time.Sleep(opts.Duration)
+	close(stop)
+	wg.Wait()
+	return history, nemesisErr
+}
+
+func runRegisterClient(client int, dfs *utils.RemoteDFS, opts WorkloadOptions, random *rand.Rand, history *History, stop chan struct{}) {
+	seq := uint64(0)
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		op := Operation{Client: client, Record: random.Int63n(opts.Records) + 1}
+		var err error
+		if random.Float64() < opts.ReadRatio {
+			op.Kind = OpRead
+			op.Call = history.Now()
+			var record []byte
+			record, err = dfs.ReadRecord(opts.File, op.Record)
+			op.Return = history.Now()
+			if err == nil {
+				value, ok := DecodeValue(record)
+				op.Value, op.Torn = value, !ok
+			}
+		} else {
+			// values are unique, so every read tells which write it saw
+			seq++
+			op.Kind = OpWrite
+			op.Value = uint64(client+1)<<32 | seq
+			op.Call = history.Now()
+			err = dfs.WriteRecord(opts.File, op.Record, EncodeValue(op.Value, opts.RecordSize))
+			op.Return = history.Now()
+		}
+		op.Outcome = outcomeOf(err)
+		if err != nil {
+			op.Error = err.Error()
+			// cluster is not ready while failure lasts, so it's not flooded with calls
+			time.Sleep(10 * time.Millisecond)
+		}
+		history.Add(op)
+	}
+}
+
+// outcomeOf - decides if failed operation may still take effect
+func outcomeOf(err error) Outcome {
+	if err == nil {
+		return OutcomeOK
+	}
+	switch utils.CodeOf(err) {
+	case utils.CodeNotReady, utils.CodeInvalidArgument, utils.CodeUnauthenticated, utils.CodePermissionDenied:
+		// master rejects such calls before they reach peers
+		return OutcomeFail
+	}
+	return OutcomeInfo
+}
+
+// runNemesis - injects failures one after another untill stop is closed
+func (c *Cluster) runNemesis(opts WorkloadOptions, random *rand.Rand, stop chan struct{}) error {
+	logger := utils.Logger(utils.LogHealth)
+	for i := 0; ; i++ {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(opts.NemesisInterval):
+		}
+
+		nemesis := opts.Nemesis[i%len(opts.Nemesis)]
+		peer := random.Intn(len(c.Peers))
+		endpoint := c.Peers[peer].Endpoint
+		logger.Info("injecting failure", "nemesis", nemesis, "peer", endpoint)
+		switch nemesis {
+		case NemesisPartition:
+			c.Partition(peer)
+		case NemesisRestart:
+			if err := c.StopPeer(peer); err != nil {
+				return err
+			}
+		case NemesisCrash:
+			c.Master.Chaos.SetPeerFaults(endpoint, master.Faults{CrashRate: 1, Downtime: opts.NemesisInterval})
+		}
+
+		select {
+		case <-stop:
+		case <-time.After(opts.NemesisInterval):
+		}
+
+		logger.Info("healing failure", "nemesis", nemesis, "peer", endpoint)
+		switch nemesis {
+		case NemesisPartition:
+			c.Heal(peer)
+		case NemesisRestart:
+			if err := c.StartPeer(peer); err != nil {
+				return fmt.Errorf("failed to start peer %d again: %v", peer, err)
+			}
+		case NemesisCrash:
+			c.Master.Chaos.ClearPeerFaults(endpoint)
+			c.Master.Chaos.Heal(endpoint)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package master
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Admin api of master is served over http:
+//   GET  /                              - status page
+//   GET  /api/cluster                   - utils.ClusterStatus as json
+//   POST /api/peers/drain?peer=host:port   - peer stops accepting changes, reads are still served
+//   POST /api/peers/undrain?peer=host:port - peer accepts changes again
+//   POST /api/peers/remove?peer=host:port  - drained peer is stopped and its slot waits for a new peer
+// If authentication is enabled, calls should have token with admin operation in Authorization header
+
+// adminServer - serves admin api of master
+type adminServer struct {
+	rfs *RemoteFS
+}
+
+// RunAdmin - serves admin api on port over TLS if config is not nil. Blocks untill server fails
+func RunAdmin(rfs *RemoteFS, port int, config *tls.Config) {
+	admin := &adminServer{rfs: rfs}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", admin.statusPage)
+	mux.HandleFunc("/api/cluster", admin.cluster)
+	mux.HandleFunc("/api/peers/drain", admin.peerAction("DrainPeer", func(endpoint string) error {
+		return rfs.setDraining(endpoint, true)
+	}))
+	mux.HandleFunc("/api/peers/undrain", admin.peerAction("UndrainPeer", func(endpoint string) error {
+		return rfs.setDraining(endpoint, false)
+	}))
+	mux.HandleFunc("/api/peers/remove", admin.peerAction("RemovePeer", rfs.removePeer))
+
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		utils.Fatal("failed to listen for admin api", "port", port, "err", err)
+	}
+	if config != nil {
+		l = tls.NewListener(l, config)
+	}
+	utils.Logger(utils.LogRPC).Info("started listening for admin api", "endpoint", fmt.Sprintf("%s:%v", utils.GetIPAddress(), port))
+	err = http.Serve(l, mux)
+	utils.Logger(utils.LogRPC).Error("admin server stopped", "err", err)
+}
+
+// authorize - checks that caller is allowed to administer the cluster
+func (admin *adminServer) authorize(req *http.Request, method string) error {
+	call := &utils.CallContext{Token: strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")}
+	// admin operation is granted only by rules which match any file
+	return admin.rfs.authorize(call, method, OpAdmin, "")
+}
+
+// writeError - answers with http status matching code of err. Body is err encoded as in rpc, so clients restore its code
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch utils.CodeOf(err) {
+	case utils.CodeInvalidArgument:
+		status = http.StatusBadRequest
+	case utils.CodeUnauthenticated:
+		status = http.StatusUnauthorized
+	case utils.CodePermissionDenied:
+		status = http.StatusForbidden
+	case utils.CodeNotFound:
+		status = http.StatusNotFound
+	case utils.CodeNotReady, utils.CodePeerUnavailable, utils.CodeUnavailable:
+		status = http.StatusServiceUnavailable
+	case utils.CodeNoSpace:
+		status = http.StatusInsufficientStorage
+	}
+	http.Error(w, utils.EncodeError(err).Error(), status)
+}
+
+func (admin *adminServer) cluster(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := admin.authorize(req, "ClusterStatus"); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(admin.rfs.clusterStatus())
+}
+
+// peerAction - returns handler which applies action to the peer passed in query and answers with new status of the cluster
+func (admin *adminServer) peerAction(method string, action func(endpoint string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := admin.authorize(req, method); err != nil {
+			writeError(w, err)
+			return
+		}
+		endpoint := req.URL.Query().Get("peer")
+		if endpoint == "" {
+			writeError(w, utils.NewError(utils.CodeInvalidArgument, "peer is not set"))
+			return
+		}
+		if err := action(endpoint); err != nil {
+			utils.Logger(utils.LogHealth).Warn("admin action failed", "action", method, "peer", endpoint, "err", err)
+			writeError(w, err)
+			return
+		}
+		utils.Logger(utils.LogHealth).Info("admin action applied", "action", method, "peer", endpoint)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(admin.rfs.clusterStatus())
+	}
+}
+
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"latencyMs": func(seconds float64) float64 { return seconds * 1000 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>DFS master</title><meta http-equiv="refresh" content="5"></head>
+<body>
+<h1>DFS master</h1>
+<p>Ready to use: <b>{{.ReadyToUse}}</b>, peers: {{len .Peers}} of {{.PeersCount}}, consistency: {{.Consistency}}</p>
+<table border="1" cellpadding="4">
+<tr><th>Slot</th><th>Endpoint</th><th>Status</th><th>Draining</th><th>Last ping</th><th>Latency, ms</th><th>Files</th><th>Disk usage, bytes</th><th>Error</th></tr>
+{{range .Peers}}<tr>
+<td>{{.Slot}}</td><td>{{.Endpoint}}</td><td>{{.Status}}</td><td>{{.Draining}}</td>
+<td>{{if .LastPing}}{{.LastPing.Format "2006-01-02 15:04:05"}}{{end}}</td>
+<td>{{printf "%.2f" (latencyMs .LatencySeconds)}}</td><td>{{.Files}}</td><td>{{.DiskUsageBytes}}</td><td>{{.Error}}</td>
+</tr>{{end}}
+</table>
+</body>
+</html>
+`))
+
+func (admin *adminServer) statusPage(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+	if err := admin.authorize(req, "ClusterStatus"); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPage.Execute(w, admin.rfs.clusterStatus()); err != nil {
+		utils.Logger(utils.LogRPC).Warn("failed to render status page", "err", err)
+	}
+}
+
+// peerStatsTimeout - how long status of the cluster waits for usage of storage of a peer
+const peerStatsTimeout = 2 * time.Second
+
+// clusterStatus - returns state of all the peers. Usage of storage is requested from peers concurrently,
+// and peer which does not answer in peerStatsTimeout gets error in its status
+func (rfs *RemoteFS) clusterStatus() utils.ClusterStatus {
+	rfs.nodesLock.Lock()
+	status := utils.ClusterStatus{
+		ReadyToUse:  rfs.ReadyToUse,
+		PeersCount:  rfs.PeersCount,
+		Consistency: rfs.Consistency.String(),
+		Peers:       make([]utils.PeerStatus, len(rfs.Nodes)),
+	}
+	peers := make([]Peer, len(rfs.Nodes))
+	for slot, node := range rfs.Nodes {
+		peer := &status.Peers[slot]
+		peer.Slot = slot
+		peer.Endpoint = *node.Endpoint
+		peer.Status = node.ConStatus.String()
+		if node.Removed {
+			peer.Status = "removed"
+		}
+		peer.Draining = node.Draining
+		if !node.LastPing.IsZero() {
+			lastPing := node.LastPing
+			peer.LastPing = &lastPing
+			peer.LatencySeconds = node.Latency.Seconds()
+		}
+		if node.ConStatus == Connected {
+			peers[slot] = node.Peer
+		}
+	}
+	rfs.nodesLock.Unlock()
+
+	// peers are asked without lock, so slow peer does not delay health checks
+	var wg sync.WaitGroup
+	for slot, peer := range peers {
+		if peer == nil {
+			status.Peers[slot].Error = ErrPeerDisconnected.Error()
+			continue
+		}
+		wg.Add(1)
+		go func(status *utils.PeerStatus, peer Peer) {
+			defer wg.Done()
+			var stats *utils.PeerStats
+			err := callWithin(peerStatsTimeout, func() (err error) {
+				stats, err = peer.Stats()
+				return err
+			})
+			if err != nil {
+				status.Error = err.Error()
+				return
+			}
+			status.Files = stats.Files
+			status.DiskUsageBytes = stats.DiskUsage
+		}(&status.Peers[slot], peer)
+	}
+	wg.Wait()
+	return status
+}
+
+// findNode - returns node of the peer which is not removed. rfs.nodesLock should be held
+func (rfs *RemoteFS) findNode(endpoint string) (*Node, error) {
+	for _, node := range rfs.Nodes {
+		if *node.Endpoint == endpoint && !node.Removed {
+			return node, nil
+		}
+	}
+	return nil, utils.Errorf(utils.CodeNotFound, "peer(%s) is not in the cluster", endpoint)
+}
+
+// PeerStatus - returns status of connection to the peer as it's seen by health checker
+func (rfs *RemoteFS) PeerStatus(endpoint string) (ConnectionStatus, error) {
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+
+	node, err := rfs.findNode(endpoint)
+	if err != nil {
+		return Unknown, err
+	}
+	return node.ConStatus, nil
+}
+
+// setDraining - stops or resumes changes of records owned by the peer
+func (rfs *RemoteFS) setDraining(endpoint string, draining bool) error {
+	defer rfs.tellEpoch()
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+
+	node, err := rfs.findNode(endpoint)
+	if err != nil {
+		return err
+	}
+	if node.Draining != draining {
+		node.Draining = draining
+		rfs.changePlacementLocked("peer draining changed")
+	}
+	return nil
+}
+
+// removePeer - stops drained peer and frees its slot. Peer which joins next takes the slot,
+// so it should be started with copy of files of removed peer to keep its records
+func (rfs *RemoteFS) removePeer(endpoint string) error {
+	rfs.nodesLock.Lock()
+	node, err := rfs.findNode(endpoint)
+	if err == nil && !node.Draining {
+		err = utils.Errorf(utils.CodeInvalidArgument, "peer(%s) should be drained before removal", endpoint)
+	}
+	if err != nil {
+		rfs.nodesLock.Unlock()
+		return err
+	}
+	peer := node.Peer
+	node.Removed = true
+	node.ConStatus = Disconnected
+	node.Peer = nil
+	rfs.ReadyToUse = false
+	rfs.Metrics.peerState(endpoint, node.ConStatus)
+	rfs.changePlacementLocked("peer removed")
+	rfs.nodesLock.Unlock()
+	rfs.tellEpoch()
+
+	if peer != nil {
+		if err := peer.Close(); err != nil {
+			utils.Logger(utils.LogHealth).Warn("failed to close removed peer", "peer", endpoint, "err", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operation - kind of access to a file checked by ACL
+type Operation string
+
+const (
+	OpRead   Operation = "read"
+	OpWrite  Operation = "write"
+	OpCreate Operation = "create"
+	OpDelete Operation = "delete"
+	// OpAdmin - viewing and changing state of the cluster with admin api. Granted only by rules without file and prefix
+	OpAdmin Operation = "admin"
+)
+
+// ACLRule - grants operations on files to subject of token. Subject "*" matches any client.
+// Rule matches file with name File or, if File is empty, any file which name starts with Prefix
+type ACLRule struct {
+	Subject string      `json:"subject"`
+	File    string      `json:"file,omitempty"`
+	Prefix  string      `json:"prefix,omitempty"`
+	Ops     []Operation `json:"ops"`
+}
+
+func (rule *ACLRule) allows(subject string, op Operation, filename string) bool {
+	if rule.Subject != "*" && rule.Subject != subject {
+		return false
+	}
+	if rule.File != "" && rule.File != filename {
+		return false
+	}
+	if rule.File == "" && !strings.HasPrefix(filename, rule.Prefix) {
+		return false
+	}
+	for _, o := range rule.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizer - checks tokens of clients and their permissions on files.
+// Every denied call is written to audit
+type Authorizer struct {
+	Secret []byte
+	Rules  []ACLRule // if nil any authenticated client can do everything
+	Audit  io.Writer // if nil audit records are written to log
+
+	auditLock sync.Mutex
+}
+
+// LoadAuthorizer - reads secret of tokens and ACL from files. ACL file is json: {"rules": [ACLRule...]}
+func LoadAuthorizer(secretFile, aclFile, auditFile string) (*Authorizer, error) {
+	secret, err := ioutil.ReadFile(secretFile)
+	if err != nil {
+		return nil, err
+	}
+	auth := &Authorizer{Secret: bytes.TrimSpace(secret)}
+	if len(auth.Secret) == 0 {
+		return nil, fmt.Errorf("secret in %s is empty", secretFile)
+	}
+
+	if aclFile != "" {
+		data, err := ioutil.ReadFile(aclFile)
+		if err != nil {
+			return nil, err
+		}
+		var acl struct {
+			Rules []ACLRule `json:"rules"`
+		}
+		if err = json.Unmarshal(data, &acl); err != nil {
+			return nil, fmt.Errorf("failed to parse ACL %s: %v", aclFile, err)
+		}
+		auth.Rules = acl.Rules
+		if auth.Rules == nil {
+			auth.Rules = []ACLRule{}
+		}
+	}
+
+	if auditFile != "" {
+		auth.Audit, err = os.OpenFile(auditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return auth, nil
+}
+
+type auditRecord struct {
+	Time    string    `json:"time"`
+	Subject string    `json:"subject,omitempty"`
+	Method  string    `json:"method"`
+	Op      Operation `json:"op"`
+	File    string    `json:"file,omitempty"`
+	Reason  string    `json:"reason"`
+}
+
+func (auth *Authorizer) audit(record auditRecord) {
+	record.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	line, _ := json.Marshal(record)
+
+	if auth.Audit == nil {
+		utils.Logger(utils.LogRPC).Warn("call denied", "audit", string(line))
+		return
+	}
+	auth.auditLock.Lock()
+	defer auth.auditLock.Unlock()
+	auth.Audit.Write(append(line, '\n'))
+}
+
+// Authorize - checks that client which made the call is allowed to do op with all the files
+func (auth *Authorizer) Authorize(call *utils.CallContext, method string, op Operation, files ...string) error {
+	claims, err := utils.VerifyToken(auth.Secret, call.Token)
+	if err != nil {
+		auth.audit(auditRecord{Method: method, Op: op, File: strings.Join(files, ","), Reason: err.Error()})
+		return err
+	}
+	if auth.Rules == nil {
+		return nil
+	}
+
+	for _, filename := range files {
+		allowed := false
+		for i := range auth.Rules {
+			if auth.Rules[i].allows(claims.Subject, op, filename) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			auth.audit(auditRecord{Subject: claims.Subject, Method: method, Op: op, File: filename, Reason: "no ACL rule allows it"})
+			if filename == "" {
+				return utils.Errorf(utils.CodePermissionDenied, "%s is not allowed for %s", op, claims.Subject)
+			}
+			return utils.Errorf(utils.CodePermissionDenied, "%s of file(%s) is not allowed for %s", op, filename, claims.Subject)
+		}
+	}
+	return nil
+}
+
+// Allowed - returns those of the files which client that made the call is allowed to do op with.
+// Only invalid token is audited, since files which are not allowed are just skipped
+func (auth *Authorizer) Allowed(call *utils.CallContext, method string, op Operation, files []string) ([]string, error) {
+	claims, err := utils.VerifyToken(auth.Secret, call.Token)
+	if err != nil {
+		auth.audit(auditRecord{Method: method, Op: op, Reason: err.Error()})
+		return nil, err
+	}
+	if auth.Rules == nil {
+		return files, nil
+	}
+
+	allowed := make([]string, 0, len(files))
+	for _, filename := range files {
+		for i := range auth.Rules {
+			if auth.Rules[i].allows(claims.Subject, op, filename) {
+				allowed = append(allowed, filename)
+				break
+			}
+		}
+	}
+	return allowed, nil
+}
+
+// allowedFiles - filters out files which are not allowed for the call if authorization is enabled
+func (rfs *RemoteFS) allowedFiles(call *utils.CallContext, method string, op Operation, files []string) ([]string, error) {
+	if rfs.Auth == nil {
+		return files, nil
+	}
+	return rfs.Auth.Allowed(call, method, op, files)
+}
+
+// authorize - checks permissions of the call if authorization is enabled
+func (rfs *RemoteFS) authorize(call *utils.CallContext, method string, op Operation, files ...string) error {
+	if rfs.Auth == nil {
+		return nil
+	}
+	return rfs.Auth.Authorize(call, method, op, files...)
+}
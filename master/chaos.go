@@ -0,0 +1,302 @@
+package master
+
+import (
+	"context"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Faults - faults injected into calls of master to a peer.
+// Latency and partitions apply to every call, errors and drops only to calls with data, so health checks are not flapping
+type Faults struct {
+	Latency   time.Duration // added to every call
+	Jitter    time.Duration // random latency up to it is added to every call
+	ErrorRate float64       // share of calls which fail before they reach the peer
+	DropRate  float64       // share of calls which are done by the peer, but their results are lost
+	CrashRate float64       // share of writes after which peer crashes having written only part of the record
+	Downtime  time.Duration // how long crashed peer is unavailable; 1s if 0
+}
+
+// Chaos - injects faults into calls of master to peers. It's used by tests and by dev master started with -chaos flag
+type Chaos struct {
+	lock        sync.Mutex
+	faults      Faults
+	peerFaults  map[string]Faults    // overrides faults of the peer
+	partitioned map[string]bool      // peers which master can not reach untill Heal
+	downUntil   map[string]time.Time // crashed peers
+	random      *rand.Rand
+}
+
+// NewChaos - returns chaos which injects faults into calls to every peer. Same seed gives same faults for same calls
+func NewChaos(faults Faults, seed int64) *Chaos {
+	return &Chaos{
+		faults:      faults,
+		peerFaults:  make(map[string]Faults),
+		partitioned: make(map[string]bool),
+		downUntil:   make(map[string]time.Time),
+		random:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// ParseChaos - parses faults in form latency=5ms,jitter=5ms,error-rate=0.01,drop-rate=0.01,crash-rate=0.001,downtime=2s,seed=1
+func ParseChaos(spec string) (*Chaos, error) {
+	var faults Faults
+	seed := time.Now().UnixNano()
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, utils.Errorf(utils.CodeInvalidArgument, "chaos setting %q should be in form name=value", item)
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		var err error
+		switch name {
+		case "latency":
+			faults.Latency, err = time.ParseDuration(value)
+		case "jitter":
+			faults.Jitter, err = time.ParseDuration(value)
+		case "downtime":
+			faults.Downtime, err = time.ParseDuration(value)
+		case "error-rate":
+			faults.ErrorRate, err = parseRate(value)
+		case "drop-rate":
+			faults.DropRate, err = parseRate(value)
+		case "crash-rate":
+			faults.CrashRate, err = parseRate(value)
+		case "seed":
+			seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return nil, utils.Errorf(utils.CodeInvalidArgument, "unknown chaos setting %q", name)
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.CodeInvalidArgument, "invalid chaos setting %q: %v", item, err)
+		}
+	}
+	return NewChaos(faults, seed), nil
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err == nil && (rate < 0 || rate > 1) {
+		err = fmt.Errorf("rate %v is not in [0, 1]", rate)
+	}
+	return rate, err
+}
+
+// SetPeerFaults - sets faults of calls to the peer instead of common ones
+func (chaos *Chaos) SetPeerFaults(endpoint string, faults Faults) {
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	chaos.peerFaults[endpoint] = faults
+}
+
+// ClearPeerFaults - makes calls to the peer get common faults again
+func (chaos *Chaos) ClearPeerFaults(endpoint string) {
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	delete(chaos.peerFaults, endpoint)
+}
+
+// Partition - fails all calls to the peer untill Heal is called
+func (chaos *Chaos) Partition(endpoint string) {
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	chaos.partitioned[endpoint] = true
+}
+
+// Heal - lets calls reach partitioned or crashed peer again
+func (chaos *Chaos) Heal(endpoint string) {
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	delete(chaos.partitioned, endpoint)
+	delete(chaos.downUntil, endpoint)
+}
+
+func (chaos *Chaos) wrap(endpoint string, peer Peer) Peer {
+	return &chaosPeer{peer: peer, endpoint: endpoint, chaos: chaos}
+}
+
+// chance - returns true with probability rate
+func (chaos *Chaos) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	return chaos.random.Float64() < rate
+}
+
+// before - delays the call and decides if it reaches the peer
+func (chaos *Chaos) before(endpoint, method string, withData bool) (Faults, error) {
+	chaos.lock.Lock()
+	faults, ok := chaos.peerFaults[endpoint]
+	if !ok {
+		faults = chaos.faults
+	}
+	delay := faults.Latency
+	if faults.Jitter > 0 {
+		delay += time.Duration(chaos.random.Int63n(int64(faults.Jitter)))
+	}
+	down := chaos.partitioned[endpoint] || time.Now().Before(chaos.downUntil[endpoint])
+	chaos.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if down {
+		return faults, utils.Errorf(utils.CodePeerUnavailable, "chaos: peer(%s) is unreachable", endpoint)
+	}
+	if withData && chaos.chance(faults.ErrorRate) {
+		return faults, utils.Errorf(utils.CodePeerUnavailable, "chaos: %s to peer(%s) failed", method, endpoint)
+	}
+	return faults, nil
+}
+
+// after - loses result of the call done by the peer
+func (chaos *Chaos) after(endpoint, method string, faults Faults, err error) error {
+	if err == nil && chaos.chance(faults.DropRate) {
+		return utils.Errorf(utils.CodePeerUnavailable, "chaos: result of %s from peer(%s) is lost", method, endpoint)
+	}
+	return err
+}
+
+// crash - makes the peer unavailable for downtime
+func (chaos *Chaos) crash(endpoint string, downtime time.Duration) {
+	if downtime <= 0 {
+		downtime = time.Second
+	}
+	chaos.lock.Lock()
+	defer chaos.lock.Unlock()
+	chaos.downUntil[endpoint] = time.Now().Add(downtime)
+}
+
+// chaosPeer - passes calls to peer injecting faults into them
+type chaosPeer struct {
+	peer     Peer
+	endpoint string
+	chaos    *Chaos
+}
+
+func (p *chaosPeer) Ping() error {
+	if _, err := p.chaos.before(p.endpoint, "Ping", false); err != nil {
+		return err
+	}
+	return p.peer.Ping()
+}
+
+func (p *chaosPeer) Close() error {
+	if _, err := p.chaos.before(p.endpoint, "Close", false); err != nil {
+		return err
+	}
+	return p.peer.Close()
+}
+
+func (p *chaosPeer) Stats() (*utils.PeerStats, error) {
+	if _, err := p.chaos.before(p.endpoint, "Stats", false); err != nil {
+		return nil, err
+	}
+	return p.peer.Stats()
+}
+
+func (p *chaosPeer) SetEpoch(epoch uint64) error {
+	if _, err := p.chaos.before(p.endpoint, "SetEpoch", false); err != nil {
+		return err
+	}
+	return p.peer.SetEpoch(epoch)
+}
+
+func (p *chaosPeer) FileExists(ctx context.Context, fname *string) (bool, error) {
+	faults, err := p.chaos.before(p.endpoint, "FileExists", true)
+	if err != nil {
+		return false, err
+	}
+	exists, err := p.peer.FileExists(ctx, fname)
+	return exists, p.chaos.after(p.endpoint, "FileExists", faults, err)
+}
+
+func (p *chaosPeer) DeleteFile(ctx context.Context, fname *string) error {
+	faults, err := p.chaos.before(p.endpoint, "DeleteFile", true)
+	if err != nil {
+		return err
+	}
+	return p.chaos.after(p.endpoint, "DeleteFile", faults, p.peer.DeleteFile(ctx, fname))
+}
+
+func (p *chaosPeer) ReadBytes(ctx context.Context, readArgs *utils.IOReadArgs) (*[]byte, error) {
+	faults, err := p.chaos.before(p.endpoint, "ReadBytes", true)
+	if err != nil {
+		return nil, err
+	}
+	data, err := p.peer.ReadBytes(ctx, readArgs)
+	return data, p.chaos.after(p.endpoint, "ReadBytes", faults, err)
+}
+
+func (p *chaosPeer) WriteBytes(ctx context.Context, filename *string, offset int32, data *[]byte) error {
+	faults, err := p.chaos.before(p.endpoint, "WriteBytes", true)
+	if err != nil {
+		return err
+	}
+	if p.chaos.chance(faults.CrashRate) {
+		// peer crashes in the middle of the record: only its beginning reaches the disk
+		p.chaos.lock.Lock()
+		torn := (*data)[:p.chaos.random.Intn(len(*data)+1)]
+		p.chaos.lock.Unlock()
+		if len(torn) > 0 {
+			p.peer.WriteBytes(ctx, filename, offset, &torn)
+		}
+		p.chaos.crash(p.endpoint, faults.Downtime)
+		return utils.Errorf(utils.CodePeerUnavailable, "chaos: peer(%s) crashed after writing %d of %d bytes", p.endpoint, len(torn), len(*data))
+	}
+	return p.chaos.after(p.endpoint, "WriteBytes", faults, p.peer.WriteBytes(ctx, filename, offset, data))
+}
+
+func (p *chaosPeer) CreateFile(ctx context.Context, filename *string) error {
+	faults, err := p.chaos.before(p.endpoint, "CreateFile", true)
+	if err != nil {
+		return err
+	}
+	return p.chaos.after(p.endpoint, "CreateFile", faults, p.peer.CreateFile(ctx, filename))
+}
+
+func (p *chaosPeer) FileSize(ctx context.Context, filename *string) (int64, error) {
+	faults, err := p.chaos.before(p.endpoint, "FileSize", true)
+	if err != nil {
+		return 0, err
+	}
+	size, err := p.peer.FileSize(ctx, filename)
+	return size, p.chaos.after(p.endpoint, "FileSize", faults, err)
+}
+
+func (p *chaosPeer) Extents(ctx context.Context, filename *string) ([]utils.Extent, error) {
+	faults, err := p.chaos.before(p.endpoint, "Extents", true)
+	if err != nil {
+		return nil, err
+	}
+	extents, err := p.peer.Extents(ctx, filename)
+	return extents, p.chaos.after(p.endpoint, "Extents", faults, err)
+}
+
+func (p *chaosPeer) ListFiles(ctx context.Context, prefix string) ([]utils.FileInfo, error) {
+	faults, err := p.chaos.before(p.endpoint, "ListFiles", true)
+	if err != nil {
+		return nil, err
+	}
+	files, err := p.peer.ListFiles(ctx, prefix)
+	return files, p.chaos.after(p.endpoint, "ListFiles", faults, err)
+}
+
+func (p *chaosPeer) RenameFile(ctx context.Context, filename, newFilename *string) error {
+	faults, err := p.chaos.before(p.endpoint, "RenameFile", true)
+	if err != nil {
+		return err
+	}
+	return p.chaos.after(p.endpoint, "RenameFile", faults, p.peer.RenameFile(ctx, filename, newFilename))
+}
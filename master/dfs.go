@@ -0,0 +1,898 @@
+package master
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"math"
+	"sync"
+	"time"
+)
+
+type DistributedFileSystem struct {
+	RemoteInterface *RemoteFS
+}
+
+// CloseConnections - stops all connectedBefore to the master peers
+func (dfs *DistributedFileSystem) CloseConnections() {
+	for _, node := range dfs.RemoteInterface.nodes() {
+		if node.ConStatus == Connected {
+			utils.Logger(utils.LogHealth).Info("sending close command to peer", "peer", *node.Endpoint)
+			err := node.Peer.Close()
+			if err != nil {
+				utils.Logger(utils.LogHealth).Warn("failed to close peer", "peer", *node.Endpoint, "err", err)
+			}
+		}
+	}
+}
+
+type ConnectionStatus byte
+
+const (
+	Unknown ConnectionStatus = iota
+	Connected
+	Disconnected
+)
+
+func (status ConnectionStatus) String() string {
+	switch status {
+	case Connected:
+		return "connected"
+	case Disconnected:
+		return "disconnected"
+	}
+	return "unknown"
+}
+
+type Node struct {
+	Endpoint     *string
+	DataEndpoint string // where peer serves clients directly; empty if it does not
+	Peer         Peer
+	ConStatus    ConnectionStatus
+	LastPing     time.Time     // time of the last successful ping
+	Latency      time.Duration // duration of the last successful ping
+	Draining     bool          // set by admin; peer serves reads, but does not accept changes
+	Removed      bool          // set by admin; slot of the peer waits for a new peer to join
+
+	epoch      uint64 // the last epoch of placement told to the peer
+	controlKey []byte // signs control calls to the peer; given to it on join
+	accessKey  []byte // signs access tokens of clients to records kept by the peer; given to it on join
+}
+
+var ErrPeerDraining = utils.NewError(utils.CodePeerUnavailable, "peer is draining")
+
+type RemoteFS struct {
+	Nodes                  []*Node
+	PeersCount             int
+	HealthCheckerIsRunnnig bool
+	HealthCheckerTicker    *time.Ticker
+	HealthCheckInterval    time.Duration // how often peers are pinged; every second if 0
+	ReadyToUse             bool
+	Consistency            Consistency   // rule applied to create, delete and file exists requests
+	PeerTLS                *tls.Config   // used to connect to peers if not nil
+	Auth                   *Authorizer   // checks permissions of clients if not nil
+	JoinToken              string        // if set peers should present it to join the cluster
+	Metrics                *Metrics      // records data passed through master if not nil
+	Chaos                  *Chaos        // injects faults into calls to peers if not nil; for testing only
+	AccessTokenTTL         time.Duration // how long tokens given with placement are valid; a minute if 0
+
+	recordSizes     map[string]int32 // record size of every file set by clients
+	recordSizesLock sync.RWMutex
+
+	fileEnds      map[string]int32     // id of the next record to append for each file
+	writeExpiries map[string]time.Time // when write placements given for the file expire
+	fileEndsLock  sync.Mutex
+
+	nodesLock         sync.Mutex    // held while nodes are joined, checked or changed by admin
+	stopHealthChecker chan struct{} // closed to stop health checker
+
+	epoch uint64 // epoch of placement; changed with atomic, since it's read by placement without locks
+}
+
+var ErrNotReady = utils.NewError(utils.CodeNotReady, "master cannot be used as distributed FS yet. wait untill peers will be connected")
+
+// Calls which lack file name or data are rejected before handlers dereference them, and calls with offsets out of
+// range are rejected by checkRange before ids of records are computed, since net/rpc does not recover panics and
+// one such call would stop master
+var (
+	errMissingFilename = utils.NewError(utils.CodeInvalidArgument, "file name is missing")
+	errMissingData     = utils.NewError(utils.CodeInvalidArgument, "data is missing")
+)
+
+// checkRange - checks that count bytes from offset lie within int32 offsets of the file
+func checkRange(filename string, offset int32, count int64) error {
+	if offset < 0 || count < 0 || int64(offset)+count > math.MaxInt32 {
+		return utils.Errorf(utils.CodeInvalidArgument, "%d bytes at offset %d of file(%s) are out of range", count, offset, filename)
+	}
+	return nil
+}
+
+// InitRecordMappings - sets record sizes of the files; should be called before any read and write operation.
+// Record sizes of other files are kept, so client can change only files it's allowed to write
+func (rfs *RemoteFS) InitRecordMappings(mappingsArgs *utils.IOMappingsArgs, ok *bool) error {
+	if err := rfs.authorize(&mappingsArgs.CallContext, "InitRecordMappings", OpWrite); err != nil {
+		return err
+	}
+	if mappingsArgs.Mappings == nil {
+		return utils.NewError(utils.CodeInvalidArgument, "record sizes are missing")
+	}
+	utils.RequestLogger(mappingsArgs.Context(), utils.LogRPC).Debug("received init record mappings", "files", len(*mappingsArgs.Mappings))
+
+	for filename, size := range *mappingsArgs.Mappings {
+		if err := rfs.authorize(&mappingsArgs.CallContext, "InitRecordMappings", OpWrite, filename); err != nil {
+			return err
+		}
+		if size <= 0 {
+			return utils.Errorf(utils.CodeInvalidArgument, "record size of file(%s) should be positive", filename)
+		}
+	}
+
+	rfs.updateRecordSizes(func(sizes map[string]int32) {
+		for filename, size := range *mappingsArgs.Mappings {
+			sizes[filename] = size
+		}
+	})
+	for filename := range *mappingsArgs.Mappings {
+		rfs.forgetFileEnd(filename)
+	}
+	*ok = true
+	return nil
+}
+
+func (rfs *RemoteFS) WriteBytes(writeArgs *utils.IOWriteArgs, ok *bool) (err error) {
+	if writeArgs.Filename == nil {
+		return errMissingFilename
+	}
+	if writeArgs.Data == nil {
+		return errMissingData
+	}
+	ctx, span := utils.StartSpan(&writeArgs.CallContext, "RemoteIO.WriteBytes", utils.FileAttribute(*writeArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogRPC).Debug("received write bytes", "file", *writeArgs.Filename, "offset", writeArgs.Offset, "size", len(*writeArgs.Data))
+
+	if err := rfs.authorize(&writeArgs.CallContext, "WriteBytes", OpWrite, *writeArgs.Filename); err != nil {
+		return err
+	}
+	if err := checkRange(*writeArgs.Filename, writeArgs.Offset, int64(len(*writeArgs.Data))); err != nil {
+		return err
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	recordSize, err := rfs.recordSize(*writeArgs.Filename)
+	if err != nil {
+		return err
+	}
+	// part of a record would be dropped, which happens when client writes with record size changed by other client
+	if writeArgs.Offset%recordSize != 0 || int32(len(*writeArgs.Data))%recordSize != 0 {
+		return utils.Errorf(utils.CodeInvalidArgument, "%d bytes at offset %d are not whole records of %d bytes of file(%s)", len(*writeArgs.Data), writeArgs.Offset, recordSize, *writeArgs.Filename)
+	}
+
+	err = rfs.writeRecords(ctx, writeArgs.Filename, writeArgs.Offset, writeArgs.Data, recordSize)
+	if err != nil {
+		return err
+	}
+	rfs.extendFileEnd(*writeArgs.Filename, writeArgs.Offset/recordSize+int32(len(*writeArgs.Data))/recordSize+1)
+	rfs.Metrics.fileWritten(*writeArgs.Filename, len(*writeArgs.Data))
+
+	*ok = true
+	return nil
+}
+
+// writeRecords - writes records starting from offset to the peers which own them
+func (rfs *RemoteFS) writeRecords(ctx context.Context, filename *string, offset int32, data *[]byte, recordSize int32) (err error) {
+	firstID := offset/recordSize + 1
+	lastID := firstID + int32(len(*data))/recordSize - 1
+	pcnt := int32(rfs.PeersCount)
+
+	ctx, span := startBatchSpan(ctx, "writeRecords", *filename, firstID, lastID-firstID+1)
+	defer func() { utils.EndSpan(span, err) }()
+
+	nodes := rfs.nodes()
+	if len(nodes) != rfs.PeersCount {
+		return ErrNotReady
+	}
+	off := int32(0)
+	for id := firstID; id <= lastID; id++ {
+		node := nodes[id%pcnt]
+		record := (*data)[off : off+recordSize]
+		if node.Draining {
+			return utils.Errorf(utils.CodePeerUnavailable, "one of peers(%v) is draining; records owned by it can not be written", *node.Endpoint)
+		} else if node.ConStatus == Connected {
+			err := node.Peer.WriteBytes(ctx, filename, offset, &record)
+			if err != nil {
+				utils.RequestLogger(ctx, utils.LogRPC).Warn("peer failed to write record", "file", *filename, "id", id, "peer", *node.Endpoint, "err", err)
+				return err
+			}
+		} else {
+			return utils.Errorf(utils.CodePeerUnavailable, "one of peers(%v) is disconnected; we can not update all wr", *node.Endpoint)
+		}
+		offset += recordSize
+		off += recordSize
+	}
+	return nil
+}
+
+// AppendRecords - reserves next free record ids of the file and writes data to them.
+// Reservation is atomic, so concurrent appends to the same file never overlap.
+func (rfs *RemoteFS) AppendRecords(appendArgs *utils.IOAppendArgs, res *utils.IOAppendResult) (err error) {
+	if appendArgs.Filename == nil {
+		return errMissingFilename
+	}
+	if appendArgs.Data == nil {
+		return errMissingData
+	}
+	ctx, span := utils.StartSpan(&appendArgs.CallContext, "RemoteIO.AppendRecords", utils.FileAttribute(*appendArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogRPC)
+	logger.Debug("received append records", "file", *appendArgs.Filename, "size", len(*appendArgs.Data))
+
+	if err := rfs.authorize(&appendArgs.CallContext, "AppendRecords", OpWrite, *appendArgs.Filename); err != nil {
+		return err
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	recordSize, err := rfs.recordSize(*appendArgs.Filename)
+	if err != nil {
+		return err
+	}
+
+	count := int32(len(*appendArgs.Data)) / recordSize
+	if count == 0 || int32(len(*appendArgs.Data))%recordSize != 0 {
+		return utils.Errorf(utils.CodeInvalidArgument, "data length %d is not a positive multiple of record size %d", len(*appendArgs.Data), recordSize)
+	}
+
+	firstID, err := rfs.reserveRecords(ctx, *appendArgs.Filename, recordSize, count)
+	if err != nil {
+		return err
+	}
+
+	offset := (firstID - 1) * recordSize
+	err = rfs.writeRecords(ctx, appendArgs.Filename, offset, appendArgs.Data, recordSize)
+	if err != nil {
+		// reserved ids are not given back: other appends may already be placed after them
+		logger.Warn("failed to append records", "file", *appendArgs.Filename, "first_id", firstID, "last_id", firstID+count-1, "err", err)
+		return err
+	}
+
+	rfs.Metrics.fileWritten(*appendArgs.Filename, len(*appendArgs.Data))
+	res.FirstID = firstID
+	res.Offset = offset
+	return nil
+}
+
+// reserveRecords - moves end of the file by count records and returns id of the first reserved record.
+// Records written directly are not seen by master, so the end of the file with write placement is asked
+// from peers on every append untill the placement expires. Peers are asked without rfs.fileEndsLock,
+// so a slow peer does not stall appends to other files
+func (rfs *RemoteFS) reserveRecords(ctx context.Context, filename string, recordSize, count int32) (int32, error) {
+	rfs.fileEndsLock.Lock()
+	nextID, ok := rfs.fileEnds[filename]
+	expiresAt, written := rfs.writeExpiries[filename]
+	if ok && !written {
+		defer rfs.fileEndsLock.Unlock()
+		return rfs.reserveFrom(filename, nextID, recordSize, count)
+	}
+	rfs.fileEndsLock.Unlock()
+
+	// placement which expired before size is asked cannot write after it, so size includes all its writes
+	expired := written && time.Now().After(expiresAt)
+	size, err := rfs.fileSize(ctx, &filename)
+	if err != nil {
+		return 0, err
+	}
+	nextID = int32(min((size+int64(recordSize)-1)/int64(recordSize)+1, math.MaxInt32))
+
+	rfs.fileEndsLock.Lock()
+	defer rfs.fileEndsLock.Unlock()
+	// concurrent append may have reserved records after the size was asked
+	if end, ok := rfs.fileEnds[filename]; ok && end > nextID {
+		nextID = end
+	}
+	if expired && rfs.writeExpiries[filename].Equal(expiresAt) {
+		delete(rfs.writeExpiries, filename)
+	}
+	return rfs.reserveFrom(filename, nextID, recordSize, count)
+}
+
+// reserveFrom - reserves count records of the file starting from nextID unless their offsets overflow.
+// rfs.fileEndsLock should be held
+func (rfs *RemoteFS) reserveFrom(filename string, nextID, recordSize, count int32) (int32, error) {
+	if int64(nextID-1)+int64(count) > math.MaxInt32/int64(recordSize) {
+		return 0, utils.Errorf(utils.CodeNoSpace, "file(%s) cannot have more than %d records", filename, math.MaxInt32/recordSize)
+	}
+	if rfs.fileEnds == nil {
+		rfs.fileEnds = make(map[string]int32)
+	}
+	rfs.fileEnds[filename] = nextID + count
+	return nextID, nil
+}
+
+// writePlacementGiven - remembers that records of the file may be written directly untill expiresAt
+func (rfs *RemoteFS) writePlacementGiven(filename string, expiresAt time.Time) {
+	rfs.fileEndsLock.Lock()
+	defer rfs.fileEndsLock.Unlock()
+
+	if rfs.writeExpiries == nil {
+		rfs.writeExpiries = make(map[string]time.Time)
+	}
+	if expiresAt.After(rfs.writeExpiries[filename]) {
+		rfs.writeExpiries[filename] = expiresAt
+	}
+}
+
+// extendFileEnd - makes sure that next appended record will have id not less than nextID
+func (rfs *RemoteFS) extendFileEnd(filename string, nextID int32) {
+	rfs.fileEndsLock.Lock()
+	defer rfs.fileEndsLock.Unlock()
+
+	if end, ok := rfs.fileEnds[filename]; ok && end < nextID {
+		rfs.fileEnds[filename] = nextID
+	}
+}
+
+// forgetFileEnd - drops known end of the file, so it will be requested from peers next time
+func (rfs *RemoteFS) forgetFileEnd(filename string) {
+	rfs.fileEndsLock.Lock()
+	defer rfs.fileEndsLock.Unlock()
+
+	delete(rfs.fileEnds, filename)
+}
+
+// fileSize - returns size of the file as the biggest size among all the peers
+func (rfs *RemoteFS) fileSize(ctx context.Context, filename *string) (int64, error) {
+	var size int64
+	for _, node := range rfs.nodes() {
+		if node.ConStatus != Connected {
+			return 0, utils.Errorf(utils.CodePeerUnavailable, "one of peers(%s) is disconnected; failed to get size of file(%s)", *node.Endpoint, *filename)
+		}
+		peerSize, err := node.Peer.FileSize(ctx, filename)
+		if err != nil {
+			return 0, err
+		}
+		if peerSize > size {
+			size = peerSize
+		}
+	}
+	return size, nil
+}
+
+func (rfs *RemoteFS) ReadBytes(readArgs *utils.IOReadArgs, data *[]byte) (err error) {
+	if readArgs.Filename == nil {
+		return errMissingFilename
+	}
+	ctx, span := utils.StartSpan(&readArgs.CallContext, "RemoteIO.ReadBytes", utils.FileAttribute(*readArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogRPC)
+	logger.Debug("received read bytes", "file", *readArgs.Filename, "offset", readArgs.Offset, "count", readArgs.Count)
+
+	if err := rfs.authorize(&readArgs.CallContext, "ReadBytes", OpRead, *readArgs.Filename); err != nil {
+		return err
+	}
+	if err := checkRange(*readArgs.Filename, readArgs.Offset, int64(readArgs.Count)); err != nil {
+		return err
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	recordSize, err := rfs.recordSize(*readArgs.Filename)
+	if err != nil {
+		return err
+	}
+
+	firstID := readArgs.Offset/recordSize + 1
+	lastID := firstID + readArgs.Count/recordSize - 1
+
+	resultArray, err := rfs.readRange(ctx, readArgs, firstID, lastID, recordSize)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("read request executed successfully", "file", *readArgs.Filename)
+	rfs.Metrics.fileRead(*readArgs.Filename, len(resultArray))
+
+	*data = resultArray
+	return nil
+}
+
+// readRange - reads records firstID..lastID requested by readArgs
+func (rfs *RemoteFS) readRange(ctx context.Context, readArgs *utils.IOReadArgs, firstID, lastID, recordSize int32) (resultArray []byte, err error) {
+	ctx, span := startBatchSpan(ctx, "readRecords", *readArgs.Filename, firstID, lastID-firstID+1)
+	defer func() { utils.EndSpan(span, err) }()
+
+	nodes := rfs.nodes()
+	if len(nodes) != rfs.PeersCount {
+		return nil, ErrNotReady
+	}
+	resultArray = make([]byte, 0, readArgs.Count)
+	cnt := int32(0)
+	for id := firstID; id <= lastID; id++ {
+		record, err := rfs.readRecord(ctx, nodes, readArgs.Filename, id, readArgs.Offset+cnt*recordSize, recordSize, readArgs.FailOnHole)
+		if err != nil {
+			utils.RequestLogger(ctx, utils.LogRPC).Warn("peer failed to read record", "file", *readArgs.Filename, "id", id, "err", err)
+			return nil, err
+		}
+		resultArray = append(resultArray, (*record)...)
+
+		cnt++
+	}
+	return resultArray, nil
+}
+
+// readRecord - reads one record from the peer which owns it. Nodes are copies given by rfs.nodes
+func (rfs *RemoteFS) readRecord(ctx context.Context, nodes []*Node, filename *string, id, offset, recordSize int32, failOnHole bool) (*[]byte, error) {
+	node := nodes[id%int32(rfs.PeersCount)]
+	if node.ConStatus != Connected {
+		return nil, utils.Errorf(utils.CodePeerUnavailable, "one of peers(%s) is disconnected; failed to read record %d", *node.Endpoint, id)
+	}
+	return node.Peer.ReadBytes(ctx,
+		&utils.IOReadArgs{Filename: filename,
+			Offset:     offset,
+			Count:      recordSize,
+			FailOnHole: failOnHole})
+}
+
+// ReadRecords - reads records with given ids in the same order as ids are passed
+func (rfs *RemoteFS) ReadRecords(recordsArgs *utils.IORecordsArgs, data *[][]byte) (err error) {
+	if recordsArgs.Filename == nil {
+		return errMissingFilename
+	}
+	if recordsArgs.IDs == nil {
+		return utils.NewError(utils.CodeInvalidArgument, "record ids are missing")
+	}
+	ctx, span := utils.StartSpan(&recordsArgs.CallContext, "RemoteIO.ReadRecords", utils.FileAttribute(*recordsArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogRPC)
+	logger.Debug("received read records", "file", *recordsArgs.Filename, "records", len(*recordsArgs.IDs))
+
+	if err := rfs.authorize(&recordsArgs.CallContext, "ReadRecords", OpRead, *recordsArgs.Filename); err != nil {
+		return err
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	recordSize, err := rfs.recordSize(*recordsArgs.Filename)
+	if err != nil {
+		return err
+	}
+
+	nodes := rfs.nodes()
+	if len(nodes) != rfs.PeersCount {
+		return ErrNotReady
+	}
+	records := make([][]byte, 0, len(*recordsArgs.IDs))
+	for _, id := range *recordsArgs.IDs {
+		if id < 1 || id > math.MaxInt32/int64(recordSize) {
+			return utils.Errorf(utils.CodeInvalidArgument, "record id %d of file(%s) is out of range", id, *recordsArgs.Filename)
+		}
+		record, err := rfs.readRecord(ctx, nodes, recordsArgs.Filename, int32(id), int32(id-1)*recordSize, recordSize, recordsArgs.FailOnHole)
+		if err != nil {
+			logger.Warn("peer failed to read record", "file", *recordsArgs.Filename, "id", id, "err", err)
+			return err
+		}
+		records = append(records, *record)
+	}
+	rfs.Metrics.fileRead(*recordsArgs.Filename, len(records)*int(recordSize))
+
+	*data = records
+	return nil
+}
+
+// RecordSize - returns record size of the file set by InitRecordMappings
+func (rfs *RemoteFS) RecordSize(fileArgs *utils.IOFileArgs, size *int32) error {
+	if fileArgs.Filename == nil {
+		return errMissingFilename
+	}
+	if err := rfs.authorize(&fileArgs.CallContext, "RecordSize", OpRead, *fileArgs.Filename); err != nil {
+		return err
+	}
+
+	recordSize, err := rfs.recordSize(*fileArgs.Filename)
+	if err != nil {
+		return err
+	}
+	*size = recordSize
+	return nil
+}
+
+func (rfs *RemoteFS) recordSize(filename string) (int32, error) {
+	rfs.recordSizesLock.RLock()
+	recordSize := rfs.recordSizes[filename]
+	rfs.recordSizesLock.RUnlock()
+	if recordSize <= 0 {
+		return 0, utils.Errorf(utils.CodeInvalidArgument, "record size of file(%s) is unknown", filename)
+	}
+	return recordSize, nil
+}
+
+func (rfs *RemoteFS) CreateFile(fileArgs *utils.IOFileArgs, res *bool) (err error) {
+	if fileArgs.Filename == nil {
+		return errMissingFilename
+	}
+	filename := fileArgs.Filename
+	ctx, span := utils.StartSpan(&fileArgs.CallContext, "RemoteIO.CreateFile", utils.FileAttribute(*filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogRPC)
+	logger.Debug("received create file", "file", *filename)
+
+	if err := rfs.authorize(&fileArgs.CallContext, "CreateFile", OpCreate, *filename); err != nil {
+		return err
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+	rfs.forgetFileEnd(*filename)
+
+	err = rfs.fanOutUpdate(ctx, fmt.Sprintf("create file(%s)", *filename), func(node *Node) error {
+		return node.Peer.CreateFile(ctx, filename)
+	})
+	if err != nil {
+		logger.Warn("failed to create file", "file", *filename, "err", err)
+	}
+	*res = err == nil
+
+	return err
+}
+
+func (rfs *RemoteFS) DeleteFile(fileArgs *utils.IOFileArgs, res *bool) (err error) {
+	if fileArgs.Filename == nil {
+		return errMissingFilename
+	}
+	filename := fileArgs.Filename
+	ctx, span := utils.StartSpan(&fileArgs.CallContext, "RemoteIO.DeleteFile", utils.FileAttribute(*filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogRPC)
+	logger.Debug("received delete file", "file", *filename)
+
+	if err := rfs.authorize(&fileArgs.CallContext, "DeleteFile", OpDelete, *filename); err != nil {
+		return err
+	}
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+	rfs.forgetFileEnd(*filename)
+
+	err = rfs.fanOutUpdate(ctx, fmt.Sprintf("delete file(%s)", *filename), func(node *Node) error {
+		return node.Peer.DeleteFile(ctx, filename)
+	})
+	if err != nil {
+		logger.Warn("failed to delete file", "file", *filename, "err", err)
+	} else {
+		rfs.Metrics.fileRemoved(*filename)
+	}
+	*res = err == nil
+
+	return err
+}
+
+func (rfs *RemoteFS) FileExists(fileArgs *utils.IOFileArgs, exists *bool) (err error) {
+	if fileArgs.Filename == nil {
+		return errMissingFilename
+	}
+	filename := fileArgs.Filename
+	ctx, span := utils.StartSpan(&fileArgs.CallContext, "RemoteIO.FileExists", utils.FileAttribute(*filename))
+	defer func() { utils.EndSpan(span, err) }()
+	if err := rfs.authorize(&fileArgs.CallContext, "FileExists", OpRead, *filename); err != nil {
+		return err
+	}
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	res, err := rfs.fanOutCheck(fmt.Sprintf("check file(%s) existance", *filename), func(node *Node) (bool, error) {
+		return node.Peer.FileExists(ctx, filename)
+	})
+	if err != nil {
+		utils.RequestLogger(ctx, utils.LogRPC).Warn("failed to check file existance", "file", *filename, "err", err)
+	}
+	*exists = res
+	return err
+}
+
+func (rfs *RemoteFS) AddPeer(joinArgs *utils.PeerJoinArgs, res *utils.PeerJoinResult) error {
+	peerEndpoint := joinArgs.Endpoint
+	if peerEndpoint == nil {
+		return utils.NewError(utils.CodeInvalidArgument, "endpoint of peer is missing")
+	}
+	if rfs.PeerTLS != nil && joinArgs.CallerRole() != utils.RolePeer {
+		// clients connect to the same endpoint, so role is checked for every join, not only by TLS listener
+		utils.Logger(utils.LogHealth).Warn("peer rejected: certificate is not of a peer", "role", joinArgs.CallerRole())
+		return utils.Errorf(utils.CodePermissionDenied, "only nodes with %s certificate can join the cluster", utils.RolePeer)
+	}
+	if rfs.JoinToken != "" && subtle.ConstantTimeCompare([]byte(rfs.JoinToken), []byte(joinArgs.JoinToken)) != 1 {
+		utils.Logger(utils.LogHealth).Warn("peer rejected: invalid join token", "peer", *peerEndpoint)
+		return utils.NewError(utils.CodeUnauthenticated, "invalid join token")
+	}
+
+	controlKey, accessKey, err := newPeerKeys()
+	if err != nil {
+		return err
+	}
+	res.ControlKey, res.AccessKey = controlKey, accessKey
+
+	defer rfs.tellEpoch()
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+
+	for _, node := range rfs.Nodes {
+		if *node.Endpoint == *peerEndpoint && !node.Removed {
+			// control call succeeds only if the peer which knows keys of the slot is still alive,
+			// so nobody can take keys of it and make master lose control of the peer
+			if node.Peer != nil && callWithin(peerJoinCheckTimeout, func() error { return node.Peer.SetEpoch(rfs.placementEpoch()) }) == nil {
+				utils.Logger(utils.LogHealth).Warn("peer rejected: peer with the same endpoint is alive", "peer", *peerEndpoint)
+				return utils.Errorf(utils.CodeAlreadyExists, "peer(%s) is connected; it can join again when master loses connection to it", *peerEndpoint)
+			}
+			// restarted peer gets new keys, so connection with the old ones is dropped and peer is dialed again
+			node.controlKey, node.accessKey = controlKey, accessKey
+			node.Peer = nil
+			node.ConStatus = Disconnected
+			rfs.Metrics.peerState(*node.Endpoint, node.ConStatus)
+			// restarted peer does not know epoch, so it's told again
+			node.epoch = 0
+			node.DataEndpoint = joinArgs.DataEndpoint
+			// placements given before have tokens signed by the old access key
+			rfs.changePlacementLocked("peer joined again")
+			res.Epoch = rfs.placementEpoch()
+			return nil
+		}
+	}
+
+	connectedBefore := len(rfs.Nodes)
+	if connectedBefore >= rfs.PeersCount {
+		// new peer takes place of removed one and owns the same records
+		for slot, node := range rfs.Nodes {
+			if node.Removed {
+				rfs.Nodes[slot] = &Node{Endpoint: peerEndpoint, DataEndpoint: joinArgs.DataEndpoint, controlKey: controlKey, accessKey: accessKey}
+				utils.Logger(utils.LogHealth).Info("peer took place of removed peer", "peer", *peerEndpoint, "removed", *node.Endpoint, "slot", slot)
+				rfs.changePlacementLocked("peer took place of removed peer")
+				res.Epoch = rfs.placementEpoch()
+				return nil
+			}
+		}
+		return fmt.Errorf("there is already %v peers connectedBefore. cannot add more :(", connectedBefore)
+	}
+
+	rfs.Nodes = append(rfs.Nodes, &Node{Endpoint: peerEndpoint, DataEndpoint: joinArgs.DataEndpoint, controlKey: controlKey, accessKey: accessKey})
+	utils.Logger(utils.LogHealth).Info("peer connected", "peer", *peerEndpoint, "peers", connectedBefore+1, "expected", rfs.PeersCount)
+	rfs.changePlacementLocked("peer connected")
+	res.Epoch = rfs.placementEpoch()
+
+	if connectedBefore == 0 {
+		rfs.stopHealthChecker = make(chan struct{})
+		go runHealthChecker(rfs, rfs.stopHealthChecker)
+	}
+
+	if connectedBefore+1 == rfs.PeersCount {
+		utils.Logger(utils.LogHealth).Info("needed number of peers connected; distributed file system is ready to use")
+		rfs.ReadyToUse = true
+	}
+	return nil
+}
+
+// peerJoinCheckTimeout - how long master waits for the answer of a peer which endpoint joins again
+const peerJoinCheckTimeout = time.Second
+
+// newPeerKeys - generates keys for joined peer. Control key signs calls of master to the peer, and access key signs
+// tokens of clients to its records. Every peer gets its own keys, so a peer can not forge calls to other peers
+func newPeerKeys() (controlKey, accessKey []byte, err error) {
+	controlKey, accessKey = make([]byte, 32), make([]byte, 32)
+	if _, err := rand.Read(controlKey); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(accessKey); err != nil {
+		return nil, nil, err
+	}
+	return controlKey, accessKey, nil
+}
+
+// callWithin - returns error of the call, or error if it does not return in timeout, so a hung peer does not
+// block the caller. The call is left running in background then
+func callWithin(timeout time.Duration, call func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- call() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return utils.Errorf(utils.CodePeerUnavailable, "peer did not answer in %v", timeout)
+	}
+}
+
+func runHealthChecker(rfs *RemoteFS, stop chan struct{}) {
+	if rfs.HealthCheckerIsRunnnig {
+		utils.Logger(utils.LogHealth).Warn("health checker is already running")
+		return
+	}
+
+	interval := rfs.HealthCheckInterval
+	if interval <= 0 {
+		interval = time.Millisecond * 1000
+	}
+	rfs.HealthCheckerTicker = time.NewTicker(interval)
+	rfs.HealthCheckerIsRunnnig = true
+	defer rfs.HealthCheckerTicker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			rfs.HealthCheckerIsRunnnig = false
+			return
+		case <-rfs.HealthCheckerTicker.C:
+			rfs.checkPeers()
+		}
+	}
+}
+
+// StopHealthChecker - stops pinging of peers, so stopped master does not try to reconnect to them
+func (rfs *RemoteFS) StopHealthChecker() {
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+
+	if rfs.stopHealthChecker != nil {
+		close(rfs.stopHealthChecker)
+		rfs.stopHealthChecker = nil
+	}
+}
+
+// dialPeer - connects to the peer of the node. Calls to it go through Chaos if it's set
+func (rfs *RemoteFS) dialPeer(node *Node) (Peer, bool) {
+	endpoint := *node.Endpoint
+	client, ok := utils.GetRemoteClientTLS(endpoint, rfs.PeerTLS)
+	if !ok {
+		return nil, false
+	}
+	var peer Peer = &PeerIO{client: client, controlKey: node.controlKey, endpoint: endpoint, metrics: rfs.Metrics}
+	if rfs.Chaos != nil {
+		peer = rfs.Chaos.wrap(endpoint, peer)
+	}
+	return peer, true
+}
+
+// isReady - tells if dfs is ready to use. ReadyToUse is changed by health checker, joins and admin under
+// rfs.nodesLock, so it's read under the lock too
+func (rfs *RemoteFS) isReady() bool {
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+	return rfs.ReadyToUse
+}
+
+// peerPingTimeout - how long health checker waits for answer of a peer before it considers the peer disconnected
+const peerPingTimeout = 5 * time.Second
+
+// nodes - returns copies of the nodes taken under rfs.nodesLock. Calls to peers work on them, so they do not
+// hold the lock while peers answer and do not see a node changed in the middle, e.g. peer of removed node set to nil
+func (rfs *RemoteFS) nodes() []*Node {
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+	return copyNodes(rfs.Nodes)
+}
+
+// copyNodes - returns copies of the nodes. rfs.nodesLock should be held
+func copyNodes(nodes []*Node) []*Node {
+	copies := make([]*Node, len(nodes))
+	for slot, node := range nodes {
+		copied := *node
+		copies[slot] = &copied
+	}
+	return copies
+}
+
+// peerCheck - result of health check of one peer
+type peerCheck struct {
+	peer      Peer // connection which answered ping; nil if check failed
+	dialed    bool // false if connection to the peer could not be established
+	err       error
+	lastPing  time.Time
+	latency   time.Duration
+	toldEpoch uint64 // the last epoch of placement told to the peer
+}
+
+// checkPeer - pings peer of the node copy and tells it epoch of placement unless it knows it. Lost peer is dialed again
+func (rfs *RemoteFS) checkPeer(node *Node) peerCheck {
+	peer := node.Peer
+	if peer == nil {
+		var ok bool
+		if peer, ok = rfs.dialPeer(node); !ok {
+			return peerCheck{}
+		}
+	}
+	start := time.Now()
+	if err := callWithin(peerPingTimeout, peer.Ping); err != nil {
+		return peerCheck{dialed: true, err: err}
+	}
+	check := peerCheck{peer: peer, dialed: true, lastPing: time.Now(), toldEpoch: node.epoch}
+	check.latency = check.lastPing.Sub(start)
+
+	if epoch := rfs.placementEpoch(); node.epoch != epoch {
+		if err := peer.SetEpoch(epoch); err != nil {
+			utils.Logger(utils.LogHealth).Warn("failed to tell epoch of placement to peer", "peer", *node.Endpoint, "epoch", epoch, "err", err)
+		} else {
+			check.toldEpoch = epoch
+		}
+	}
+	return check
+}
+
+// checkPeers - pings all the peers, reconnects to lost ones and decides if dfs is ready to use. Peers are pinged
+// concurrently and without rfs.nodesLock, so a slow peer does not delay joins, admin actions and checks of others
+func (rfs *RemoteFS) checkPeers() {
+	rfs.nodesLock.Lock()
+	originals := append([]*Node(nil), rfs.Nodes...)
+	nodes := copyNodes(originals)
+	rfs.nodesLock.Unlock()
+
+	checks := make([]peerCheck, len(nodes))
+	var wg sync.WaitGroup
+	for slot, node := range nodes {
+		if node.Removed {
+			continue
+		}
+		wg.Add(1)
+		go func(check *peerCheck, node *Node) {
+			defer wg.Done()
+			*check = rfs.checkPeer(node)
+		}(&checks[slot], node)
+	}
+	wg.Wait()
+
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+
+	allAreOk := len(rfs.Nodes) == rfs.PeersCount
+	for slot, node := range rfs.Nodes {
+		if node.Removed {
+			allAreOk = false
+			continue
+		}
+		// node which was replaced, or which peer joined again, while it was checked is checked next time
+		if slot >= len(originals) || node != originals[slot] || node.Peer != nodes[slot].Peer || !bytes.Equal(node.controlKey, nodes[slot].controlKey) {
+			allAreOk = allAreOk && node.ConStatus == Connected
+			continue
+		}
+		rfs.applyCheck(node, checks[slot])
+		allAreOk = allAreOk && node.ConStatus == Connected
+	}
+	rfs.ReadyToUse = allAreOk
+}
+
+// applyCheck - updates state of the node by result of its health check. rfs.nodesLock should be held
+func (rfs *RemoteFS) applyCheck(node *Node, check peerCheck) {
+	if check.peer == nil {
+		if node.ConStatus != Disconnected {
+			if !check.dialed {
+				utils.Logger(utils.LogHealth).Warn("cannot establish rpc connection with peer", "peer", *node.Endpoint)
+			} else {
+				utils.Logger(utils.LogHealth).Warn("ping of peer failed", "peer", *node.Endpoint, "err", check.err)
+			}
+		}
+		node.ConStatus = Disconnected
+		rfs.Metrics.peerState(*node.Endpoint, node.ConStatus)
+		node.Peer = nil
+		// peer may be restarted while it's unreachable, so it's told epoch again after reconnection
+		node.epoch = 0
+		return
+	}
+	if node.ConStatus == Disconnected {
+		utils.Logger(utils.LogHealth).Info("connection with peer is restored", "peer", *node.Endpoint)
+	}
+	node.Peer = check.peer
+	node.ConStatus = Connected
+	node.LastPing = check.lastPing
+	node.Latency = check.latency
+	node.epoch = check.toldEpoch
+	rfs.Metrics.peerState(*node.Endpoint, node.ConStatus)
+}
@@ -0,0 +1,180 @@
+package master
+
+import (
+	"context"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"strings"
+	"sync"
+)
+
+// Consistency - rule which decides how many peers should agree for fan-out operation to succeed
+type Consistency byte
+
+const (
+	// ConsistencyAll - every peer should succeed
+	ConsistencyAll Consistency = iota
+	// ConsistencyQuorum - majority of peers should succeed
+	ConsistencyQuorum
+	// ConsistencyAny - at least one peer should succeed
+	ConsistencyAny
+)
+
+// ParseConsistency - parses consistency rule from its name: all, quorum or any
+func ParseConsistency(name string) (Consistency, error) {
+	switch name {
+	case "all":
+		return ConsistencyAll, nil
+	case "quorum":
+		return ConsistencyQuorum, nil
+	case "any":
+		return ConsistencyAny, nil
+	}
+	return ConsistencyAll, fmt.Errorf("unknown consistency %q; use all, quorum or any", name)
+}
+
+func (c Consistency) String() string {
+	switch c {
+	case ConsistencyQuorum:
+		return "quorum"
+	case ConsistencyAny:
+		return "any"
+	}
+	return "all"
+}
+
+// required - returns number of peers out of peersCount which should agree
+func (c Consistency) required(peersCount int) int {
+	switch c {
+	case ConsistencyQuorum:
+		return peersCount/2 + 1
+	case ConsistencyAny:
+		return 1
+	}
+	return peersCount
+}
+
+var ErrPeerDisconnected = utils.NewError(utils.CodePeerUnavailable, "peer is disconnected")
+
+// PeerError - failure of one peer during fan-out operation
+type PeerError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *PeerError) Error() string {
+	return fmt.Sprintf("peer(%s): %v", e.Endpoint, e.Err)
+}
+
+func (e *PeerError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError - failures of all the peers which did not succeed in fan-out operation
+type MultiError struct {
+	Op     string
+	Errors []*PeerError
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%s failed on %d peer(s): %s", e.Op, len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// fanOutResult - answers of all the peers collected by fanOut
+type fanOutResult struct {
+	positive int // number of peers which answered true
+	failures []*PeerError
+}
+
+// err - returns failures as MultiError. If all the peers failed with the same code, error keeps it
+func (res fanOutResult) err(op string) error {
+	multi := &MultiError{Op: op, Errors: res.failures}
+	code := utils.CodeUnknown
+	for i, failure := range res.failures {
+		if c := utils.CodeOf(failure.Err); i == 0 {
+			code = c
+		} else if c != code {
+			return multi
+		}
+	}
+	if code == utils.CodeUnknown {
+		return multi
+	}
+	return &utils.Error{Code: code, Err: multi}
+}
+
+// fanOut - concurrently calls every peer and waits untill all of them answer. Calls get copies of the nodes, see nodes
+func (rfs *RemoteFS) fanOut(call func(node *Node) (bool, error)) fanOutResult {
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		res fanOutResult
+	)
+
+	for _, node := range rfs.nodes() {
+		if node.ConStatus != Connected {
+			res.failures = append(res.failures, &PeerError{Endpoint: *node.Endpoint, Err: ErrPeerDisconnected})
+			continue
+		}
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+			answer, err := call(node)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				res.failures = append(res.failures, &PeerError{Endpoint: *node.Endpoint, Err: err})
+			} else if answer {
+				res.positive++
+			}
+		}(node)
+	}
+	wg.Wait()
+	return res
+}
+
+// fanOutUpdate - applies change to every peer. Fails if less peers than consistency rule requires succeeded
+func (rfs *RemoteFS) fanOutUpdate(ctx context.Context, op string, update func(node *Node) error) error {
+	res := rfs.fanOut(func(node *Node) (bool, error) {
+		if node.Draining {
+			return false, ErrPeerDraining
+		}
+		return true, update(node)
+	})
+
+	if res.positive < rfs.Consistency.required(rfs.PeersCount) {
+		return res.err(op)
+	}
+	for _, failure := range res.failures {
+		utils.RequestLogger(ctx, utils.LogRPC).Warn("update failed on peer, but consistency is satisfied", "op", op, "peer", failure.Endpoint, "err", failure.Err, "consistency", rfs.Consistency.String())
+	}
+	return nil
+}
+
+// fanOutCheck - asks every peer and decides answer by consistency rule.
+// Fails if answer cannot be decided because of failed peers
+func (rfs *RemoteFS) fanOutCheck(op string, check func(node *Node) (bool, error)) (bool, error) {
+	res := rfs.fanOut(check)
+
+	required := rfs.Consistency.required(rfs.PeersCount)
+	if res.positive >= required {
+		return true, nil
+	}
+	if res.positive+len(res.failures) >= required {
+		return false, res.err(op)
+	}
+	return false, nil
+}
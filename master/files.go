@@ -0,0 +1,171 @@
+package master
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"sort"
+	"sync"
+)
+
+// ListFiles - returns files which names start with prefix and which client is allowed to read
+func (rfs *RemoteFS) ListFiles(listArgs *utils.IOListArgs, res *[]utils.FileInfo) (err error) {
+	ctx, span := utils.StartSpan(&listArgs.CallContext, "RemoteIO.ListFiles")
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogRPC).Debug("received list files", "prefix", listArgs.Prefix)
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	// every peer keeps part of every file, so size of the file is the biggest one among peers
+	var sizesLock sync.Mutex
+	sizes := make(map[string]int64)
+	listed := rfs.fanOut(func(node *Node) (bool, error) {
+		files, err := node.Peer.ListFiles(ctx, listArgs.Prefix)
+		if err != nil {
+			return false, err
+		}
+		sizesLock.Lock()
+		defer sizesLock.Unlock()
+		for _, file := range files {
+			if size, ok := sizes[file.Name]; !ok || file.Size > size {
+				sizes[file.Name] = file.Size
+			}
+		}
+		return true, nil
+	})
+	if len(listed.failures) > 0 {
+		return listed.err(fmt.Sprintf("list files with prefix %q", listArgs.Prefix))
+	}
+
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names, err = rfs.allowedFiles(&listArgs.CallContext, "ListFiles", OpRead, names)
+	if err != nil {
+		return err
+	}
+
+	files := make([]utils.FileInfo, len(names))
+	for i, name := range names {
+		files[i] = utils.FileInfo{Name: name, Size: sizes[name]}
+		files[i].RecordSize, _ = rfs.recordSize(name)
+	}
+	*res = files
+	return nil
+}
+
+// StatFile - returns size and record size of the file. Fails with ErrNotFound if file does not exist
+func (rfs *RemoteFS) StatFile(fileArgs *utils.IOFileArgs, info *utils.FileInfo) (err error) {
+	if fileArgs.Filename == nil {
+		return errMissingFilename
+	}
+	filename := fileArgs.Filename
+	ctx, span := utils.StartSpan(&fileArgs.CallContext, "RemoteIO.StatFile", utils.FileAttribute(*filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogRPC).Debug("received stat file", "file", *filename)
+
+	if err := rfs.authorize(&fileArgs.CallContext, "StatFile", OpRead, *filename); err != nil {
+		return err
+	}
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	exists, err := rfs.fanOutCheck(fmt.Sprintf("check file(%s) existance", *filename), func(node *Node) (bool, error) {
+		return node.Peer.FileExists(ctx, filename)
+	})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return utils.Errorf(utils.CodeNotFound, "file(%s) does not exist", *filename)
+	}
+
+	size, err := rfs.fileSize(ctx, filename)
+	if err != nil {
+		return err
+	}
+	info.Name = *filename
+	info.Size = size
+	info.RecordSize, _ = rfs.recordSize(*filename)
+	return nil
+}
+
+// SetRecordSize - sets record size of one file keeping record sizes of other files
+func (rfs *RemoteFS) SetRecordSize(sizeArgs *utils.IORecordSizeArgs, ok *bool) error {
+	if sizeArgs.Filename == nil {
+		return errMissingFilename
+	}
+	filename := *sizeArgs.Filename
+	utils.RequestLogger(sizeArgs.Context(), utils.LogRPC).Debug("received set record size", "file", filename, "record_size", sizeArgs.RecordSize)
+
+	if err := rfs.authorize(&sizeArgs.CallContext, "SetRecordSize", OpCreate, filename); err != nil {
+		return err
+	}
+	if sizeArgs.RecordSize <= 0 {
+		return utils.Errorf(utils.CodeInvalidArgument, "record size of file(%s) should be positive", filename)
+	}
+
+	rfs.updateRecordSizes(func(sizes map[string]int32) {
+		sizes[filename] = sizeArgs.RecordSize
+	})
+	rfs.forgetFileEnd(filename)
+	*ok = true
+	return nil
+}
+
+// RenameFile - renames the file on all the peers. Record size of the file is kept
+func (rfs *RemoteFS) RenameFile(renameArgs *utils.IORenameArgs, res *bool) (err error) {
+	if renameArgs.Filename == nil || renameArgs.NewFilename == nil {
+		return errMissingFilename
+	}
+	filename, newFilename := renameArgs.Filename, renameArgs.NewFilename
+	ctx, span := utils.StartSpan(&renameArgs.CallContext, "RemoteIO.RenameFile", utils.FileAttribute(*filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogRPC)
+	logger.Debug("received rename file", "file", *filename, "new_file", *newFilename)
+
+	if err := rfs.authorize(&renameArgs.CallContext, "RenameFile", OpDelete, *filename); err != nil {
+		return err
+	}
+	if err := rfs.authorize(&renameArgs.CallContext, "RenameFile", OpCreate, *newFilename); err != nil {
+		return err
+	}
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+	rfs.forgetFileEnd(*filename)
+	rfs.forgetFileEnd(*newFilename)
+
+	err = rfs.fanOutUpdate(ctx, fmt.Sprintf("rename file(%s) to file(%s)", *filename, *newFilename), func(node *Node) error {
+		return node.Peer.RenameFile(ctx, filename, newFilename)
+	})
+	if err != nil {
+		logger.Warn("failed to rename file", "file", *filename, "new_file", *newFilename, "err", err)
+		return err
+	}
+
+	rfs.Metrics.fileRemoved(*filename)
+	rfs.updateRecordSizes(func(sizes map[string]int32) {
+		if size, ok := sizes[*filename]; ok {
+			sizes[*newFilename] = size
+			delete(sizes, *filename)
+		}
+	})
+	*res = true
+	return nil
+}
+
+// updateRecordSizes - changes record sizes of files by update while calls reading them wait
+func (rfs *RemoteFS) updateRecordSizes(update func(sizes map[string]int32)) {
+	rfs.recordSizesLock.Lock()
+	defer rfs.recordSizesLock.Unlock()
+
+	if rfs.recordSizes == nil {
+		rfs.recordSizes = make(map[string]int32)
+	}
+	update(rfs.recordSizes)
+}
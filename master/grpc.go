@@ -0,0 +1,184 @@
+package master
+
+import (
+	"context"
+	"github.com/alikhil/distributed-fs/dfspb"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"math"
+)
+
+// grpcRemoteIO - serves grpc api of master by calling the same methods of RemoteFS as net/rpc does
+type grpcRemoteIO struct {
+	dfspb.UnimplementedRemoteIOServer
+	rfs *RemoteFS
+}
+
+// NewGRPCRemoteIO - returns grpc server of master api backed by rfs
+func NewGRPCRemoteIO(rfs *RemoteFS) dfspb.RemoteIOServer {
+	return &grpcRemoteIO{rfs: rfs}
+}
+
+// toInt32 - checks that value sent by grpc client fits into int32 used by dfs
+func toInt32(value int64, name string) (int32, error) {
+	if value < 0 || value > math.MaxInt32 {
+		return 0, utils.Errorf(utils.CodeInvalidArgument, "%s %d is out of range", name, value)
+	}
+	return int32(value), nil
+}
+
+func fileArgs(ctx context.Context, req *dfspb.FileRequest) *utils.IOFileArgs {
+	return &utils.IOFileArgs{Filename: &req.Filename, CallContext: utils.GRPCCallContext(ctx)}
+}
+
+func (s *grpcRemoteIO) InitRecordMappings(ctx context.Context, req *dfspb.RecordMappings) (*dfspb.Empty, error) {
+	mp := req.RecordSizes
+	var ok bool
+	err := s.rfs.InitRecordMappings(&utils.IOMappingsArgs{Mappings: &mp, CallContext: utils.GRPCCallContext(ctx)}, &ok)
+	return &dfspb.Empty{}, utils.GRPCError(err)
+}
+
+func (s *grpcRemoteIO) FileExists(ctx context.Context, req *dfspb.FileRequest) (*dfspb.ExistsReply, error) {
+	var exists bool
+	err := s.rfs.FileExists(fileArgs(ctx, req), &exists)
+	return &dfspb.ExistsReply{Exists: exists}, utils.GRPCError(err)
+}
+
+func (s *grpcRemoteIO) CreateFile(ctx context.Context, req *dfspb.FileRequest) (*dfspb.Empty, error) {
+	var ok bool
+	return &dfspb.Empty{}, utils.GRPCError(s.rfs.CreateFile(fileArgs(ctx, req), &ok))
+}
+
+func (s *grpcRemoteIO) DeleteFile(ctx context.Context, req *dfspb.FileRequest) (*dfspb.Empty, error) {
+	var ok bool
+	return &dfspb.Empty{}, utils.GRPCError(s.rfs.DeleteFile(fileArgs(ctx, req), &ok))
+}
+
+// ReadBytes - reads requested range by chunks of whole records and streams them to client
+func (s *grpcRemoteIO) ReadBytes(req *dfspb.ReadRequest, stream dfspb.RemoteIO_ReadBytesServer) error {
+	offset, err := toInt32(req.Offset, "offset")
+	if err != nil {
+		return utils.GRPCError(err)
+	}
+	count, err := toInt32(req.Count, "count")
+	if err != nil {
+		return utils.GRPCError(err)
+	}
+	call := utils.GRPCCallContext(stream.Context())
+	if err = s.rfs.authorize(&call, "ReadBytes", OpRead, req.Filename); err != nil {
+		return utils.GRPCError(err)
+	}
+	recordSize, err := s.rfs.recordSize(req.Filename)
+	if err != nil {
+		return utils.GRPCError(err)
+	}
+
+	chunkSize := utils.GRPCChunkSize / recordSize * recordSize
+	if chunkSize == 0 {
+		chunkSize = recordSize
+	}
+	for count > 0 {
+		if chunkSize > count {
+			chunkSize = count
+		}
+		var data []byte
+		err = s.rfs.ReadBytes(&utils.IOReadArgs{Filename: &req.Filename, Offset: offset, Count: chunkSize, FailOnHole: req.FailOnHole, CallContext: call}, &data)
+		if err != nil {
+			return utils.GRPCError(err)
+		}
+		if err = stream.Send(&dfspb.Chunk{Offset: int64(offset), Data: data}); err != nil {
+			return err
+		}
+		offset += chunkSize
+		count -= chunkSize
+	}
+	return nil
+}
+
+// WriteBytes - writes every received chunk as soon as it arrives
+func (s *grpcRemoteIO) WriteBytes(stream dfspb.RemoteIO_WriteBytesServer) error {
+	call := utils.GRPCCallContext(stream.Context())
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&dfspb.Empty{})
+		}
+		if err != nil {
+			return err
+		}
+		offset, err := toInt32(chunk.Offset, "offset")
+		if err != nil {
+			return utils.GRPCError(err)
+		}
+		var ok bool
+		err = s.rfs.WriteBytes(&utils.IOWriteArgs{Filename: &chunk.Filename, Offset: offset, Data: &chunk.Data, CallContext: call}, &ok)
+		if err != nil {
+			return utils.GRPCError(err)
+		}
+	}
+}
+
+func (s *grpcRemoteIO) AppendRecords(ctx context.Context, req *dfspb.AppendRequest) (*dfspb.AppendReply, error) {
+	var res utils.IOAppendResult
+	err := s.rfs.AppendRecords(&utils.IOAppendArgs{Filename: &req.Filename, Data: &req.Data, CallContext: utils.GRPCCallContext(ctx)}, &res)
+	if err != nil {
+		return nil, utils.GRPCError(err)
+	}
+	return &dfspb.AppendReply{FirstId: int64(res.FirstID), Offset: int64(res.Offset)}, nil
+}
+
+// readRecordsBatch - number of records read from peers before they are sent to client
+const readRecordsBatch = 64
+
+func (s *grpcRemoteIO) ReadRecords(req *dfspb.RecordsRequest, stream dfspb.RemoteIO_ReadRecordsServer) error {
+	call := utils.GRPCCallContext(stream.Context())
+	for start := 0; start < len(req.Ids); start += readRecordsBatch {
+		end := start + readRecordsBatch
+		if end > len(req.Ids) {
+			end = len(req.Ids)
+		}
+		ids := req.Ids[start:end]
+		var records [][]byte
+		err := s.rfs.ReadRecords(&utils.IORecordsArgs{Filename: &req.Filename, IDs: &ids, FailOnHole: req.FailOnHole, CallContext: call}, &records)
+		if err != nil {
+			return utils.GRPCError(err)
+		}
+		for i, record := range records {
+			if err = stream.Send(&dfspb.Record{Id: ids[i], Data: record}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *grpcRemoteIO) RecordSize(ctx context.Context, req *dfspb.FileRequest) (*dfspb.RecordSizeReply, error) {
+	var size int32
+	err := s.rfs.RecordSize(fileArgs(ctx, req), &size)
+	return &dfspb.RecordSizeReply{RecordSize: size}, utils.GRPCError(err)
+}
+
+func (s *grpcRemoteIO) DataRanges(ctx context.Context, req *dfspb.FileRequest) (*dfspb.DataRangesReply, error) {
+	var ranges []utils.RecordRange
+	err := s.rfs.DataRanges(fileArgs(ctx, req), &ranges)
+	if err != nil {
+		return nil, utils.GRPCError(err)
+	}
+	res := &dfspb.DataRangesReply{Ranges: make([]*dfspb.RecordRange, len(ranges))}
+	for i, r := range ranges {
+		res.Ranges[i] = &dfspb.RecordRange{From: r.From, To: r.To}
+	}
+	return res, nil
+}
+
+func (s *grpcRemoteIO) SeekData(ctx context.Context, req *dfspb.SeekRequest) (*dfspb.SeekReply, error) {
+	var id int64
+	err := s.rfs.SeekData(&utils.IOSeekArgs{Filename: &req.Filename, ID: req.Id, CallContext: utils.GRPCCallContext(ctx)}, &id)
+	return &dfspb.SeekReply{Id: id}, utils.GRPCError(err)
+}
+
+func (s *grpcRemoteIO) SeekHole(ctx context.Context, req *dfspb.SeekRequest) (*dfspb.SeekReply, error) {
+	var id int64
+	err := s.rfs.SeekHole(&utils.IOSeekArgs{Filename: &req.Filename, ID: req.Id, CallContext: utils.GRPCCallContext(ctx)}, &id)
+	return &dfspb.SeekReply{Id: id}, utils.GRPCError(err)
+}
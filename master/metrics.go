@@ -0,0 +1,83 @@
+package master
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics - metrics of data passed through master. Nil metrics record nothing
+type Metrics struct {
+	fileBytesRead    *prometheus.CounterVec
+	fileBytesWritten *prometheus.CounterVec
+	peerBytesRead    *prometheus.CounterVec
+	peerBytesWritten *prometheus.CounterVec
+	peerUp           *prometheus.GaugeVec
+}
+
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		fileBytesRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dfs_master_file_read_bytes_total",
+			Help: "Bytes read by clients from the file.",
+		}, []string{"file"}),
+		fileBytesWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dfs_master_file_written_bytes_total",
+			Help: "Bytes written by clients to the file.",
+		}, []string{"file"}),
+		peerBytesRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dfs_master_peer_read_bytes_total",
+			Help: "Bytes read by master from the peer.",
+		}, []string{"peer"}),
+		peerBytesWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dfs_master_peer_written_bytes_total",
+			Help: "Bytes written by master to the peer.",
+		}, []string{"peer"}),
+		peerUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "dfs_master_peer_up",
+			Help: "1 if health checker is connected to the peer, 0 otherwise.",
+		}, []string{"peer"}),
+	}
+	reg.MustRegister(m.fileBytesRead, m.fileBytesWritten, m.peerBytesRead, m.peerBytesWritten, m.peerUp)
+	return m
+}
+
+func (m *Metrics) fileRead(filename string, n int) {
+	if m != nil {
+		m.fileBytesRead.WithLabelValues(filename).Add(float64(n))
+	}
+}
+
+func (m *Metrics) fileWritten(filename string, n int) {
+	if m != nil {
+		m.fileBytesWritten.WithLabelValues(filename).Add(float64(n))
+	}
+}
+
+// fileRemoved - drops series of the file which is deleted or renamed, so names of gone files are not kept forever
+func (m *Metrics) fileRemoved(filename string) {
+	if m != nil {
+		m.fileBytesRead.DeleteLabelValues(filename)
+		m.fileBytesWritten.DeleteLabelValues(filename)
+	}
+}
+
+func (m *Metrics) peerRead(endpoint string, n int) {
+	if m != nil {
+		m.peerBytesRead.WithLabelValues(endpoint).Add(float64(n))
+	}
+}
+
+func (m *Metrics) peerWritten(endpoint string, n int) {
+	if m != nil {
+		m.peerBytesWritten.WithLabelValues(endpoint).Add(float64(n))
+	}
+}
+
+func (m *Metrics) peerState(endpoint string, status ConnectionStatus) {
+	if m != nil {
+		up := 0.0
+		if status == Connected {
+			up = 1
+		}
+		m.peerUp.WithLabelValues(endpoint).Set(up)
+	}
+}
@@ -0,0 +1,185 @@
+package master
+
+import (
+	"context"
+	"github.com/alikhil/distributed-fs/utils"
+	"net/rpc"
+	"time"
+)
+
+// Peer - calls of master to one peer. PeerIO makes them over net/rpc, chaos wraps it to inject faults
+type Peer interface {
+	Ping() error
+	// Close - stops the peer
+	Close() error
+	Stats() (*utils.PeerStats, error)
+	// SetEpoch - tells peer epoch of placement, so it rejects access tokens of older epochs
+	SetEpoch(epoch uint64) error
+	FileExists(ctx context.Context, fname *string) (bool, error)
+	DeleteFile(ctx context.Context, fname *string) error
+	ReadBytes(ctx context.Context, readArgs *utils.IOReadArgs) (*[]byte, error)
+	WriteBytes(ctx context.Context, filename *string, offset int32, data *[]byte) error
+	CreateFile(ctx context.Context, filename *string) error
+	FileSize(ctx context.Context, filename *string) (int64, error)
+	Extents(ctx context.Context, filename *string) ([]utils.Extent, error)
+	ListFiles(ctx context.Context, prefix string) ([]utils.FileInfo, error)
+	RenameFile(ctx context.Context, filename, newFilename *string) error
+}
+
+// PeerIO - calls peer over net/rpc
+type PeerIO struct {
+	client     *rpc.Client
+	controlKey []byte
+	endpoint   string
+	metrics    *Metrics
+}
+
+// controlArgs - signs control call, so peer knows that it's sent by master it joined
+func (peer *PeerIO) controlArgs() (*utils.ControlArgs, error) {
+	token, err := utils.NewToken(peer.controlKey, utils.RoleMaster, time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return &utils.ControlArgs{Token: token}, nil
+}
+
+// peerError - restores code of the error returned by peer. Failures of connection mean that peer is unavailable
+func peerError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(rpc.ServerError); !ok {
+		return &utils.Error{Code: utils.CodePeerUnavailable, Err: err}
+	}
+	return utils.DecodeError(err)
+}
+
+func (peer *PeerIO) Ping() error {
+	a := 4
+	b := 0
+	return peerError(peer.client.Call("PeerFS.Ping", &a, &b))
+}
+
+func (peer *PeerIO) Close() error {
+	args, err := peer.controlArgs()
+	if err != nil {
+		return err
+	}
+	var ok bool
+	return peerError(peer.client.Call("PeerFS.Close", args, &ok))
+}
+
+func (peer *PeerIO) Stats() (*utils.PeerStats, error) {
+	args, err := peer.controlArgs()
+	if err != nil {
+		return nil, err
+	}
+	var stats utils.PeerStats
+	return &stats, peerError(peer.client.Call("PeerFS.Stats", args, &stats))
+}
+
+func (peer *PeerIO) SetEpoch(epoch uint64) error {
+	args, err := peer.controlArgs()
+	if err != nil {
+		return err
+	}
+	var ok bool
+	return peerError(peer.client.Call("PeerFS.SetEpoch", &utils.EpochArgs{Epoch: epoch, ControlArgs: *args}, &ok))
+}
+
+func (peer *PeerIO) FileExists(ctx context.Context, fname *string) (result bool, err error) {
+	ctx, span := peer.startSpan(ctx, "FileExists", *fname)
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IOFileArgs{Filename: fname}
+	args.SetContext(ctx)
+	err = peerError(peer.client.Call("PeerFS.FileExists", args, &result))
+	return
+}
+
+func (peer *PeerIO) DeleteFile(ctx context.Context, fname *string) (err error) {
+	ctx, span := peer.startSpan(ctx, "DeleteFile", *fname)
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IOFileArgs{Filename: fname}
+	args.SetContext(ctx)
+	ok := false
+	return peerError(peer.client.Call("PeerFS.DeleteFile", args, &ok))
+}
+
+func (peer *PeerIO) ReadBytes(ctx context.Context, readArgs *utils.IOReadArgs) (_ *[]byte, err error) {
+	ctx, span := peer.startSpan(ctx, "ReadBytes", *readArgs.Filename)
+	defer func() { utils.EndSpan(span, err) }()
+	readArgs.SetContext(ctx)
+
+	bytes := make([]byte, readArgs.Count)
+	err = peer.client.Call("PeerFS.ReadBytes", readArgs, &bytes)
+	if err == nil {
+		peer.metrics.peerRead(peer.endpoint, len(bytes))
+	}
+	return &bytes, peerError(err)
+}
+
+func (peer *PeerIO) WriteBytes(ctx context.Context, filename *string, offset int32, data *[]byte) (err error) {
+	ctx, span := peer.startSpan(ctx, "WriteBytes", *filename)
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IOWriteArgs{Filename: filename, Offset: offset, Data: data}
+	args.SetContext(ctx)
+	ok := true
+	err = peer.client.Call("PeerFS.WriteBytes", args, &ok)
+	if err == nil {
+		peer.metrics.peerWritten(peer.endpoint, len(*data))
+	}
+	return peerError(err)
+}
+
+func (peer *PeerIO) CreateFile(ctx context.Context, filename *string) (err error) {
+	ctx, span := peer.startSpan(ctx, "CreateFile", *filename)
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IOFileArgs{Filename: filename}
+	args.SetContext(ctx)
+	ok := false
+	return peerError(peer.client.Call("PeerFS.CreateFile", args, &ok))
+}
+
+func (peer *PeerIO) FileSize(ctx context.Context, filename *string) (size int64, err error) {
+	ctx, span := peer.startSpan(ctx, "FileSize", *filename)
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IOFileArgs{Filename: filename}
+	args.SetContext(ctx)
+	err = peerError(peer.client.Call("PeerFS.FileSize", args, &size))
+	return
+}
+
+func (peer *PeerIO) Extents(ctx context.Context, filename *string) (extents []utils.Extent, err error) {
+	ctx, span := peer.startSpan(ctx, "Extents", *filename)
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IOFileArgs{Filename: filename}
+	args.SetContext(ctx)
+	err = peerError(peer.client.Call("PeerFS.Extents", args, &extents))
+	return
+}
+
+func (peer *PeerIO) ListFiles(ctx context.Context, prefix string) (files []utils.FileInfo, err error) {
+	ctx, span := peer.startSpan(ctx, "ListFiles", "")
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IOListArgs{Prefix: prefix}
+	args.SetContext(ctx)
+	err = peerError(peer.client.Call("PeerFS.ListFiles", args, &files))
+	return
+}
+
+func (peer *PeerIO) RenameFile(ctx context.Context, filename, newFilename *string) (err error) {
+	ctx, span := peer.startSpan(ctx, "RenameFile", *filename)
+	defer func() { utils.EndSpan(span, err) }()
+
+	args := &utils.IORenameArgs{Filename: filename, NewFilename: newFilename}
+	args.SetContext(ctx)
+	ok := false
+	return peerError(peer.client.Call("PeerFS.RenameFile", args, &ok))
+}
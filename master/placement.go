@@ -0,0 +1,141 @@
+package master
+
+import (
+	"github.com/alikhil/distributed-fs/utils"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultAccessTokenTTL - how long access token given with placement is valid if RemoteFS.AccessTokenTTL is not set
+const defaultAccessTokenTTL = time.Minute
+
+// anonymousSubject - subject of access tokens given when authentication of clients is disabled
+const anonymousSubject = "anonymous"
+
+// Placement - returns peers which keep records of the file and tokens which let client read or write them on
+// peers directly, so data does not pass through master. Appends still go through master, since it reserves ids
+func (rfs *RemoteFS) Placement(args *utils.IOPlacementArgs, placement *utils.Placement) (err error) {
+	if args.Filename == nil {
+		return errMissingFilename
+	}
+	ctx, span := utils.StartSpan(&args.CallContext, "RemoteIO.Placement", utils.FileAttribute(*args.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogRPC).Debug("received placement", "file", *args.Filename, "write", args.Write)
+
+	op, access := OpRead, utils.AccessRead
+	if args.Write {
+		op, access = OpWrite, utils.AccessWrite
+	}
+	if err := rfs.authorize(&args.CallContext, "Placement", op, *args.Filename); err != nil {
+		return err
+	}
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	// epoch is read before placement, so placement changed in between is given with stale epoch and rejected
+	epoch := rfs.placementEpoch()
+	recordSize, err := rfs.recordSize(*args.Filename)
+	if err != nil {
+		return err
+	}
+
+	subject := anonymousSubject
+	if rfs.Auth != nil {
+		claims, err := utils.VerifyToken(rfs.Auth.Secret, args.Token)
+		if err != nil {
+			return err
+		}
+		subject = claims.Subject
+	}
+	ttl := rfs.AccessTokenTTL
+	if ttl <= 0 {
+		ttl = defaultAccessTokenTTL
+	}
+	// token expires at the end of the second, so it's valid at least untill expiresAt
+	expiresAt := time.Unix(time.Now().Add(ttl).Unix(), 0)
+
+	nodes := rfs.nodes()
+	if len(nodes) != rfs.PeersCount {
+		return ErrNotReady
+	}
+	peers := make([]string, len(nodes))
+	tokens := make([]string, len(nodes))
+	for slot, node := range nodes {
+		if node.DataEndpoint == "" {
+			return utils.Errorf(utils.CodeUnavailable, "peer(%s) does not serve clients directly; start it with -data-port", *node.Endpoint)
+		}
+		// the same rules as for writes through master, since peers do not know about draining
+		if args.Write && node.Draining {
+			return utils.Errorf(utils.CodePeerUnavailable, "one of peers(%v) is draining; records owned by it can not be written", *node.Endpoint)
+		}
+		if args.Write && node.ConStatus != Connected {
+			return utils.Errorf(utils.CodePeerUnavailable, "one of peers(%v) is disconnected; we can not update all wr", *node.Endpoint)
+		}
+		// each peer verifies tokens by its own key, so token of one peer is not accepted by others
+		token, err := utils.NewAccessToken(node.accessKey, subject, *args.Filename, access, recordSize, epoch, ttl)
+		if err != nil {
+			return err
+		}
+		peers[slot], tokens[slot] = node.DataEndpoint, token
+	}
+
+	if args.Write {
+		rfs.writePlacementGiven(*args.Filename, expiresAt)
+	}
+
+	*placement = utils.Placement{Filename: *args.Filename, RecordSize: recordSize, Peers: peers, Tokens: tokens, ExpiresAt: expiresAt, Epoch: epoch}
+	return nil
+}
+
+// placementEpoch - returns epoch of placement. It's changed whenever peers of slots or their draining change,
+// so clients know that placements they cached are stale
+func (rfs *RemoteFS) placementEpoch() uint64 {
+	return atomic.LoadUint64(&rfs.epoch)
+}
+
+// changePlacementLocked - starts new epoch of placement, so peers reject access tokens given with placements
+// before. rfs.nodesLock should be held; tellEpoch should be called after it's released
+func (rfs *RemoteFS) changePlacementLocked(reason string) {
+	epoch := atomic.AddUint64(&rfs.epoch, 1)
+	utils.Logger(utils.LogHealth).Debug("placement changed", "epoch", epoch, "reason", reason)
+}
+
+// tellEpoch - tells connected peers the current epoch unless they know it. Peers are called concurrently and
+// without rfs.nodesLock, so a slow peer does not delay joins and health checks. Peer which is not told
+// is told on the next health check
+func (rfs *RemoteFS) tellEpoch() {
+	epoch := rfs.placementEpoch()
+	rfs.nodesLock.Lock()
+	originals := append([]*Node(nil), rfs.Nodes...)
+	nodes := copyNodes(originals)
+	rfs.nodesLock.Unlock()
+
+	told := make([]bool, len(nodes))
+	var wg sync.WaitGroup
+	for slot, node := range nodes {
+		if node.Removed || node.Peer == nil || node.ConStatus != Connected || node.epoch == epoch {
+			continue
+		}
+		wg.Add(1)
+		go func(slot int, node *Node) {
+			defer wg.Done()
+			if err := node.Peer.SetEpoch(epoch); err != nil {
+				utils.Logger(utils.LogHealth).Warn("failed to tell epoch of placement to peer", "peer", *node.Endpoint, "epoch", epoch, "err", err)
+				return
+			}
+			told[slot] = true
+		}(slot, node)
+	}
+	wg.Wait()
+
+	rfs.nodesLock.Lock()
+	defer rfs.nodesLock.Unlock()
+	for slot, node := range originals {
+		// peer which joined again while it was told does not know epoch
+		if told[slot] && node.Peer == nodes[slot].Peer && node.epoch < epoch {
+			node.epoch = epoch
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package master
+
+import (
+	"context"
+	"github.com/alikhil/distributed-fs/utils"
+	"sort"
+)
+
+// writtenRanges - returns ranges of record ids that were written to the file, collected from all the peers
+func (rfs *RemoteFS) writtenRanges(ctx context.Context, filename *string) ([]utils.RecordRange, error) {
+	recordSize, err := rfs.recordSize(*filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []utils.Extent
+	for _, node := range rfs.nodes() {
+		if node.ConStatus != Connected {
+			return nil, utils.Errorf(utils.CodePeerUnavailable, "one of peers(%s) is disconnected; failed to get written ranges of file(%s)", *node.Endpoint, *filename)
+		}
+		extents, err := node.Peer.Extents(ctx, filename)
+		if err != nil {
+			utils.RequestLogger(ctx, utils.LogRPC).Warn("failed to get extents of file from peer", "file", *filename, "peer", *node.Endpoint, "err", err)
+			return nil, err
+		}
+		all = append(all, extents...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Start < all[j].Start })
+
+	size := int64(recordSize)
+	var ranges []utils.RecordRange
+	for _, e := range all {
+		// record with id k occupies bytes [(k-1)*size, k*size)
+		from := (e.Start+size-1)/size + 1
+		to := e.End / size
+		if from > to {
+			continue
+		}
+		if n := len(ranges); n > 0 && ranges[n-1].To+1 >= from {
+			if to > ranges[n-1].To {
+				ranges[n-1].To = to
+			}
+			continue
+		}
+		ranges = append(ranges, utils.RecordRange{From: from, To: to})
+	}
+	return ranges, nil
+}
+
+// DataRanges - returns all ranges of record ids of the file which were ever written
+func (rfs *RemoteFS) DataRanges(fileArgs *utils.IOFileArgs, ranges *[]utils.RecordRange) (err error) {
+	if fileArgs.Filename == nil {
+		return errMissingFilename
+	}
+	filename := fileArgs.Filename
+	ctx, span := utils.StartSpan(&fileArgs.CallContext, "RemoteIO.DataRanges", utils.FileAttribute(*filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogRPC).Debug("received data ranges", "file", *filename)
+
+	if err := rfs.authorize(&fileArgs.CallContext, "DataRanges", OpRead, *filename); err != nil {
+		return err
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	res, err := rfs.writtenRanges(ctx, filename)
+	if err != nil {
+		return err
+	}
+	*ranges = res
+	return nil
+}
+
+// SeekData - returns id of the first written record starting from seekArgs.ID.
+// Fails with utils.ErrNoData if there is no written records after it
+func (rfs *RemoteFS) SeekData(seekArgs *utils.IOSeekArgs, id *int64) (err error) {
+	if seekArgs.Filename == nil {
+		return errMissingFilename
+	}
+	ctx, span := utils.StartSpan(&seekArgs.CallContext, "RemoteIO.SeekData", utils.FileAttribute(*seekArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogRPC).Debug("received seek data", "file", *seekArgs.Filename, "id", seekArgs.ID)
+
+	if err := rfs.authorize(&seekArgs.CallContext, "SeekData", OpRead, *seekArgs.Filename); err != nil {
+		return err
+	}
+	if seekArgs.ID < 1 {
+		return utils.Errorf(utils.CodeInvalidArgument, "record id %d of file(%s) is out of range", seekArgs.ID, *seekArgs.Filename)
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	ranges, err := rfs.writtenRanges(ctx, seekArgs.Filename)
+	if err != nil {
+		return err
+	}
+	for _, r := range ranges {
+		if r.To >= seekArgs.ID {
+			*id = r.From
+			if seekArgs.ID > r.From {
+				*id = seekArgs.ID
+			}
+			return nil
+		}
+	}
+	return utils.EncodeError(utils.ErrNoData)
+}
+
+// SeekHole - returns id of the first never written record starting from seekArgs.ID.
+// As with lseek there is always an implicit hole after the last record of the file
+func (rfs *RemoteFS) SeekHole(seekArgs *utils.IOSeekArgs, id *int64) (err error) {
+	if seekArgs.Filename == nil {
+		return errMissingFilename
+	}
+	ctx, span := utils.StartSpan(&seekArgs.CallContext, "RemoteIO.SeekHole", utils.FileAttribute(*seekArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogRPC).Debug("received seek hole", "file", *seekArgs.Filename, "id", seekArgs.ID)
+
+	if err := rfs.authorize(&seekArgs.CallContext, "SeekHole", OpRead, *seekArgs.Filename); err != nil {
+		return err
+	}
+	if seekArgs.ID < 1 {
+		return utils.Errorf(utils.CodeInvalidArgument, "record id %d of file(%s) is out of range", seekArgs.ID, *seekArgs.Filename)
+	}
+
+	if !rfs.isReady() {
+		return ErrNotReady
+	}
+
+	ranges, err := rfs.writtenRanges(ctx, seekArgs.Filename)
+	if err != nil {
+		return err
+	}
+	*id = seekArgs.ID
+	for _, r := range ranges {
+		if r.From <= seekArgs.ID && seekArgs.ID <= r.To {
+			*id = r.To + 1
+			break
+		}
+	}
+	return nil
+}
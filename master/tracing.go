@@ -0,0 +1,23 @@
+package master
+
+import (
+	"context"
+	"github.com/alikhil/distributed-fs/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startBatchSpan - starts span of reading or writing count records of the file starting from firstID
+func startBatchSpan(ctx context.Context, name, filename string, firstID, count int32) (context.Context, trace.Span) {
+	return utils.Tracer().Start(ctx, name, trace.WithAttributes(
+		utils.FileAttribute(filename),
+		attribute.Int("dfs.first_id", int(firstID)),
+		attribute.Int("dfs.records", int(count))))
+}
+
+// startSpan - starts span of the call to the peer
+func (peer *PeerIO) startSpan(ctx context.Context, method string, filename string) (context.Context, trace.Span) {
+	return utils.Tracer().Start(ctx, "PeerFS."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		utils.FileAttribute(filename),
+		attribute.String("dfs.peer", peer.endpoint)))
+}
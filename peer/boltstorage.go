@@ -0,0 +1,383 @@
+package peer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/alikhil/distributed-fs/utils"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// boltFile - name of the database of bolt storage in its directory
+const boltFile = "peer.db"
+
+var (
+	// filesBucket - name of the file -> its meta
+	filesBucket = []byte("files")
+	// recordsBucket - id of the file and offset of the record in it -> bytes of the record
+	recordsBucket = []byte("records")
+)
+
+// BoltStorage - keeps files in embedded bbolt database. Every write is a record stored under id of the file and
+// its offset, so holes take no space, and every call changes database in one transaction, so it either
+// survives crash as a whole or is lost. Written ranges are saved in the meta of the file in the same transaction
+// as the write. Records of a file are kept under its id rather than name, so rename changes only the meta of the file
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+// boltMeta - meta of the file kept in files bucket
+type boltMeta struct {
+	id      uint64
+	size    int64
+	extents []utils.Extent
+}
+
+func (m *boltMeta) encode() []byte {
+	buf := make([]byte, 0, 16+32*len(m.extents))
+	buf = binary.BigEndian.AppendUint64(buf, m.id)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(m.size))
+	return appendExtents(buf, m.extents)
+}
+
+func decodeBoltMeta(name string, buf []byte) (*boltMeta, error) {
+	if len(buf) < 16 {
+		return nil, utils.Errorf(utils.CodeUnknown, "corrupted meta of file(%s)", name)
+	}
+	ex, ok := decodeExtents(buf[16:])
+	if !ok {
+		return nil, utils.Errorf(utils.CodeUnknown, "corrupted meta of file(%s)", name)
+	}
+	return &boltMeta{id: binary.BigEndian.Uint64(buf), size: int64(binary.BigEndian.Uint64(buf[8:])), extents: ex}, nil
+}
+
+// recordKey - key of the record of the file with id starting from off. Keys of a file are sorted by offset
+func recordKey(id uint64, off int64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, id)
+	binary.BigEndian.PutUint64(key[8:], uint64(off))
+	return key
+}
+
+// NewBoltStorage - opens database of the storage in opts.Dir creating it if it does not exist
+func NewBoltStorage(opts StorageOptions) (*BoltStorage, error) {
+	if err := os.MkdirAll(opts.Dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	// other peer holding the database makes open wait for the lock, so it's limited
+	db, err := bolt.Open(filepath.Join(opts.Dir, boltFile), 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{filesBucket, recordsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStorage{db: db}, nil
+}
+
+// meta - returns meta of the file or nil if it does not exist
+func (s *BoltStorage) meta(tx *bolt.Tx, name string) (*boltMeta, error) {
+	buf := tx.Bucket(filesBucket).Get([]byte(name))
+	if buf == nil {
+		return nil, nil
+	}
+	return decodeBoltMeta(name, buf)
+}
+
+func (s *BoltStorage) putMeta(tx *bolt.Tx, name string, m *boltMeta) error {
+	return tx.Bucket(filesBucket).Put([]byte(name), m.encode())
+}
+
+// deleteRecords - removes all records of the file with id
+func (s *BoltStorage) deleteRecords(tx *bolt.Tx, id uint64) error {
+	records := tx.Bucket(recordsBucket)
+	prefix := recordKey(id, 0)[:8]
+	// keys are collected first, since deleting under cursor makes it skip keys
+	var keys [][]byte
+	c := records.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := records.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create - replaces the file with the name, if there is one, with empty file
+func (s *BoltStorage) create(tx *bolt.Tx, name string) (*boltMeta, error) {
+	old, err := s.meta(tx, name)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil {
+		if err = s.deleteRecords(tx, old.id); err != nil {
+			return nil, err
+		}
+	}
+	id, err := tx.Bucket(filesBucket).NextSequence()
+	if err != nil {
+		return nil, err
+	}
+	m := &boltMeta{id: id}
+	return m, s.putMeta(tx, name, m)
+}
+
+func (s *BoltStorage) Create(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		_, err := s.create(tx, name)
+		return err
+	})
+}
+
+func (s *BoltStorage) Delete(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		m, err := s.meta(tx, name)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return notFound(name)
+		}
+		if err = s.deleteRecords(tx, m.id); err != nil {
+			return err
+		}
+		return tx.Bucket(filesBucket).Delete([]byte(name))
+	})
+}
+
+func (s *BoltStorage) Exists(name string) (bool, error) {
+	var exists bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		exists = tx.Bucket(filesBucket).Get([]byte(name)) != nil
+		return nil
+	})
+	return exists, err
+}
+
+// overlapping - moves cursor to the first record of the file which ends after off
+func overlapping(c *bolt.Cursor, id uint64, off int64) (k, v []byte) {
+	prefix := recordKey(id, 0)[:8]
+	k, v = c.Seek(recordKey(id, off))
+	if k == nil || !bytes.HasPrefix(k, prefix) || int64(binary.BigEndian.Uint64(k[8:])) > off {
+		// record starting before off may cover it
+		var pk, pv []byte
+		if k == nil {
+			pk, pv = c.Last()
+		} else {
+			pk, pv = c.Prev()
+		}
+		if pk != nil && bytes.HasPrefix(pk, prefix) && int64(binary.BigEndian.Uint64(pk[8:]))+int64(len(pv)) > off {
+			return pk, pv
+		}
+		k, v = c.Seek(recordKey(id, off))
+	}
+	if k == nil || !bytes.HasPrefix(k, prefix) {
+		return nil, nil
+	}
+	return k, v
+}
+
+func (s *BoltStorage) ReadAt(name string, p []byte, off int64) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		m, err := s.meta(tx, name)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return notFound(name)
+		}
+		for i := range p {
+			p[i] = 0
+		}
+		end := off + int64(len(p))
+		c := tx.Bucket(recordsBucket).Cursor()
+		prefix := recordKey(m.id, 0)[:8]
+		for k, v := overlapping(c, m.id, off); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			start := int64(binary.BigEndian.Uint64(k[8:]))
+			if start >= end {
+				break
+			}
+			from, to := max(start, off), min(start+int64(len(v)), end)
+			copy(p[from-off:to-off], v[from-start:to-start])
+		}
+		return nil
+	})
+}
+
+// WriteAt - puts p as a record of the file and adds its range to written ranges of the file. Parts of records
+// it overlaps are kept as records of their own. Concurrent writes are committed in one transaction
+func (s *BoltStorage) WriteAt(name string, p []byte, off int64) error {
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		m, err := s.meta(tx, name)
+		if err == nil && m == nil {
+			m, err = s.create(tx, name)
+		}
+		if err != nil || len(p) == 0 {
+			return err
+		}
+
+		end := off + int64(len(p))
+		records := tx.Bucket(recordsBucket)
+		c := records.Cursor()
+		prefix := recordKey(m.id, 0)[:8]
+		var left, right []byte
+		var leftStart int64
+		var replaced [][]byte
+		for k, v := overlapping(c, m.id, off); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			start := int64(binary.BigEndian.Uint64(k[8:]))
+			if start >= end {
+				break
+			}
+			if start < off {
+				left, leftStart = append([]byte(nil), v[:off-start]...), start
+			}
+			if recordEnd := start + int64(len(v)); recordEnd > end {
+				right = append([]byte(nil), v[end-start:]...)
+			}
+			replaced = append(replaced, append([]byte(nil), k...))
+		}
+		for _, k := range replaced {
+			if err = records.Delete(k); err != nil {
+				return err
+			}
+		}
+		if left != nil {
+			if err = records.Put(recordKey(m.id, leftStart), left); err != nil {
+				return err
+			}
+		}
+		if right != nil {
+			if err = records.Put(recordKey(m.id, end), right); err != nil {
+				return err
+			}
+		}
+		// batch may run the function again, so p is copied instead of being changed
+		if err = records.Put(recordKey(m.id, off), append([]byte(nil), p...)); err != nil {
+			return err
+		}
+		m.size = max(m.size, end)
+		m.extents = extents(m.extents).add(off, end)
+		return s.putMeta(tx, name, m)
+	})
+}
+
+func (s *BoltStorage) Rename(name, newName string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		m, err := s.meta(tx, name)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return notFound(name)
+		}
+		if name == newName {
+			return nil
+		}
+		old, err := s.meta(tx, newName)
+		if err != nil {
+			return err
+		}
+		if old != nil {
+			if err = s.deleteRecords(tx, old.id); err != nil {
+				return err
+			}
+		}
+		if err = tx.Bucket(filesBucket).Delete([]byte(name)); err != nil {
+			return err
+		}
+		return s.putMeta(tx, newName, m)
+	})
+}
+
+func (s *BoltStorage) List(prefix string) ([]utils.FileInfo, error) {
+	files := []utils.FileInfo{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(filesBucket).Cursor()
+		for k, v := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+			m, err := decodeBoltMeta(string(k), v)
+			if err != nil {
+				return err
+			}
+			files = append(files, utils.FileInfo{Name: string(k), Size: m.size})
+		}
+		return nil
+	})
+	// keys are sorted by bytes, which is the order of strings
+	return files, err
+}
+
+func (s *BoltStorage) Stat(name string) (utils.FileInfo, error) {
+	var info utils.FileInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		m, err := s.meta(tx, name)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return notFound(name)
+		}
+		info = utils.FileInfo{Name: name, Size: m.size}
+		return nil
+	})
+	return info, err
+}
+
+func (s *BoltStorage) ReadExtents(name string) ([]utils.Extent, error) {
+	var ex []utils.Extent
+	err := s.db.View(func(tx *bolt.Tx) error {
+		m, err := s.meta(tx, name)
+		if m != nil {
+			ex = m.extents
+		}
+		return err
+	})
+	return ex, err
+}
+
+func (s *BoltStorage) WriteExtents(name string, ex []utils.Extent) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		m, err := s.meta(tx, name)
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			return notFound(name)
+		}
+		m.extents = ex
+		return s.putMeta(tx, name, m)
+	})
+}
+
+// Sync - flushes database to disk. Every transaction is already flushed on commit, so it's needed only
+// if database was opened without syncing
+func (s *BoltStorage) Sync() error {
+	return s.db.Sync()
+}
+
+// DiskUsage - returns size of the database file. Space freed by deleted records is reused but not returned
+func (s *BoltStorage) DiskUsage() int64 {
+	var size int64
+	s.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return size
+}
+
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
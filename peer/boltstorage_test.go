@@ -0,0 +1,40 @@
+package peer
+
+import (
+	"github.com/alikhil/distributed-fs/utils"
+	"reflect"
+	"testing"
+)
+
+func TestBoltStorageKeepsExtentsWithData(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewBoltStorage(StorageOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []struct {
+		data string
+		off  int64
+	}{{"xx", 4}, {"yy", 6}, {"zz", 0}} {
+		if err = s.WriteAt("a", []byte(w.data), w.off); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ranges are not saved by WriteExtents, so they are kept only if WriteAt saved them
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = NewBoltStorage(StorageOptions{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ex, err := s.ReadExtents("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []utils.Extent{{Start: 0, End: 2}, {Start: 4, End: 8}}
+	if !reflect.DeepEqual(ex, want) {
+		t.Fatalf("written ranges after reopen are %v, want %v", ex, want)
+	}
+}
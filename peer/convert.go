@@ -0,0 +1,63 @@
+package peer
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+)
+
+// convertChunkSize - most bytes copied by one write while converting storage
+const convertChunkSize = 1 << 20
+
+// ConvertStorage - copies all files of src with their written ranges to dst, so peer can be restarted with
+// another backend keeping its files. Only written ranges are copied, holes stay holes. Returns number of
+// copied files and bytes
+func ConvertStorage(dst, src Storage) (int, int64, error) {
+	files, err := src.List("")
+	if err != nil {
+		return 0, 0, err
+	}
+	var copied int64
+	for _, info := range files {
+		n, err := convertFile(dst, src, info)
+		copied += n
+		if err != nil {
+			return 0, copied, fmt.Errorf("failed to convert file(%s): %w", info.Name, err)
+		}
+	}
+	if err = dst.Sync(); err != nil {
+		return 0, copied, err
+	}
+	return len(files), copied, nil
+}
+
+func convertFile(dst, src Storage, info utils.FileInfo) (int64, error) {
+	ex, err := src.ReadExtents(info.Name)
+	if err != nil {
+		return 0, err
+	}
+	if err = dst.Create(info.Name); err != nil {
+		return 0, err
+	}
+	var copied, end int64
+	buf := make([]byte, convertChunkSize)
+	for _, e := range extents(ex).ranges() {
+		for off := e.Start; off < e.End; off += convertChunkSize {
+			chunk := buf[:min(convertChunkSize, e.End-off)]
+			if err = src.ReadAt(info.Name, chunk, off); err != nil {
+				return copied, err
+			}
+			if err = dst.WriteAt(info.Name, chunk, off); err != nil {
+				return copied, err
+			}
+			copied += int64(len(chunk))
+		}
+		end = max(end, e.End)
+	}
+	if info.Size > end {
+		// size of the file is kept even if its tail was never written
+		if err = dst.WriteAt(info.Name, []byte{0}, info.Size-1); err != nil {
+			return copied, err
+		}
+	}
+	return copied, dst.WriteExtents(info.Name, ex)
+}
@@ -0,0 +1,153 @@
+package peer
+
+import (
+	"crypto/tls"
+	"github.com/alikhil/distributed-fs/utils"
+	"math"
+	"sync/atomic"
+)
+
+// DataFS - serves reads and writes of clients which got placement of the file from master, so data does not pass
+// through master. Every call should carry access token to the file issued by master
+type DataFS struct {
+	fs *LocalFS
+}
+
+// NewDataFS - returns service which lets clients read and write files of the peer directly
+func NewDataFS(fs *LocalFS) *DataFS {
+	return &DataFS{fs: fs}
+}
+
+// RunDataRPC - serves rpc calls of clients on port untill close command is received. It's run on its own port,
+// so TLS of it may accept clients while port of master accepts only master
+func (fs *LocalFS) RunDataRPC(port int, config *tls.Config, metrics *utils.RPCMetrics) {
+	utils.RunRPCWithTLS("PeerData", NewDataFS(fs), port, &fs.isDataRunning, &fs.dataListener, config, metrics)
+}
+
+// authorizeAccess - checks that call carries token given by master which peer joined to access the file,
+// and that placement which token was given with is not stale. Returns claims of the token
+func (fs *LocalFS) authorizeAccess(call *utils.CallContext, fname *string, access string) (*utils.TokenClaims, error) {
+	if fname == nil {
+		return nil, errMissingFilename
+	}
+	fs.keysLock.RLock()
+	accessKey := fs.accessKey
+	fs.keysLock.RUnlock()
+	if accessKey == nil {
+		return nil, utils.NewError(utils.CodeNotReady, "peer has not joined master yet")
+	}
+	claims, err := utils.VerifyAccessToken(accessKey, call.Token, *fname, access)
+	if err != nil {
+		return nil, err
+	}
+	if epoch := atomic.LoadUint64(&fs.epoch); claims.Epoch < epoch {
+		return nil, utils.Errorf(utils.CodeStaleEpoch, "placement of file(%s) of epoch %d is stale; current epoch is %d", *fname, claims.Epoch, epoch)
+	}
+	// token is signed by master, so its epoch is not newer than the current one
+	fs.raiseEpoch(claims.Epoch)
+	return claims, nil
+}
+
+// checkBytes - checks that count bytes at offset fit int32 offsets of the file and one call
+func checkBytes(fname string, offset int32, count int64) error {
+	if offset < 0 || count < 0 || int64(offset)+count > math.MaxInt32 {
+		return utils.Errorf(utils.CodeInvalidArgument, "%d bytes at offset %d of file(%s) are out of range", count, offset, fname)
+	}
+	if count > utils.MaxPeerCallBytes {
+		return utils.Errorf(utils.CodeInvalidArgument, "%d bytes of file(%s) are more than %d bytes of one call", count, fname, utils.MaxPeerCallBytes)
+	}
+	return nil
+}
+
+func (d *DataFS) ReadBytes(readArgs *utils.IOReadArgs, data *[]byte) error {
+	if _, err := d.fs.authorizeAccess(&readArgs.CallContext, readArgs.Filename, utils.AccessRead); err != nil {
+		utils.RequestLogger(readArgs.Context(), utils.LogRPC).Warn("rejected direct read", "err", err)
+		return utils.EncodeError(err)
+	}
+	if err := checkBytes(*readArgs.Filename, readArgs.Offset, int64(readArgs.Count)); err != nil {
+		return utils.EncodeError(err)
+	}
+	return d.fs.ReadBytes(readArgs, data)
+}
+
+func (d *DataFS) WriteBytes(writeArgs *utils.IOWriteArgs, res *bool) error {
+	if _, err := d.fs.authorizeAccess(&writeArgs.CallContext, writeArgs.Filename, utils.AccessWrite); err != nil {
+		utils.RequestLogger(writeArgs.Context(), utils.LogRPC).Warn("rejected direct write", "err", err)
+		return utils.EncodeError(err)
+	}
+	if writeArgs.Data == nil {
+		return utils.EncodeError(errMissingData)
+	}
+	if err := checkBytes(*writeArgs.Filename, writeArgs.Offset, int64(len(*writeArgs.Data))); err != nil {
+		return utils.EncodeError(err)
+	}
+	return d.fs.WriteBytes(writeArgs, res)
+}
+
+// checkRecords - checks that records of the call are of the record size given with access token,
+// fit int32 offsets of the file and one call
+func checkRecords(args *utils.IOPeerRecordsArgs, claims *utils.TokenClaims) error {
+	if args.RecordSize <= 0 {
+		return utils.NewError(utils.CodeInvalidArgument, "record size should be positive")
+	}
+	if args.RecordSize != claims.RecordSize {
+		return utils.Errorf(utils.CodeInvalidArgument, "record size %d differs from record size %d of file(%s) given with access token", args.RecordSize, claims.RecordSize, *args.Filename)
+	}
+	// one record bigger than a call is still read or written
+	if len(args.Offsets) > 1 && len(args.Offsets) > utils.MaxPeerCallBytes/int(args.RecordSize) {
+		return utils.Errorf(utils.CodeInvalidArgument, "%d records of %d bytes are more than %d bytes of one call", len(args.Offsets), args.RecordSize, utils.MaxPeerCallBytes)
+	}
+	for _, offset := range args.Offsets {
+		if offset < 0 || offset > math.MaxInt32-args.RecordSize {
+			return utils.Errorf(utils.CodeInvalidArgument, "offset %d of record is out of range", offset)
+		}
+	}
+	return nil
+}
+
+// ReadRecords - reads records at given offsets, so client reads all records it needs from the peer in one call.
+// Records are returned one after another
+func (d *DataFS) ReadRecords(args *utils.IOPeerRecordsArgs, data *[]byte) error {
+	claims, err := d.fs.authorizeAccess(&args.CallContext, args.Filename, utils.AccessRead)
+	if err != nil {
+		utils.RequestLogger(args.Context(), utils.LogRPC).Warn("rejected direct read", "err", err)
+		return utils.EncodeError(err)
+	}
+	if err := checkRecords(args, claims); err != nil {
+		return utils.EncodeError(err)
+	}
+	*data = make([]byte, 0, len(args.Offsets)*int(args.RecordSize))
+	for _, offset := range args.Offsets {
+		var record []byte
+		readArgs := &utils.IOReadArgs{Filename: args.Filename, Offset: offset, Count: args.RecordSize, FailOnHole: args.FailOnHole, CallContext: args.CallContext}
+		if err := d.fs.ReadBytes(readArgs, &record); err != nil {
+			return err
+		}
+		*data = append(*data, record...)
+	}
+	return nil
+}
+
+// WriteRecords - writes records at given offsets, so client writes all records of the peer in one call
+func (d *DataFS) WriteRecords(args *utils.IOPeerRecordsArgs, res *bool) error {
+	claims, err := d.fs.authorizeAccess(&args.CallContext, args.Filename, utils.AccessWrite)
+	if err != nil {
+		utils.RequestLogger(args.Context(), utils.LogRPC).Warn("rejected direct write", "err", err)
+		return utils.EncodeError(err)
+	}
+	if err := checkRecords(args, claims); err != nil {
+		return utils.EncodeError(err)
+	}
+	if args.Data == nil || len(*args.Data) != len(args.Offsets)*int(args.RecordSize) {
+		return utils.EncodeError(utils.NewError(utils.CodeInvalidArgument, "data does not match records"))
+	}
+	for i, offset := range args.Offsets {
+		record := (*args.Data)[i*int(args.RecordSize) : (i+1)*int(args.RecordSize)]
+		writeArgs := &utils.IOWriteArgs{Filename: args.Filename, Offset: offset, Data: &record, CallContext: args.CallContext}
+		if err := d.fs.WriteBytes(writeArgs, res); err != nil {
+			return err
+		}
+	}
+	*res = true
+	return nil
+}
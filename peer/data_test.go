@@ -0,0 +1,91 @@
+package peer
+
+import (
+	"github.com/alikhil/distributed-fs/utils"
+	"math"
+	"testing"
+	"time"
+)
+
+// joinedFS - returns peer which verifies access tokens by key as if it joined master, and token to file "f"
+// with records of recordSize bytes
+func joinedFS(t *testing.T, recordSize int32) (*DataFS, utils.CallContext) {
+	t.Helper()
+	fs, err := NewLocalFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("access key")
+	fs.accessKey = key
+	token, err := utils.NewAccessToken(key, "client", "f", utils.AccessWrite, recordSize, 0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDataFS(fs), utils.CallContext{Token: token}
+}
+
+func TestDataFSRejectsBytesOutOfRange(t *testing.T) {
+	d, call := joinedFS(t, 4)
+	name := "f"
+	var ok bool
+	var data []byte
+	record := []byte("abcd")
+	if err := d.WriteBytes(&utils.IOWriteArgs{Filename: &name, Offset: 0, Data: &record, CallContext: call}, &ok); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	calls := map[string]func() error{
+		"negative count": func() error {
+			return d.ReadBytes(&utils.IOReadArgs{Filename: &name, Count: -1, CallContext: call}, &data)
+		},
+		"negative read offset": func() error {
+			return d.ReadBytes(&utils.IOReadArgs{Filename: &name, Offset: -4, Count: 4, CallContext: call}, &data)
+		},
+		"read past int32 offsets": func() error {
+			return d.ReadBytes(&utils.IOReadArgs{Filename: &name, Offset: math.MaxInt32 - 3, Count: 8, CallContext: call}, &data)
+		},
+		"read bigger than call": func() error {
+			return d.ReadBytes(&utils.IOReadArgs{Filename: &name, Count: math.MaxInt32, CallContext: call}, &data)
+		},
+		"negative write offset": func() error {
+			return d.WriteBytes(&utils.IOWriteArgs{Filename: &name, Offset: -4, Data: &record, CallContext: call}, &ok)
+		},
+		"write past int32 offsets": func() error {
+			return d.WriteBytes(&utils.IOWriteArgs{Filename: &name, Offset: math.MaxInt32 - 1, Data: &record, CallContext: call}, &ok)
+		},
+		"write without data": func() error {
+			return d.WriteBytes(&utils.IOWriteArgs{Filename: &name, CallContext: call}, &ok)
+		},
+	}
+	for what, f := range calls {
+		if err := f(); utils.CodeOf(err) != utils.CodeInvalidArgument {
+			t.Errorf("%s returned %v, want InvalidArgument", what, err)
+		}
+	}
+}
+
+func TestDataFSRejectsRecordsNotGivenWithToken(t *testing.T) {
+	const recordSize = utils.MaxPeerCallBytes / 2
+	d, call := joinedFS(t, recordSize)
+	name := "f"
+	var data []byte
+	var ok bool
+
+	calls := map[string]func() error{
+		"other record size": func() error {
+			return d.ReadRecords(&utils.IOPeerRecordsArgs{Filename: &name, Offsets: []int32{0}, RecordSize: 4, CallContext: call}, &data)
+		},
+		"records bigger than call": func() error {
+			return d.ReadRecords(&utils.IOPeerRecordsArgs{Filename: &name, Offsets: []int32{0, recordSize, 2 * recordSize}, RecordSize: recordSize, CallContext: call}, &data)
+		},
+		"write of other record size": func() error {
+			records := []byte("abcd")
+			return d.WriteRecords(&utils.IOPeerRecordsArgs{Filename: &name, Offsets: []int32{0}, RecordSize: 4, Data: &records, CallContext: call}, &ok)
+		},
+	}
+	for what, f := range calls {
+		if err := f(); utils.CodeOf(err) != utils.CodeInvalidArgument {
+			t.Errorf("%s returned %v, want InvalidArgument", what, err)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package peer
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// extentsDir - directory inside of fsdir where written ranges of the files are kept
+const extentsDir = ".extents"
+
+// appendedExtentsLimit - ranges appended to file of written ranges after which it's rewritten, unless
+// the file had more ranges when it was rewritten the last time
+const appendedExtentsLimit = 64
+
+// DirStorage - keeps every file of dfs as file with the same name in directory. Written ranges of the files
+// are kept as text files in .extents subdirectory. Every write appends its range to the file of ranges,
+// which is rewritten with merged ranges once appended ones pile up
+type DirStorage struct {
+	dir string
+
+	lock     sync.Mutex // taken while files of ranges are changed, so appended range is not lost by rewrite
+	appended map[string]extentsFile
+}
+
+// extentsFile - lines of file of written ranges: kept by the last rewrite and appended after it
+type extentsFile struct {
+	kept, appended int
+}
+
+// NewDirStorage - returns storage in dir. Directory is created if it does not exist
+func NewDirStorage(dir string) (*DirStorage, error) {
+	if err := os.MkdirAll(filepath.Join(dir, extentsDir), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &DirStorage{dir: dir, appended: make(map[string]extentsFile)}, nil
+}
+
+func (s *DirStorage) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func (s *DirStorage) extentsPath(name string) string {
+	return filepath.Join(s.dir, extentsDir, name)
+}
+
+func notFound(name string) error {
+	return utils.Errorf(utils.CodeNotFound, "file(%s) does not exist", name)
+}
+
+func (s *DirStorage) Create(name string) error {
+	file, err := os.Create(s.path(name))
+	if err != nil {
+		return err
+	}
+	file.Close()
+	// missing ranges mean that file was written before they were tracked, so empty ones are saved
+	return s.WriteExtents(name, nil)
+}
+
+func (s *DirStorage) Delete(name string) error {
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		return notFound(name)
+	}
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.appended, name)
+	return removeIfExists(s.extentsPath(name))
+}
+
+func (s *DirStorage) Exists(name string) (bool, error) {
+	_, err := os.Stat(s.path(name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *DirStorage) ReadAt(name string, p []byte, off int64) error {
+	file, err := os.Open(s.path(name))
+	if os.IsNotExist(err) {
+		return notFound(name)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// records after the end of the file were never written, they are read as zeros
+	n, err := file.ReadAt(p, off)
+	if err == io.EOF {
+		for i := n; i < len(p); i++ {
+			p[i] = 0
+		}
+		return nil
+	}
+	return err
+}
+
+func (s *DirStorage) WriteAt(name string, p []byte, off int64) error {
+	// writing far after the end of the file leaves a hole, which does not take disk space
+	file, err := os.OpenFile(s.path(name), os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		if err = s.WriteExtents(name, nil); err != nil {
+			return err
+		}
+		file, err = os.OpenFile(s.path(name), os.O_CREATE|os.O_WRONLY, 0644)
+	} else if err == nil {
+		err = s.keepUntrackedExtents(name, file)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteAt(p, off)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || len(p) == 0 {
+		return err
+	}
+	return s.appendExtent(name, off, off+int64(len(p)))
+}
+
+// keepUntrackedExtents - saves the whole file as written if it was written before written ranges were tracked,
+// so range appended by the write does not make the rest of the file a hole
+func (s *DirStorage) keepUntrackedExtents(name string, file *os.File) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := os.Stat(s.extentsPath(name)); !os.IsNotExist(err) {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	var ex []utils.Extent
+	if info.Size() > 0 {
+		ex = []utils.Extent{{Start: 0, End: info.Size()}}
+	}
+	return s.writeExtents(name, ex)
+}
+
+// appendExtent - appends written range to file of ranges, and rewrites the file if too many ranges are appended
+func (s *DirStorage) appendExtent(name string, start, end int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	file, err := os.OpenFile(s.extentsPath(name), os.O_APPEND|os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		// file is deleted or renamed while it's written
+		return notFound(name)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(file, "%d %d\n", start, end)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	lines := s.appended[name]
+	lines.appended++
+	s.appended[name] = lines
+	if lines.appended < max(appendedExtentsLimit, lines.kept) {
+		return nil
+	}
+	ex, err := s.readExtents(name)
+	if err != nil {
+		return err
+	}
+	return s.writeExtents(name, ex)
+}
+
+func (s *DirStorage) Rename(name, newName string) error {
+	if err := os.Rename(s.path(name), s.path(newName)); err != nil {
+		if os.IsNotExist(err) {
+			return notFound(name)
+		}
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.appended[newName] = s.appended[name]
+	delete(s.appended, name)
+	err := os.Rename(s.extentsPath(name), s.extentsPath(newName))
+	if os.IsNotExist(err) {
+		// file written before extents were tracked; stale ranges of replaced file should not stay
+		return removeIfExists(s.extentsPath(newName))
+	}
+	return err
+}
+
+func (s *DirStorage) List(prefix string) ([]utils.FileInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]utils.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		// written ranges are kept in directory, so only stored files are listed
+		if !entry.Type().IsRegular() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// file is deleted while listing
+			continue
+		}
+		files = append(files, utils.FileInfo{Name: entry.Name(), Size: info.Size()})
+	}
+	return files, nil
+}
+
+func (s *DirStorage) Stat(name string) (utils.FileInfo, error) {
+	info, err := os.Stat(s.path(name))
+	if os.IsNotExist(err) {
+		return utils.FileInfo{}, notFound(name)
+	}
+	if err != nil {
+		return utils.FileInfo{}, err
+	}
+	return utils.FileInfo{Name: name, Size: info.Size()}, nil
+}
+
+func (s *DirStorage) ReadExtents(name string) ([]utils.Extent, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.readExtents(name)
+}
+
+// readExtents - reads ranges saved by the last rewrite and merges appended ones in. s.lock should be held
+func (s *DirStorage) readExtents(name string) ([]utils.Extent, error) {
+	var ex extents
+	file, err := os.Open(s.extentsPath(name))
+	switch {
+	case err == nil:
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			e, err := parseExtent(scanner.Text())
+			if err != nil {
+				return nil, fmt.Errorf("corrupted extents of file(%s): %v", name, err)
+			}
+			if _, last := member(e, count(e)-1); count(e) > 1 && (len(ex) == 0 || e.Start > last) {
+				// runs are written only by rewrite, which sorts them
+				ex = append(ex, e)
+				continue
+			}
+			for i := int64(0); i < count(e); i++ {
+				ex = ex.add(member(e, i))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(err):
+		// files written before extents were tracked are considered fully written
+		if info, err := os.Stat(s.path(name)); err == nil && info.Size() > 0 {
+			ex = extents{{Start: 0, End: info.Size()}}
+		}
+	default:
+		return nil, err
+	}
+	return ex, nil
+}
+
+// parseExtent - parses line of file of written ranges: start and end of a range, followed by stride and count
+// if it's a run
+func parseExtent(line string) (utils.Extent, error) {
+	var e utils.Extent
+	fields := strings.Fields(line)
+	if len(fields) != 2 && len(fields) != 4 {
+		return e, fmt.Errorf("line %q is not a range", line)
+	}
+	_, err := fmt.Sscan(line, &e.Start, &e.End)
+	if err == nil && len(fields) == 4 {
+		_, err = fmt.Sscan(line, &e.Start, &e.End, &e.Stride, &e.Count)
+		if err == nil && (e.Count < 2 || e.Stride <= e.End-e.Start) {
+			err = fmt.Errorf("line %q is not a run", line)
+		}
+	}
+	return e, err
+}
+
+func (s *DirStorage) WriteExtents(name string, ex []utils.Extent) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.writeExtents(name, ex)
+}
+
+// writeExtents - rewrites file of written ranges with ex. s.lock should be held
+func (s *DirStorage) writeExtents(name string, ex []utils.Extent) error {
+	path := s.extentsPath(name)
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, e := range ex {
+		if count(e) > 1 {
+			fmt.Fprintf(w, "%d %d %d %d\n", e.Start, e.End, e.Stride, e.Count)
+		} else {
+			fmt.Fprintf(w, "%d %d\n", e.Start, e.End)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	s.appended[name] = extentsFile{kept: len(ex)}
+	return nil
+}
+
+// Sync - flushes stored files and both directories to disk
+func (s *DirStorage) Sync() error {
+	for _, dir := range []string{s.dir, filepath.Join(s.dir, extentsDir)} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				if err = syncPath(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		}
+		if err = syncPath(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DirStorage) DiskUsage() int64 {
+	return diskUsage(s.dir)
+}
+
+func (s *DirStorage) Close() error {
+	return nil
+}
+
+func syncPath(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+func removeIfExists(path string) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// diskUsage - returns disk space taken by all files in dir. Holes of sparse files are not counted where it's known
+func diskUsage(dir string) int64 {
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += allocatedSize(info)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Logger(utils.LogStorage).Warn("failed to compute disk usage", "dir", dir, "err", err)
+	}
+	return total
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package peer
+
+import (
+	"os"
+)
+
+// allocatedSize - returns size of the file, since allocated size is not known on this platform
+func allocatedSize(info os.FileInfo) int64 {
+	return info.Size()
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package peer
+
+import (
+	"os"
+	"syscall"
+)
+
+// allocatedSize - returns number of bytes allocated on disk for the file
+func allocatedSize(info os.FileInfo) int64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(stat.Blocks) * 512
+	}
+	return info.Size()
+}
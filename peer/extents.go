@@ -0,0 +1,260 @@
+package peer
+
+import (
+	"encoding/binary"
+	"github.com/alikhil/distributed-fs/utils"
+	"sort"
+)
+
+// extents - written ranges of a file sorted by start. Records are striped over peers, so a peer usually gets
+// one record of every few, and ranges of such records are kept as one run instead of a range per record.
+// Entries do not overlap from the start of the first range to the end of the last one
+type extents []utils.Extent
+
+// count - number of ranges of the entry
+func count(e utils.Extent) int64 {
+	return max(e.Count, 1)
+}
+
+// member - returns i-th range of the entry
+func member(e utils.Extent, i int64) (int64, int64) {
+	return e.Start + i*e.Stride, e.End + i*e.Stride
+}
+
+// slice - returns entry of ranges [from, to) of e
+func slice(e utils.Extent, from, to int64) utils.Extent {
+	start, end := member(e, from)
+	if to-from == 1 {
+		return utils.Extent{Start: start, End: end}
+	}
+	return utils.Extent{Start: start, End: end, Stride: e.Stride, Count: to - from}
+}
+
+// join - merges entry b which follows a into one, if ranges of both are adjacent or continue one run
+func join(a, b utils.Extent) (utils.Extent, bool) {
+	if count(a) == 1 && count(b) == 1 && b.Start <= a.End {
+		return utils.Extent{Start: a.Start, End: max(a.End, b.End)}, true
+	}
+	length := a.End - a.Start
+	stride := b.Start - a.Start
+	if count(a) > 1 {
+		stride = a.Stride
+	} else if count(b) > 1 {
+		stride = b.Stride
+	}
+	if b.End-b.Start != length || stride <= length || (count(b) > 1 && b.Stride != stride) || b.Start != a.Start+count(a)*stride {
+		return a, false
+	}
+	return utils.Extent{Start: a.Start, End: a.End, Stride: stride, Count: count(a) + count(b)}, true
+}
+
+// add - returns extents with [start, end) range merged in. Entries are changed in place, so appending
+// the next record of a run does not copy the others
+func (ex extents) add(start, end int64) extents {
+	if start >= end || ex.covers(start, end) {
+		return ex
+	}
+	// ex[i:j] are entries which ranges may touch [start, end)
+	i := sort.Search(len(ex), func(k int) bool { _, last := member(ex[k], count(ex[k])-1); return last >= start })
+	j := sort.Search(len(ex), func(k int) bool { return ex[k].Start > end })
+
+	from, to := max(i-1, 0), min(j+1, len(ex))
+	window := make(extents, 0, 5)
+	window = append(window, ex[from:i]...)
+	merged := utils.Extent{Start: start, End: end}
+	var rest []utils.Extent
+	for k := i; k < j; k++ {
+		e := ex[k]
+		// ranges [lo, hi) of e touch [start, end). Ranges before and after them can be left only in the first
+		// and the last entries
+		lo, hi := int64(0), count(e)
+		if count(e) > 1 {
+			if n := start - e.End; n > 0 {
+				lo = (n + e.Stride - 1) / e.Stride
+			}
+			hi = min(hi, (end-e.Start)/e.Stride+1)
+		}
+		if lo > 0 {
+			window = append(window, slice(e, 0, lo))
+		}
+		if lo < hi {
+			first, _ := member(e, lo)
+			_, last := member(e, hi-1)
+			merged.Start, merged.End = min(merged.Start, first), max(merged.End, last)
+		}
+		if hi < count(e) {
+			rest = append(rest, slice(e, hi, count(e)))
+		}
+	}
+	window = append(window, merged)
+	window = append(window, rest...)
+	window = append(window, ex[j:to]...)
+
+	joined := window[:1]
+	for _, e := range window[1:] {
+		if res, ok := join(joined[len(joined)-1], e); ok {
+			joined[len(joined)-1] = res
+		} else {
+			joined = append(joined, e)
+		}
+	}
+	if len(joined) == to-from {
+		copy(ex[from:to], joined)
+		return ex
+	}
+	if to == len(ex) {
+		return append(ex[:from], joined...)
+	}
+	tail := append(joined, ex[to:]...)
+	return append(ex[:from], tail...)
+}
+
+// covers - checks if all bytes of [start, end) range were written
+func (ex extents) covers(start, end int64) bool {
+	for pos := start; pos < end; {
+		k := sort.Search(len(ex), func(k int) bool { return ex[k].Start > pos }) - 1
+		if k < 0 {
+			return false
+		}
+		e := ex[k]
+		var i int64
+		if count(e) > 1 {
+			i = min((pos-e.Start)/e.Stride, count(e)-1)
+		}
+		_, memberEnd := member(e, i)
+		if memberEnd <= pos {
+			return false
+		}
+		pos = memberEnd
+	}
+	return true
+}
+
+// ranges - returns every range of extents, with runs split into their ranges
+func (ex extents) ranges() []utils.Extent {
+	res := make([]utils.Extent, 0, len(ex))
+	for _, e := range ex {
+		for i := int64(0); i < count(e); i++ {
+			start, end := member(e, i)
+			res = append(res, utils.Extent{Start: start, End: end})
+		}
+	}
+	return res
+}
+
+// runFlag - set in encoded start of an entry which is a run, so stride and count of the run follow its end
+const runFlag = uint64(1) << 63
+
+// appendExtents - appends entries encoded as start, end pairs to buf. Run has runFlag in its start and stride,
+// count pair after it, so plain ranges are encoded as they were before runs were kept
+func appendExtents(buf []byte, ex []utils.Extent) []byte {
+	for _, e := range ex {
+		if count(e) == 1 {
+			buf = binary.BigEndian.AppendUint64(buf, uint64(e.Start))
+			buf = binary.BigEndian.AppendUint64(buf, uint64(e.End))
+			continue
+		}
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.Start)|runFlag)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.End))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.Stride))
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.Count))
+	}
+	return buf
+}
+
+// decodeExtents - decodes entries encoded by appendExtents. Returns false if buf is damaged
+func decodeExtents(buf []byte) ([]utils.Extent, bool) {
+	var ex []utils.Extent
+	for len(buf) > 0 {
+		if len(buf) < 16 {
+			return nil, false
+		}
+		start, end := binary.BigEndian.Uint64(buf), int64(binary.BigEndian.Uint64(buf[8:]))
+		buf = buf[16:]
+		if start&runFlag == 0 {
+			ex = append(ex, utils.Extent{Start: int64(start), End: end})
+			continue
+		}
+		if len(buf) < 16 {
+			return nil, false
+		}
+		e := utils.Extent{Start: int64(start &^ runFlag), End: end, Stride: int64(binary.BigEndian.Uint64(buf)), Count: int64(binary.BigEndian.Uint64(buf[8:]))}
+		if e.Count < 2 || e.Stride <= e.End-e.Start {
+			return nil, false
+		}
+		ex = append(ex, e)
+		buf = buf[16:]
+	}
+	return ex, true
+}
+
+// fileExtents - returns written ranges of the file. fs.extentsLock should be held
+func (fs *LocalFS) fileExtents(fname string) (extents, error) {
+	if ex, ok := fs.extents[fname]; ok {
+		return ex, nil
+	}
+	ex, err := fs.storage.ReadExtents(fname)
+	if err != nil {
+		return nil, err
+	}
+	if fs.extents == nil {
+		fs.extents = make(map[string]extents)
+	}
+	fs.extents[fname] = ex
+	return ex, nil
+}
+
+// markWritten - remembers that [start, end) range of the file was written. Storage saves the range with
+// the data of the write itself
+func (fs *LocalFS) markWritten(fname string, start, end int64) error {
+	fs.extentsLock.Lock()
+	defer fs.extentsLock.Unlock()
+
+	ex, err := fs.fileExtents(fname)
+	if err != nil {
+		return err
+	}
+	fs.extents[fname] = ex.add(start, end)
+	return nil
+}
+
+// forgetExtents - drops cached written ranges of the file, since storage dropped or moved them.
+// fs.extentsLock should be held
+func (fs *LocalFS) forgetExtents(fname string) {
+	delete(fs.extents, fname)
+}
+
+// isWritten - checks if [start, end) range of the file was written
+func (fs *LocalFS) isWritten(fname string, start, end int64) (bool, error) {
+	fs.extentsLock.Lock()
+	defer fs.extentsLock.Unlock()
+
+	ex, err := fs.fileExtents(fname)
+	if err != nil {
+		return false, err
+	}
+	return ex.covers(start, end), nil
+}
+
+func (fs *LocalFS) Extents(args *utils.IOFileArgs, res *[]utils.Extent) (err error) {
+	if args.Filename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	ctx, span := utils.StartSpan(&args.CallContext, "PeerFS.Extents", utils.FileAttribute(*args.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogStorage).Debug("received extents request", "file", *args.Filename)
+
+	if err = checkName(*args.Filename); err != nil {
+		return utils.EncodeError(err)
+	}
+
+	fs.extentsLock.Lock()
+	defer fs.extentsLock.Unlock()
+
+	ex, err := fs.fileExtents(*args.Filename)
+	if err != nil {
+		return utils.EncodeError(err)
+	}
+	*res = ex.ranges()
+	return nil
+}
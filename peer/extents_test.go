@@ -0,0 +1,101 @@
+package peer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkCovers - checks that extents cover exactly bytes set in written
+func checkCovers(t *testing.T, ex extents, written []bool) {
+	t.Helper()
+	for i := range written {
+		if got := ex.covers(int64(i), int64(i+1)); got != written[i] {
+			t.Fatalf("byte %d is covered: %v, want %v; extents %v", i, got, written[i], ex)
+		}
+	}
+	for _, r := range ex.ranges() {
+		if !ex.covers(r.Start, r.End) {
+			t.Fatalf("range %v of extents is not covered; extents %v", r, ex)
+		}
+	}
+}
+
+func TestExtentsKeepStripedRecordsAsRun(t *testing.T) {
+	const recordSize, peers, records = 8, 3, 1000
+	var ex extents
+	written := make([]bool, recordSize*peers*records)
+	for k := 0; k < records; k++ {
+		start := int64(k * peers * recordSize)
+		ex = ex.add(start, start+recordSize)
+		for i := start; i < start+recordSize; i++ {
+			written[i] = true
+		}
+	}
+	if len(ex) != 1 || ex[0].Count != records || ex[0].Stride != peers*recordSize {
+		t.Fatalf("striped records are kept as %v, want one run of %d records", ex, records)
+	}
+	checkCovers(t, ex, written)
+	if ex.covers(0, 2*recordSize) {
+		t.Fatal("range over a hole between records is covered")
+	}
+	if n := len(ex.ranges()); n != records {
+		t.Fatalf("run has %d ranges, want %d", n, records)
+	}
+
+	// filling holes of the run leaves one range
+	for k := 0; k < records; k++ {
+		for p := int64(1); p < peers; p++ {
+			start := int64(k*peers*recordSize) + p*recordSize
+			ex = ex.add(start, start+recordSize)
+		}
+	}
+	if len(ex) != 1 || ex[0].Count > 1 || ex[0].End != int64(len(written)) {
+		t.Fatalf("fully written file is kept as %v, want one range", ex)
+	}
+}
+
+func TestExtentsMatchWrittenBytes(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 200; round++ {
+		var ex extents
+		written := make([]bool, 512)
+		recordSize := int64(1 + rnd.Intn(8))
+		for w := 0; w < 40; w++ {
+			// mostly records of a striped file in random order, sometimes any range
+			var start, end int64
+			if rnd.Intn(4) > 0 {
+				start = int64(rnd.Intn(len(written)/int(recordSize))) * recordSize
+				end = start + recordSize
+			} else {
+				start = int64(rnd.Intn(len(written)))
+				end = min(start+int64(rnd.Intn(40)), int64(len(written)))
+			}
+			ex = ex.add(start, end)
+			for i := start; i < end; i++ {
+				written[i] = true
+			}
+			checkCovers(t, ex, written)
+			for i := 1; i < len(ex); i++ {
+				if _, last := member(ex[i-1], count(ex[i-1])-1); last >= ex[i].Start {
+					t.Fatalf("entries %v and %v overlap; extents %v", ex[i-1], ex[i], ex)
+				}
+			}
+		}
+	}
+}
+
+func TestExtentsEncoding(t *testing.T) {
+	ex := extents{{Start: 0, End: 4}, {Start: 8, End: 12, Stride: 12, Count: 5}, {Start: 100, End: 101}}
+	decoded, ok := decodeExtents(appendExtents(nil, ex))
+	if !ok || len(decoded) != len(ex) {
+		t.Fatalf("decoded %v, %v; want %v", decoded, ok, ex)
+	}
+	for i := range ex {
+		if decoded[i] != ex[i] {
+			t.Fatalf("decoded %v, want %v", decoded, ex)
+		}
+	}
+	if _, ok = decodeExtents(appendExtents(nil, ex)[:40]); ok {
+		t.Fatal("run without stride and count is decoded")
+	}
+}
@@ -0,0 +1,131 @@
+package peer
+
+import (
+	"context"
+	"github.com/alikhil/distributed-fs/dfspb"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"math"
+)
+
+// grpcPeerFS - serves grpc api of peer by calling the same methods of LocalFS as net/rpc does
+type grpcPeerFS struct {
+	dfspb.UnimplementedPeerFSServer
+	fs *LocalFS
+}
+
+// NewGRPCPeerFS - returns grpc server of peer api backed by fs
+func NewGRPCPeerFS(fs *LocalFS) dfspb.PeerFSServer {
+	return &grpcPeerFS{fs: fs}
+}
+
+// toInt32 - checks that value sent by grpc client fits into int32 used by dfs
+func toInt32(value int64, name string) (int32, error) {
+	if value < 0 || value > math.MaxInt32 {
+		return 0, utils.Errorf(utils.CodeInvalidArgument, "%s %d is out of range", name, value)
+	}
+	return int32(value), nil
+}
+
+// fileArgs - args of net/rpc call made by grpc request to the file
+func fileArgs(ctx context.Context, req *dfspb.FileRequest) *utils.IOFileArgs {
+	return &utils.IOFileArgs{Filename: &req.Filename, CallContext: utils.GRPCCallContext(ctx)}
+}
+
+func (s *grpcPeerFS) Ping(ctx context.Context, req *dfspb.Empty) (*dfspb.Empty, error) {
+	return &dfspb.Empty{}, nil
+}
+
+func (s *grpcPeerFS) Close(ctx context.Context, req *dfspb.Empty) (*dfspb.Empty, error) {
+	args := utils.ControlArgs{Token: utils.GRPCCallContext(ctx).Token}
+	var ok bool
+	return &dfspb.Empty{}, utils.GRPCError(s.fs.Close(&args, &ok))
+}
+
+func (s *grpcPeerFS) FileExists(ctx context.Context, req *dfspb.FileRequest) (*dfspb.ExistsReply, error) {
+	var exists bool
+	err := s.fs.FileExists(fileArgs(ctx, req), &exists)
+	return &dfspb.ExistsReply{Exists: exists}, utils.GRPCError(err)
+}
+
+func (s *grpcPeerFS) FileSize(ctx context.Context, req *dfspb.FileRequest) (*dfspb.FileSizeReply, error) {
+	var size int64
+	err := s.fs.FileSize(fileArgs(ctx, req), &size)
+	return &dfspb.FileSizeReply{Size: size}, utils.GRPCError(err)
+}
+
+func (s *grpcPeerFS) CreateFile(ctx context.Context, req *dfspb.FileRequest) (*dfspb.Empty, error) {
+	var ok bool
+	return &dfspb.Empty{}, utils.GRPCError(s.fs.CreateFile(fileArgs(ctx, req), &ok))
+}
+
+func (s *grpcPeerFS) DeleteFile(ctx context.Context, req *dfspb.FileRequest) (*dfspb.Empty, error) {
+	var ok bool
+	return &dfspb.Empty{}, utils.GRPCError(s.fs.DeleteFile(fileArgs(ctx, req), &ok))
+}
+
+// ReadBytes - streams requested range by chunks of utils.GRPCChunkSize
+func (s *grpcPeerFS) ReadBytes(req *dfspb.ReadRequest, stream dfspb.PeerFS_ReadBytesServer) error {
+	offset, err := toInt32(req.Offset, "offset")
+	if err != nil {
+		return utils.GRPCError(err)
+	}
+	count, err := toInt32(req.Count, "count")
+	if err != nil {
+		return utils.GRPCError(err)
+	}
+
+	call := utils.GRPCCallContext(stream.Context())
+	for count > 0 {
+		chunkSize := int32(utils.GRPCChunkSize)
+		if chunkSize > count {
+			chunkSize = count
+		}
+		var data []byte
+		err = s.fs.ReadBytes(&utils.IOReadArgs{Filename: &req.Filename, Offset: offset, Count: chunkSize, FailOnHole: req.FailOnHole, CallContext: call}, &data)
+		if err != nil {
+			return utils.GRPCError(err)
+		}
+		if err = stream.Send(&dfspb.Chunk{Offset: int64(offset), Data: data}); err != nil {
+			return err
+		}
+		offset += chunkSize
+		count -= chunkSize
+	}
+	return nil
+}
+
+func (s *grpcPeerFS) WriteBytes(stream dfspb.PeerFS_WriteBytesServer) error {
+	call := utils.GRPCCallContext(stream.Context())
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&dfspb.Empty{})
+		}
+		if err != nil {
+			return err
+		}
+		offset, err := toInt32(chunk.Offset, "offset")
+		if err != nil {
+			return utils.GRPCError(err)
+		}
+		var ok bool
+		err = s.fs.WriteBytes(&utils.IOWriteArgs{Filename: &chunk.Filename, Offset: offset, Data: &chunk.Data, CallContext: call}, &ok)
+		if err != nil {
+			return utils.GRPCError(err)
+		}
+	}
+}
+
+func (s *grpcPeerFS) Extents(ctx context.Context, req *dfspb.FileRequest) (*dfspb.ExtentsReply, error) {
+	var extents []utils.Extent
+	err := s.fs.Extents(fileArgs(ctx, req), &extents)
+	if err != nil {
+		return nil, utils.GRPCError(err)
+	}
+	res := &dfspb.ExtentsReply{Extents: make([]*dfspb.Extent, len(extents))}
+	for i, e := range extents {
+		res.Extents[i] = &dfspb.Extent{Start: e.Start, End: e.End}
+	}
+	return res, nil
+}
@@ -0,0 +1,398 @@
+package peer
+
+import (
+	"crypto/tls"
+	"github.com/alikhil/distributed-fs/utils"
+	"google.golang.org/grpc"
+
+	"net"
+	"net/rpc"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LocalFS - peer which stores files of dfs in its storage and serves them to master
+type LocalFS struct {
+	GRPCServer   *grpc.Server // stopped on close command if not nil
+	Metrics      *Metrics     // records data stored by peer if not nil
+	DataEndpoint string       // sent to master on join if peer serves clients directly, see RunDataRPC
+
+	isRPCRunning  bool
+	rpcListener   *net.Listener
+	isDataRunning bool
+	dataListener  *net.Listener
+	storage       Storage
+	controlKey    []byte // given by master on join; control calls should be signed by it
+	accessKey     []byte // given by master on join; access tokens of clients should be signed by it
+	keysLock      sync.RWMutex
+	epoch         uint64 // the latest epoch of placement known to the peer; changed with atomic
+
+	extents     map[string]extents // written ranges of the files read from storage
+	extentsLock sync.Mutex
+}
+
+// NewLocalFS - returns peer which stores files in fsDir. Directory is created if it does not exist
+func NewLocalFS(fsDir string) (*LocalFS, error) {
+	storage, err := NewDirStorage(fsDir)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalFSWithStorage(storage), nil
+}
+
+// NewLocalFSWithStorage - returns peer which stores files in the storage
+func NewLocalFSWithStorage(storage Storage) *LocalFS {
+	return &LocalFS{storage: storage}
+}
+
+// CloseStorage - syncs and closes storage of the peer. Peer should not serve calls after it
+func (fs *LocalFS) CloseStorage() error {
+	err := fs.storage.Sync()
+	if closeErr := fs.storage.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Join - joins the cluster of master, so master connects to the peer on endpoint
+func (fs *LocalFS) Join(master *rpc.Client, endpoint string, joinToken string) error {
+	var res utils.PeerJoinResult
+	err := master.Call("RemoteIO.AddPeer", &utils.PeerJoinArgs{Endpoint: &endpoint, JoinToken: joinToken, DataEndpoint: fs.DataEndpoint}, &res)
+	if err != nil {
+		return utils.DecodeError(err)
+	}
+	// master may call peer which serves calls before it joins
+	fs.keysLock.Lock()
+	fs.controlKey, fs.accessKey = res.ControlKey, res.AccessKey
+	fs.keysLock.Unlock()
+	atomic.StoreUint64(&fs.epoch, res.Epoch)
+	return nil
+}
+
+// RunRPC - serves rpc calls of master on port untill close command is received
+func (fs *LocalFS) RunRPC(port int, config *tls.Config, metrics *utils.RPCMetrics) {
+	utils.RunRPCWithTLS("PeerFS", fs, port, &fs.isRPCRunning, &fs.rpcListener, config, metrics)
+}
+
+func (*LocalFS) Ping(a, b *int) error {
+	*b = *a
+	return nil
+}
+
+// authorizeControl - checks that control call is sent by master which peer joined
+func (fs *LocalFS) authorizeControl(args *utils.ControlArgs) error {
+	fs.keysLock.RLock()
+	controlKey := fs.controlKey
+	fs.keysLock.RUnlock()
+	claims, err := utils.VerifyToken(controlKey, args.Token)
+	if err != nil {
+		return err
+	}
+	if claims.Subject != utils.RoleMaster {
+		return utils.Errorf(utils.CodePermissionDenied, "%s is not allowed to control peer", claims.Subject)
+	}
+	return nil
+}
+
+func (fs *LocalFS) Close(args *utils.ControlArgs, ok *bool) error {
+	if err := fs.authorizeControl(args); err != nil {
+		utils.Logger(utils.LogRPC).Warn("rejected close command", "err", err)
+		return utils.EncodeError(err)
+	}
+	utils.Logger(utils.LogRPC).Info("received close command; stopping everything")
+	fs.isRPCRunning = false
+	if fs.GRPCServer != nil {
+		// close can be called by grpc itself, so server is stopped in background
+		go fs.GRPCServer.Stop()
+	}
+	if fs.rpcListener != nil {
+		(*fs.rpcListener).Close()
+	}
+	fs.isDataRunning = false
+	if fs.dataListener != nil {
+		(*fs.dataListener).Close()
+	}
+	if err := fs.storage.Sync(); err != nil {
+		utils.Logger(utils.LogStorage).Error("failed to sync storage", "err", err)
+	}
+	*ok = true
+	return nil
+}
+
+// Stats - returns number of stored files and disk space taken by them
+func (fs *LocalFS) Stats(args *utils.ControlArgs, stats *utils.PeerStats) error {
+	if err := fs.authorizeControl(args); err != nil {
+		return utils.EncodeError(err)
+	}
+	files, err := fs.storage.List("")
+	if err != nil {
+		return utils.EncodeError(err)
+	}
+	stats.Files = len(files)
+	stats.DiskUsage = fs.storage.DiskUsage()
+	return nil
+}
+
+// SetEpoch - learns epoch of placement from master, so access tokens given with older placements are rejected
+func (fs *LocalFS) SetEpoch(args *utils.EpochArgs, ok *bool) error {
+	if err := fs.authorizeControl(&args.ControlArgs); err != nil {
+		return utils.EncodeError(err)
+	}
+	fs.raiseEpoch(args.Epoch)
+	*ok = true
+	return nil
+}
+
+// raiseEpoch - remembers epoch unless a newer one is known. Epochs come from master
+// and from access tokens, so they may come out of order
+func (fs *LocalFS) raiseEpoch(epoch uint64) {
+	for {
+		known := atomic.LoadUint64(&fs.epoch)
+		if epoch <= known || atomic.CompareAndSwapUint64(&fs.epoch, known, epoch) {
+			return
+		}
+	}
+}
+
+// checkName - checks that name of dfs file can be stored by any storage
+func checkName(fname string) error {
+	if filepath.IsAbs(fname) {
+		return utils.Errorf(utils.CodeInvalidArgument, "path %s is absolute. use only relative paths", fname)
+	}
+
+	if strings.Contains(fname, "/") {
+		return utils.NewError(utils.CodeInvalidArgument, "path contains directories. dfs does not support directories")
+	}
+
+	if fname == "" || fname == "." || fname == ".." || fname == extentsDir {
+		return utils.Errorf(utils.CodeInvalidArgument, "name %q is reserved by dfs", fname)
+	}
+	return nil
+}
+
+// Calls which lack file name or data are rejected before handlers dereference them,
+// since net/rpc does not recover panics and one such call would stop peer
+var (
+	errMissingFilename = utils.NewError(utils.CodeInvalidArgument, "file name is missing")
+	errMissingData     = utils.NewError(utils.CodeInvalidArgument, "data is missing")
+)
+
+func (fs *LocalFS) FileExists(args *utils.IOFileArgs, res *bool) (err error) {
+	if args.Filename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	ctx, span := utils.StartSpan(&args.CallContext, "PeerFS.FileExists", utils.FileAttribute(*args.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogStorage).Debug("received file exists request", "file", *args.Filename)
+
+	if err = checkName(*args.Filename); err != nil {
+		return utils.EncodeError(err)
+	}
+
+	exists, err := fs.storage.Exists(*args.Filename)
+	*res = exists
+	return utils.EncodeError(err)
+}
+
+func (fs *LocalFS) FileSize(args *utils.IOFileArgs, size *int64) (err error) {
+	if args.Filename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	ctx, span := utils.StartSpan(&args.CallContext, "PeerFS.FileSize", utils.FileAttribute(*args.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogStorage).Debug("received file size request", "file", *args.Filename)
+
+	if err = checkName(*args.Filename); err != nil {
+		return utils.EncodeError(err)
+	}
+
+	info, err := fs.storage.Stat(*args.Filename)
+	if utils.CodeOf(err) == utils.CodeNotFound {
+		*size = 0
+		return nil
+	}
+	if err != nil {
+		return utils.EncodeError(err)
+	}
+	*size = info.Size
+	return nil
+}
+
+func (fs *LocalFS) CreateFile(args *utils.IOFileArgs, res *bool) (err error) {
+	if args.Filename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	ctx, span := utils.StartSpan(&args.CallContext, "PeerFS.CreateFile", utils.FileAttribute(*args.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogStorage).Debug("received create file request", "file", *args.Filename)
+
+	if err = checkName(*args.Filename); err != nil {
+		return utils.EncodeError(err)
+	}
+
+	fs.extentsLock.Lock()
+	defer fs.extentsLock.Unlock()
+	err = fs.storage.Create(*args.Filename)
+	fs.forgetExtents(*args.Filename)
+	*res = err == nil
+	return utils.EncodeError(err)
+}
+
+func (fs *LocalFS) DeleteFile(args *utils.IOFileArgs, res *bool) (err error) {
+	if args.Filename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	ctx, span := utils.StartSpan(&args.CallContext, "PeerFS.DeleteFile", utils.FileAttribute(*args.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogStorage).Debug("received delete file request", "file", *args.Filename)
+
+	if err = checkName(*args.Filename); err != nil {
+		return utils.EncodeError(err)
+	}
+
+	fs.extentsLock.Lock()
+	defer fs.extentsLock.Unlock()
+	err = fs.storage.Delete(*args.Filename)
+	fs.forgetExtents(*args.Filename)
+	if err == nil {
+		fs.Metrics.fileRemoved(*args.Filename)
+	}
+	if utils.CodeOf(err) == utils.CodeNotFound {
+		*res = false
+		return nil
+	}
+	*res = err == nil
+	return utils.EncodeError(err)
+}
+
+// ListFiles - returns stored files which names start with prefix
+func (fs *LocalFS) ListFiles(args *utils.IOListArgs, res *[]utils.FileInfo) (err error) {
+	ctx, span := utils.StartSpan(&args.CallContext, "PeerFS.ListFiles")
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogStorage).Debug("received list files request", "prefix", args.Prefix)
+
+	files, err := fs.storage.List(args.Prefix)
+	if err != nil {
+		return utils.EncodeError(err)
+	}
+	*res = files
+	return nil
+}
+
+// RenameFile - gives the file new name. Fails if file with new name exists
+func (fs *LocalFS) RenameFile(args *utils.IORenameArgs, res *bool) (err error) {
+	if args.Filename == nil || args.NewFilename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	ctx, span := utils.StartSpan(&args.CallContext, "PeerFS.RenameFile", utils.FileAttribute(*args.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	utils.RequestLogger(ctx, utils.LogStorage).Debug("received rename file request", "file", *args.Filename, "new_file", *args.NewFilename)
+
+	if err = checkName(*args.Filename); err != nil {
+		return utils.EncodeError(err)
+	}
+	if err = checkName(*args.NewFilename); err != nil {
+		return utils.EncodeError(err)
+	}
+
+	fs.extentsLock.Lock()
+	defer fs.extentsLock.Unlock()
+	exists, err := fs.storage.Exists(*args.Filename)
+	if err != nil {
+		return utils.EncodeError(err)
+	}
+	if !exists {
+		return utils.EncodeError(utils.Errorf(utils.CodeNotFound, "file(%s) does not exist", *args.Filename))
+	}
+	if exists, err = fs.storage.Exists(*args.NewFilename); err != nil || exists {
+		if err == nil {
+			err = utils.Errorf(utils.CodeAlreadyExists, "file(%s) already exists", *args.NewFilename)
+		}
+		return utils.EncodeError(err)
+	}
+
+	err = fs.storage.Rename(*args.Filename, *args.NewFilename)
+	fs.forgetExtents(*args.Filename)
+	fs.forgetExtents(*args.NewFilename)
+	if err == nil {
+		fs.Metrics.fileRemoved(*args.Filename)
+	}
+	*res = err == nil
+	return utils.EncodeError(err)
+}
+
+func (fs *LocalFS) ReadBytes(readArgs *utils.IOReadArgs, data *[]byte) (err error) {
+	if readArgs.Filename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	ctx, span := utils.StartSpan(&readArgs.CallContext, "PeerFS.ReadBytes", utils.FileAttribute(*readArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogStorage).With("file", *readArgs.Filename)
+	logger.Debug("received read bytes request", "offset", readArgs.Offset, "count", readArgs.Count)
+
+	if err = checkName(*readArgs.Filename); err != nil {
+		logger.Warn("could not read bytes", "err", err)
+		return utils.EncodeError(err)
+	}
+
+	exists, err := fs.storage.Exists(*readArgs.Filename)
+	if err == nil && !exists {
+		err = utils.Errorf(utils.CodeNotFound, "file(%s) does not exist", *readArgs.Filename)
+	}
+	if err != nil {
+		logger.Warn("could not read bytes", "err", err)
+		return utils.EncodeError(err)
+	}
+
+	if readArgs.FailOnHole {
+		written, err := fs.isWritten(*readArgs.Filename, int64(readArgs.Offset), int64(readArgs.Offset+readArgs.Count))
+		if err != nil {
+			logger.Warn("could not read bytes", "err", err)
+			return utils.EncodeError(err)
+		}
+		if !written {
+			return utils.EncodeError(utils.ErrNotWritten)
+		}
+	}
+
+	*data = make([]byte, readArgs.Count, readArgs.Count)
+	if err = fs.storage.ReadAt(*readArgs.Filename, *data, int64(readArgs.Offset)); err != nil {
+		logger.Warn("could not read bytes", "offset", readArgs.Offset, "count", readArgs.Count, "err", err)
+		return utils.EncodeError(err)
+	}
+	logger.Debug("read bytes successfully")
+	fs.Metrics.fileRead(*readArgs.Filename, len(*data))
+	return nil
+}
+
+func (fs *LocalFS) WriteBytes(writeArgs *utils.IOWriteArgs, res *bool) (err error) {
+	if writeArgs.Filename == nil {
+		return utils.EncodeError(errMissingFilename)
+	}
+	if writeArgs.Data == nil {
+		return utils.EncodeError(errMissingData)
+	}
+	ctx, span := utils.StartSpan(&writeArgs.CallContext, "PeerFS.WriteBytes", utils.FileAttribute(*writeArgs.Filename))
+	defer func() { utils.EndSpan(span, err) }()
+	logger := utils.RequestLogger(ctx, utils.LogStorage).With("file", *writeArgs.Filename)
+	logger.Debug("received write bytes request", "offset", writeArgs.Offset, "count", len(*writeArgs.Data))
+
+	if err = checkName(*writeArgs.Filename); err != nil {
+		return utils.EncodeError(err)
+	}
+
+	if err = fs.storage.WriteAt(*writeArgs.Filename, *writeArgs.Data, int64(writeArgs.Offset)); err != nil {
+		*res = false
+		return utils.EncodeError(err)
+	}
+
+	fs.Metrics.fileWritten(*writeArgs.Filename, len(*writeArgs.Data))
+
+	start := int64(writeArgs.Offset)
+	err = fs.markWritten(*writeArgs.Filename, start, start+int64(len(*writeArgs.Data)))
+	*res = err == nil
+	return utils.EncodeError(err)
+
+}
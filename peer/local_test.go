@@ -0,0 +1,34 @@
+package peer
+
+import (
+	"github.com/alikhil/distributed-fs/utils"
+	"testing"
+)
+
+func TestLocalFSRejectsMissingFilename(t *testing.T) {
+	fs, err := NewLocalFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ok bool
+	var size int64
+	var data []byte
+	var ex []utils.Extent
+	name := "a"
+	calls := map[string]func() error{
+		"FileExists":  func() error { return fs.FileExists(&utils.IOFileArgs{}, &ok) },
+		"FileSize":    func() error { return fs.FileSize(&utils.IOFileArgs{}, &size) },
+		"CreateFile":  func() error { return fs.CreateFile(&utils.IOFileArgs{}, &ok) },
+		"DeleteFile":  func() error { return fs.DeleteFile(&utils.IOFileArgs{}, &ok) },
+		"RenameFile":  func() error { return fs.RenameFile(&utils.IORenameArgs{Filename: &name}, &ok) },
+		"ReadBytes":   func() error { return fs.ReadBytes(&utils.IOReadArgs{Count: 1}, &data) },
+		"WriteBytes":  func() error { return fs.WriteBytes(&utils.IOWriteArgs{Data: &data}, &ok) },
+		"WriteNoData": func() error { return fs.WriteBytes(&utils.IOWriteArgs{Filename: &name}, &ok) },
+		"Extents":     func() error { return fs.Extents(&utils.IOFileArgs{}, &ex) },
+	}
+	for call, f := range calls {
+		if err := f(); utils.CodeOf(err) != utils.CodeInvalidArgument {
+			t.Errorf("%s returned %v, want InvalidArgument", call, err)
+		}
+	}
+}
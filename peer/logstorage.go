@@ -0,0 +1,597 @@
+package peer
+
+import (
+	"bufio"
+	"github.com/alikhil/distributed-fs/utils"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSegmentSize - size after which log storage starts new segment
+	defaultSegmentSize = 64 << 20
+	// compactionInterval - how often log storage looks for segments to compact
+	compactionInterval = 5 * time.Second
+	// compactionThreshold - sealed segment is compacted when share of its live data falls below it
+	compactionThreshold = 0.5
+)
+
+// LogStorage - appends writes of all the files into large segment files, so peer with many small files takes
+// few inodes and random writes become sequential. Where bytes of every file lie and which ranges were written
+// is kept in memory and rebuilt from segments on open; write entry marks its range written by itself. Sealed segments with mostly overwritten data are compacted in background:
+// their live data is appended again and segment is removed
+type LogStorage struct {
+	dir         string
+	segmentSize int64
+
+	lock     sync.RWMutex
+	segments map[uint32]*segment
+	active   *segment
+	files    map[string]*logFile // live files by name
+	byID     map[uint64]*logFile
+	bindings map[string]uint32 // segment with the latest entry which binds the name, including deletions
+	nextID   uint64
+	// set while segments are replayed on open. Compaction appends bindings in any order, so a file unbound by
+	// one entry may be bound to another name by a later one, and files are dropped only after replay
+	replaying bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// logFile - file of dfs kept in log storage
+type logFile struct {
+	id        uint64
+	size      int64
+	intervals []logInterval // sorted and not overlapping
+	extents   []utils.Extent
+	extentsAt uint32 // segment with the latest extents entry of the file; 0 if there is none
+}
+
+// hasData - checks if some bytes of the file lie in the segment
+func (f *logFile) hasData(seg uint32) bool {
+	for _, iv := range f.intervals {
+		if iv.seg == seg {
+			return true
+		}
+	}
+	return false
+}
+
+// logInterval - bytes [start, end) of the file lie in the segment from pos
+type logInterval struct {
+	start, end int64
+	seg        uint32
+	pos        int64
+}
+
+// NewLogStorage - opens log storage in opts.Dir replaying its segments, and starts compaction
+func NewLogStorage(opts StorageOptions) (*LogStorage, error) {
+	if err := os.MkdirAll(opts.Dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	s := &LogStorage{
+		dir:         opts.Dir,
+		segmentSize: opts.SegmentSize,
+		segments:    make(map[uint32]*segment),
+		files:       make(map[string]*logFile),
+		byID:        make(map[uint64]*logFile),
+		bindings:    make(map[string]uint32),
+		nextID:      1,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if s.segmentSize <= 0 {
+		s.segmentSize = defaultSegmentSize
+	}
+
+	ids, err := listSegments(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	s.replaying = true
+	for i, id := range ids {
+		if err = s.replay(id, i == len(ids)-1); err != nil {
+			s.closeSegments()
+			return nil, err
+		}
+	}
+	s.replaying = false
+	// files which data was left in segments after they were deleted or which name was taken by another file
+	named := make(map[uint64]bool, len(s.files))
+	for _, f := range s.files {
+		named[f.id] = true
+	}
+	for id, f := range s.byID {
+		if !named[id] {
+			s.drop(f)
+		}
+	}
+	if s.active == nil {
+		if err = s.roll(); err != nil {
+			s.closeSegments()
+			return nil, err
+		}
+	}
+	utils.Logger(utils.LogStorage).Info("opened log storage", "dir", s.dir, "segments", len(s.segments), "files", len(s.files))
+
+	go s.runCompaction()
+	return s, nil
+}
+
+// replay - applies entries of the segment. Damaged tail of the last segment is cut off, since it's left by crash
+func (s *LogStorage) replay(id uint32, last bool) error {
+	flags := os.O_RDONLY
+	if last {
+		flags = os.O_RDWR
+	}
+	file, err := os.OpenFile(segmentPath(s.dir, id), flags, 0644)
+	if err != nil {
+		return err
+	}
+	seg := &segment{id: id, file: file}
+	s.segments[id] = seg
+
+	r := bufio.NewReaderSize(io.NewSectionReader(file, 0, 1<<62), 1<<20)
+	for {
+		e, n, err := readEntry(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !last {
+				return utils.Errorf(utils.CodeUnknown, "segment %s is damaged at %d", segmentPath(s.dir, id), seg.size)
+			}
+			utils.Logger(utils.LogStorage).Warn("cutting off torn tail of segment", "segment", segmentPath(s.dir, id), "offset", seg.size)
+			if err = file.Truncate(seg.size); err != nil {
+				return err
+			}
+			break
+		}
+		s.apply(e, seg.id, seg.size)
+		seg.size += n
+	}
+	if last {
+		s.active = seg
+	}
+	return nil
+}
+
+// apply - changes index according to the entry lying in the segment at pos. s.lock should be held
+func (s *LogStorage) apply(e *logEntry, seg uint32, pos int64) {
+	if e.id >= s.nextID {
+		s.nextID = e.id + 1
+	}
+	switch e.kind {
+	case entryBind:
+		s.bind(e.name, e.id, seg)
+	case entryRename:
+		if e.name == e.newName {
+			return
+		}
+		s.bind(e.newName, e.id, seg)
+		delete(s.files, e.name)
+		s.bindings[e.name] = seg
+	case entryData, entryWrite:
+		if len(e.data) == 0 {
+			return
+		}
+		f := s.file(e.id)
+		end := e.offset + int64(len(e.data))
+		s.put(f, logInterval{start: e.offset, end: end, seg: seg, pos: pos + dataHeaderSize})
+		if e.kind == entryWrite {
+			f.extents = extents(f.extents).add(e.offset, end)
+		}
+	case entryExtents:
+		f := s.file(e.id)
+		f.extents = append(f.extents[:0], e.extents...)
+		f.extentsAt = seg
+	}
+}
+
+// file - returns file with the id. Compaction moves bindings of files to newer segments than some of their data,
+// so on replay data may come before the name, and the file is added unnamed. s.lock should be held
+func (s *LogStorage) file(id uint64) *logFile {
+	f, ok := s.byID[id]
+	if !ok {
+		f = &logFile{id: id}
+		s.byID[id] = f
+	}
+	return f
+}
+
+// bind - makes name point to the file with id. Previous file of the name is dropped unless it's the same file
+// or segments are replayed. s.lock should be held
+func (s *LogStorage) bind(name string, id uint64, seg uint32) {
+	if old, ok := s.files[name]; ok && old.id != id {
+		delete(s.files, name)
+		if !s.replaying {
+			s.drop(old)
+		}
+	}
+	s.bindings[name] = seg
+	if id != 0 {
+		s.files[name] = s.file(id)
+	}
+}
+
+// drop - forgets the file, so its data becomes garbage. s.lock should be held
+func (s *LogStorage) drop(f *logFile) {
+	for _, iv := range f.intervals {
+		s.segments[iv.seg].live -= iv.end - iv.start
+	}
+	delete(s.byID, f.id)
+}
+
+// put - makes range of the interval point to it. s.lock should be held
+func (s *LogStorage) put(f *logFile, iv logInterval) {
+	// intervals[i:j] overlap the new one
+	i := sort.Search(len(f.intervals), func(k int) bool { return f.intervals[k].end > iv.start })
+	j := sort.Search(len(f.intervals), func(k int) bool { return f.intervals[k].start >= iv.end })
+
+	replacement := make([]logInterval, 0, 3)
+	for k := i; k < j; k++ {
+		old := f.intervals[k]
+		if old.start < iv.start {
+			replacement = append(replacement, logInterval{start: old.start, end: iv.start, seg: old.seg, pos: old.pos})
+		}
+		overlapStart, overlapEnd := max(old.start, iv.start), min(old.end, iv.end)
+		s.segments[old.seg].live -= overlapEnd - overlapStart
+		if k == j-1 {
+			replacement = append(replacement, iv)
+		}
+		if old.end > iv.end {
+			replacement = append(replacement, logInterval{start: iv.end, end: old.end, seg: old.seg, pos: old.pos + iv.end - old.start})
+		}
+	}
+	if i == j {
+		replacement = append(replacement, iv)
+	}
+	s.segments[iv.seg].live += iv.end - iv.start
+
+	if len(replacement) == j-i {
+		copy(f.intervals[i:j], replacement)
+	} else {
+		tail := append(replacement, f.intervals[j:]...)
+		f.intervals = append(f.intervals[:i], tail...)
+	}
+	if iv.end > f.size {
+		f.size = iv.end
+	}
+}
+
+// append - writes the entry to active segment and applies it. s.lock should be held
+func (s *LogStorage) append(e *logEntry) error {
+	if s.active.size >= s.segmentSize {
+		if err := s.roll(); err != nil {
+			return err
+		}
+	}
+	buf := encodeEntry(e)
+	seg, pos := s.active, s.active.size
+	if _, err := seg.file.WriteAt(buf, pos); err != nil {
+		// torn entry would hide entries appended after it
+		seg.file.Truncate(pos)
+		return err
+	}
+	seg.size += int64(len(buf))
+	s.apply(e, seg.id, pos)
+	return nil
+}
+
+// roll - seals active segment and starts the new one. s.lock should be held
+func (s *LogStorage) roll() error {
+	id := uint32(1)
+	if s.active != nil {
+		if err := s.active.file.Sync(); err != nil {
+			return err
+		}
+		id = s.active.id + 1
+	}
+	file, err := os.OpenFile(segmentPath(s.dir, id), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if err = syncPath(s.dir); err != nil {
+		file.Close()
+		return err
+	}
+	s.active = &segment{id: id, file: file}
+	s.segments[id] = s.active
+	return nil
+}
+
+func (s *LogStorage) Create(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.append(&logEntry{kind: entryBind, id: s.nextID, name: name})
+}
+
+func (s *LogStorage) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.files[name]; !ok {
+		return notFound(name)
+	}
+	return s.append(&logEntry{kind: entryBind, name: name})
+}
+
+func (s *LogStorage) Exists(name string) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	_, ok := s.files[name]
+	return ok, nil
+}
+
+func (s *LogStorage) ReadAt(name string, p []byte, off int64) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	f, ok := s.files[name]
+	if !ok {
+		return notFound(name)
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	end := off + int64(len(p))
+	k := sort.Search(len(f.intervals), func(k int) bool { return f.intervals[k].end > off })
+	for ; k < len(f.intervals) && f.intervals[k].start < end; k++ {
+		iv := f.intervals[k]
+		from, to := max(iv.start, off), min(iv.end, end)
+		if _, err := s.segments[iv.seg].file.ReadAt(p[from-off:to-off], iv.pos+from-iv.start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LogStorage) WriteAt(name string, p []byte, off int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.files[name]
+	if !ok {
+		if err := s.append(&logEntry{kind: entryBind, id: s.nextID, name: name}); err != nil {
+			return err
+		}
+		f = s.files[name]
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	return s.append(&logEntry{kind: entryWrite, id: f.id, offset: off, data: p})
+}
+
+func (s *LogStorage) Rename(name, newName string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.files[name]
+	if !ok {
+		return notFound(name)
+	}
+	return s.append(&logEntry{kind: entryRename, id: f.id, name: name, newName: newName})
+}
+
+func (s *LogStorage) List(prefix string) ([]utils.FileInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	files := make([]utils.FileInfo, 0, len(s.files))
+	for name, f := range s.files {
+		if strings.HasPrefix(name, prefix) {
+			files = append(files, utils.FileInfo{Name: name, Size: f.size})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (s *LogStorage) Stat(name string) (utils.FileInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	f, ok := s.files[name]
+	if !ok {
+		return utils.FileInfo{}, notFound(name)
+	}
+	return utils.FileInfo{Name: name, Size: f.size}, nil
+}
+
+func (s *LogStorage) ReadExtents(name string) ([]utils.Extent, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if f, ok := s.files[name]; ok {
+		return append([]utils.Extent(nil), f.extents...), nil
+	}
+	return nil, nil
+}
+
+func (s *LogStorage) WriteExtents(name string, ex []utils.Extent) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.files[name]
+	if !ok {
+		return notFound(name)
+	}
+	return s.append(extentsEntry(f.id, ex))
+}
+
+func extentsEntry(id uint64, ex []utils.Extent) *logEntry {
+	return &logEntry{kind: entryExtents, id: id, extents: ex}
+}
+
+// Sync - flushes active segment to disk. Sealed segments are flushed when they are sealed
+func (s *LogStorage) Sync() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.active.file.Sync()
+}
+
+// DiskUsage - returns size of all segments
+func (s *LogStorage) DiskUsage() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.size
+	}
+	return total
+}
+
+// Close - stops compaction and closes segments
+func (s *LogStorage) Close() error {
+	select {
+	case <-s.stop:
+		return nil
+	default:
+	}
+	close(s.stop)
+	<-s.done
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	err := s.active.file.Sync()
+	if closeErr := s.closeSegments(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// closeSegments - closes files of all segments. s.lock should be held
+func (s *LogStorage) closeSegments() error {
+	var err error
+	for _, seg := range s.segments {
+		if closeErr := seg.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (s *LogStorage) runCompaction() {
+	defer close(s.done)
+	ticker := time.NewTicker(compactionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+		if id, ok := s.compactionCandidate(); ok {
+			if err := s.compact(id); err != nil {
+				utils.Logger(utils.LogStorage).Error("failed to compact segment", "segment", segmentPath(s.dir, id), "err", err)
+			}
+		}
+	}
+}
+
+// compactionCandidate - returns sealed segment with the smallest share of live data, if it's below threshold
+func (s *LogStorage) compactionCandidate() (uint32, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var best *segment
+	for _, seg := range s.segments {
+		if seg == s.active || float64(seg.live) >= compactionThreshold*float64(seg.size) {
+			continue
+		}
+		if best == nil || float64(seg.live)/float64(seg.size) < float64(best.live)/float64(best.size) {
+			best = seg
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.id, true
+}
+
+// compact - appends live entries of sealed segment to active one and removes the segment.
+// Lock is taken file by file, so writes are not stopped for the whole compaction
+func (s *LogStorage) compact(id uint32) error {
+	s.lock.Lock()
+	seg := s.segments[id]
+	oldest := true
+	for other := range s.segments {
+		oldest = oldest && other >= id
+	}
+	// bindings go first, so data appended below belongs to live files on replay
+	for name, at := range s.bindings {
+		if at != id {
+			continue
+		}
+		f, ok := s.files[name]
+		if !ok && oldest {
+			// there is no older segment where deleted file could be found, so deletion is not needed anymore
+			delete(s.bindings, name)
+			continue
+		}
+		e := &logEntry{kind: entryBind, name: name}
+		if ok {
+			e.id = f.id
+		}
+		if err := s.append(e); err != nil {
+			s.lock.Unlock()
+			return err
+		}
+	}
+	// written ranges are added by write entries, and data copied below does not add them, so ranges of files
+	// with data in the segment are saved before it
+	ids := make([]uint64, 0, len(s.byID))
+	for fileID, f := range s.byID {
+		if f.extentsAt == id || f.hasData(id) {
+			if err := s.append(extentsEntry(f.id, f.extents)); err != nil {
+				s.lock.Unlock()
+				return err
+			}
+		}
+		ids = append(ids, fileID)
+	}
+	s.lock.Unlock()
+
+	for _, fileID := range ids {
+		if err := s.moveData(seg, fileID); err != nil {
+			return err
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// copies should be durable before the segment is gone
+	if err := s.active.file.Sync(); err != nil {
+		return err
+	}
+	delete(s.segments, id)
+	seg.file.Close()
+	if err := os.Remove(segmentPath(s.dir, id)); err != nil {
+		return err
+	}
+	utils.Logger(utils.LogStorage).Debug("compacted segment", "segment", segmentPath(s.dir, id), "size", seg.size)
+	return nil
+}
+
+// moveData - appends data of the file which still lies in the segment to active segment
+func (s *LogStorage) moveData(seg *segment, fileID uint64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.byID[fileID]
+	if !ok {
+		return nil
+	}
+	var moved []logInterval
+	for _, iv := range f.intervals {
+		if iv.seg == seg.id {
+			moved = append(moved, iv)
+		}
+	}
+	for _, iv := range moved {
+		data := make([]byte, iv.end-iv.start)
+		if _, err := seg.file.ReadAt(data, iv.pos); err != nil {
+			return err
+		}
+		if err := s.append(&logEntry{kind: entryData, id: fileID, offset: iv.start, data: data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package peer
+
+import (
+	"testing"
+)
+
+// sealSegment - starts new segment of the storage, so the active one can be compacted
+func sealSegment(t *testing.T, s *LogStorage) uint32 {
+	t.Helper()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	id := s.active.id
+	if err := s.roll(); err != nil {
+		t.Fatalf("failed to roll segment: %v", err)
+	}
+	return id
+}
+
+func TestLogStorageCompactionKeepsRenamedFile(t *testing.T) {
+	// bindings of compacted segment are appended in random order, so the case is repeated to see all of them
+	for i := 0; i < 10; i++ {
+		dir := t.TempDir()
+		s, err := NewLogStorage(StorageOptions{Dir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = s.WriteAt("a", []byte("hello"), 0); err != nil {
+			t.Fatal(err)
+		}
+		sealSegment(t, s)
+
+		// new file takes the name of renamed one in the segment which is compacted
+		if err = s.Rename("a", "b"); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Create("a"); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.compact(sealSegment(t, s)); err != nil {
+			t.Fatalf("failed to compact segment: %v", err)
+		}
+		if err = s.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if s, err = NewLogStorage(StorageOptions{Dir: dir}); err != nil {
+			t.Fatal(err)
+		}
+		p := make([]byte, 5)
+		if err = s.ReadAt("b", p, 0); err != nil {
+			t.Fatalf("failed to read renamed file: %v", err)
+		}
+		if string(p) != "hello" {
+			t.Fatalf("renamed file has %q after compaction and reopen, want %q", p, "hello")
+		}
+		if info, err := s.Stat("a"); err != nil || info.Size != 0 {
+			t.Fatalf("new file is %+v, %v; want empty file", info, err)
+		}
+		s.Close()
+	}
+}
+
+func TestLogStorageReopenDropsDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLogStorage(StorageOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteAt("a", []byte("old"), 0); err != nil {
+		t.Fatal(err)
+	}
+	// file created again over the old one gets new data
+	if err = s.Create("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteAt("a", []byte("new"), 3); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteAt("c", []byte("gone"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Delete("c"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = NewLogStorage(StorageOptions{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	p := make([]byte, 6)
+	if err = s.ReadAt("a", p, 0); err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "\x00\x00\x00new" {
+		t.Fatalf("file created again has %q, want %q", p, "\x00\x00\x00new")
+	}
+	if exists, _ := s.Exists("c"); exists {
+		t.Fatal("deleted file exists after reopen")
+	}
+	if len(s.byID) != 1 {
+		t.Fatalf("%d files are kept after reopen, want 1", len(s.byID))
+	}
+	if live := s.segments[s.active.id].live; live != 3 {
+		t.Fatalf("live data of segment is %d bytes, want 3", live)
+	}
+}
@@ -0,0 +1,280 @@
+package peer
+
+import (
+	"encoding/gob"
+	"github.com/alikhil/distributed-fs/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// memoryPageSize - files of memory storage are kept in pages of this size. Pages which were never written
+// are not allocated, so holes take no memory as in sparse files
+const memoryPageSize = 4096
+
+// snapshotFile - name of the file in storage directory where memory storage saves its files
+const snapshotFile = "memory.snapshot"
+
+// MemoryStorage - keeps files in memory. Files are lost when peer stops unless snapshot is enabled
+type MemoryStorage struct {
+	lock     sync.RWMutex
+	files    map[string]*memoryFile
+	used     int64 // bytes taken by pages of all the files
+	limit    int64
+	snapshot string // path of snapshot; files are not saved if empty
+	dirty    bool   // files are changed after the last snapshot
+}
+
+type memoryFile struct {
+	Size    int64
+	Pages   map[int64][]byte // page index -> page
+	Extents []utils.Extent
+}
+
+// NewMemoryStorage - returns empty storage. If snapshot is enabled, files saved to opts.Dir are loaded
+func NewMemoryStorage(opts StorageOptions) (*MemoryStorage, error) {
+	s := &MemoryStorage{files: make(map[string]*memoryFile), limit: opts.MemoryLimit}
+	if !opts.Snapshot {
+		return s, nil
+	}
+	if err := os.MkdirAll(opts.Dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	s.snapshot = filepath.Join(opts.Dir, snapshotFile)
+	file, err := os.Open(s.snapshot)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if err = gob.NewDecoder(file).Decode(&s.files); err != nil {
+		return nil, utils.Errorf(utils.CodeUnknown, "corrupted snapshot %s: %v", s.snapshot, err)
+	}
+	for _, f := range s.files {
+		if f.Pages == nil {
+			// gob does not keep empty maps
+			f.Pages = make(map[int64][]byte)
+		}
+		s.used += int64(len(f.Pages)) * memoryPageSize
+	}
+	utils.Logger(utils.LogStorage).Info("loaded snapshot of memory storage", "snapshot", s.snapshot, "files", len(s.files), "bytes", s.used)
+	return s, nil
+}
+
+func (s *MemoryStorage) Create(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.remove(name)
+	s.files[name] = &memoryFile{Pages: make(map[int64][]byte)}
+	s.dirty = true
+	return nil
+}
+
+// remove - deletes the file and frees its pages. s.lock should be held
+func (s *MemoryStorage) remove(name string) bool {
+	f, ok := s.files[name]
+	if ok {
+		s.used -= int64(len(f.Pages)) * memoryPageSize
+		delete(s.files, name)
+		s.dirty = true
+	}
+	return ok
+}
+
+func (s *MemoryStorage) Delete(name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.remove(name) {
+		return notFound(name)
+	}
+	return nil
+}
+
+func (s *MemoryStorage) Exists(name string) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	_, ok := s.files[name]
+	return ok, nil
+}
+
+func (s *MemoryStorage) ReadAt(name string, p []byte, off int64) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	f, ok := s.files[name]
+	if !ok {
+		return notFound(name)
+	}
+	for done := 0; done < len(p); {
+		pos := off + int64(done)
+		index, pageOff := pos/memoryPageSize, int(pos%memoryPageSize)
+		n := memoryPageSize - pageOff
+		if n > len(p)-done {
+			n = len(p) - done
+		}
+		chunk := p[done : done+n]
+		// pages are zeroed when allocated, so holes and bytes after the end of the file are read as zeros
+		if page, ok := f.Pages[index]; ok {
+			copy(chunk, page[pageOff:])
+		} else {
+			for i := range chunk {
+				chunk[i] = 0
+			}
+		}
+		done += n
+	}
+	return nil
+}
+
+func (s *MemoryStorage) WriteAt(name string, p []byte, off int64) error {
+	if len(p) == 0 {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.files[name]
+	if !ok {
+		f = &memoryFile{Pages: make(map[int64][]byte)}
+	}
+
+	first, last := off/memoryPageSize, (off+int64(len(p))-1)/memoryPageSize
+	newPages := int64(0)
+	for index := first; index <= last; index++ {
+		if _, ok := f.Pages[index]; !ok {
+			newPages++
+		}
+	}
+	if s.limit > 0 && s.used+newPages*memoryPageSize > s.limit {
+		return utils.Errorf(utils.CodeNoSpace, "memory storage would take %d bytes with limit of %d", s.used+newPages*memoryPageSize, s.limit)
+	}
+	s.files[name] = f
+	s.used += newPages * memoryPageSize
+
+	for done := 0; done < len(p); {
+		pos := off + int64(done)
+		index, pageOff := pos/memoryPageSize, int(pos%memoryPageSize)
+		page, ok := f.Pages[index]
+		if !ok {
+			page = make([]byte, memoryPageSize)
+			f.Pages[index] = page
+		}
+		done += copy(page[pageOff:], p[done:])
+	}
+	end := off + int64(len(p))
+	if end > f.Size {
+		f.Size = end
+	}
+	f.Extents = extents(f.Extents).add(off, end)
+	s.dirty = true
+	return nil
+}
+
+func (s *MemoryStorage) Rename(name, newName string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.files[name]
+	if !ok {
+		return notFound(name)
+	}
+	s.remove(newName)
+	delete(s.files, name)
+	s.files[newName] = f
+	s.dirty = true
+	return nil
+}
+
+func (s *MemoryStorage) List(prefix string) ([]utils.FileInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	files := make([]utils.FileInfo, 0, len(s.files))
+	for name, f := range s.files {
+		if strings.HasPrefix(name, prefix) {
+			files = append(files, utils.FileInfo{Name: name, Size: f.Size})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (s *MemoryStorage) Stat(name string) (utils.FileInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	f, ok := s.files[name]
+	if !ok {
+		return utils.FileInfo{}, notFound(name)
+	}
+	return utils.FileInfo{Name: name, Size: f.Size}, nil
+}
+
+func (s *MemoryStorage) ReadExtents(name string) ([]utils.Extent, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if f, ok := s.files[name]; ok {
+		return append([]utils.Extent(nil), f.Extents...), nil
+	}
+	return nil, nil
+}
+
+func (s *MemoryStorage) WriteExtents(name string, ex []utils.Extent) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.files[name]
+	if !ok {
+		return notFound(name)
+	}
+	f.Extents = append([]utils.Extent(nil), ex...)
+	s.dirty = true
+	return nil
+}
+
+// Sync - saves snapshot of the files if it's enabled and files are changed after the last one
+func (s *MemoryStorage) Sync() error {
+	// writers wait while snapshot is written, so it's consistent
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.snapshot == "" || !s.dirty {
+		return nil
+	}
+
+	tmp := s.snapshot + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = gob.NewEncoder(file).Encode(s.files); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.snapshot)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	s.dirty = false
+	utils.Logger(utils.LogStorage).Info("saved snapshot of memory storage", "snapshot", s.snapshot, "files", len(s.files), "bytes", s.used)
+	return nil
+}
+
+// DiskUsage - returns memory taken by pages of the files
+func (s *MemoryStorage) DiskUsage() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.used
+}
+
+// Close - saves snapshot if it's enabled and frees the files
+func (s *MemoryStorage) Close() error {
+	err := s.Sync()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.files = make(map[string]*memoryFile)
+	s.used = 0
+	return err
+}
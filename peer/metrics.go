@@ -0,0 +1,52 @@
+package peer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics - metrics of data stored by peer. Nil metrics record nothing
+type Metrics struct {
+	fileBytesRead    *prometheus.CounterVec
+	fileBytesWritten *prometheus.CounterVec
+}
+
+func NewMetrics(reg prometheus.Registerer, storage Storage) *Metrics {
+	m := &Metrics{
+		fileBytesRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dfs_peer_file_read_bytes_total",
+			Help: "Bytes read from the file stored by peer.",
+		}, []string{"file"}),
+		fileBytesWritten: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dfs_peer_file_written_bytes_total",
+			Help: "Bytes written to the file stored by peer.",
+		}, []string{"file"}),
+	}
+	diskUsage := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "dfs_peer_disk_usage_bytes",
+		Help: "Bytes taken by files stored by the peer on disk or in memory, depending on backend.",
+	}, func() float64 {
+		return float64(storage.DiskUsage())
+	})
+	reg.MustRegister(m.fileBytesRead, m.fileBytesWritten, diskUsage)
+	return m
+}
+
+func (m *Metrics) fileRead(filename string, n int) {
+	if m != nil {
+		m.fileBytesRead.WithLabelValues(filename).Add(float64(n))
+	}
+}
+
+func (m *Metrics) fileWritten(filename string, n int) {
+	if m != nil {
+		m.fileBytesWritten.WithLabelValues(filename).Add(float64(n))
+	}
+}
+
+// fileRemoved - drops series of the file which is deleted or renamed, so names of gone files are not kept forever
+func (m *Metrics) fileRemoved(filename string) {
+	if m != nil {
+		m.fileBytesRead.DeleteLabelValues(filename)
+		m.fileBytesWritten.DeleteLabelValues(filename)
+	}
+}
@@ -0,0 +1,54 @@
+package peer
+
+import (
+	"github.com/alikhil/distributed-fs/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"testing"
+)
+
+// series - returns number of series collected from c
+func series(c prometheus.Collector) int {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	n := 0
+	for range ch {
+		n++
+	}
+	return n
+}
+
+func TestMetricsDropSeriesOfRemovedFiles(t *testing.T) {
+	fs, err := NewLocalFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.Metrics = NewMetrics(prometheus.NewRegistry(), fs.storage)
+	var ok bool
+	data := []byte("xxxx")
+	for _, name := range []string{"a", "b"} {
+		name := name
+		if err = fs.WriteBytes(&utils.IOWriteArgs{Filename: &name, Data: &data}, &ok); err != nil {
+			t.Fatal(err)
+		}
+		if err = fs.ReadBytes(&utils.IOReadArgs{Filename: &name, Count: 4}, &data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := series(fs.Metrics.fileBytesWritten) + series(fs.Metrics.fileBytesRead); n != 4 {
+		t.Fatalf("%d series are collected, want 4", n)
+	}
+
+	a, b, c := "a", "b", "c"
+	if err = fs.DeleteFile(&utils.IOFileArgs{Filename: &a}, &ok); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.RenameFile(&utils.IORenameArgs{Filename: &b, NewFilename: &c}, &ok); err != nil {
+		t.Fatal(err)
+	}
+	if n := series(fs.Metrics.fileBytesWritten) + series(fs.Metrics.fileBytesRead); n != 0 {
+		t.Fatalf("%d series of deleted and renamed files are left", n)
+	}
+}
@@ -0,0 +1,165 @@
+package peer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Segment is a file of log storage where entries are appended one after another. Entry is
+//
+//	crc32 of body (4 bytes) | length of body (4 bytes) | body
+//
+// and body starts with its kind and id of the file of dfs it belongs to. Ids are given to files on creation,
+// so entries of deleted files are left in segments, but never applied again
+
+type entryKind byte
+
+const (
+	// entryBind - name of the file points to id, or the file is deleted if id is 0. Body: kind | id | name
+	entryBind entryKind = iota + 1
+	// entryData - bytes of the file which do not change its written ranges, as copied by compaction. Body: kind | id | offset | data
+	entryData
+	// entryExtents - written ranges of the file replacing previous ones. Body: kind | id | ranges encoded by appendExtents
+	entryExtents
+	// entryRename - file moves to new name replacing file with it. Body: kind | id | length of name (2 bytes) | name | new name
+	entryRename
+	// entryWrite - bytes written to the file, which also add their range to written ranges of it. Body: as of entryData
+	entryWrite
+)
+
+const (
+	entryHeaderSize = 8
+	// dataHeaderSize - bytes of data entry before the data itself
+	dataHeaderSize = entryHeaderSize + 1 + 8 + 8
+	segmentPrefix  = "segment-"
+	segmentSuffix  = ".log"
+)
+
+// logEntry - decoded entry of segment
+type logEntry struct {
+	kind    entryKind
+	id      uint64
+	name    string
+	newName string
+	offset  int64
+	data    []byte
+	extents []utils.Extent
+}
+
+func encodeEntry(e *logEntry) []byte {
+	body := make([]byte, 0, 1+8+8+2+len(e.name)+len(e.newName)+len(e.data)+32*len(e.extents))
+	body = append(body, byte(e.kind))
+	body = binary.BigEndian.AppendUint64(body, e.id)
+	switch e.kind {
+	case entryBind:
+		body = append(body, e.name...)
+	case entryData, entryWrite:
+		body = binary.BigEndian.AppendUint64(body, uint64(e.offset))
+		body = append(body, e.data...)
+	case entryExtents:
+		body = appendExtents(body, e.extents)
+	case entryRename:
+		body = binary.BigEndian.AppendUint16(body, uint16(len(e.name)))
+		body = append(body, e.name...)
+		body = append(body, e.newName...)
+	}
+	buf := make([]byte, entryHeaderSize, entryHeaderSize+len(body))
+	binary.BigEndian.PutUint32(buf, crc32.ChecksumIEEE(body))
+	binary.BigEndian.PutUint32(buf[4:], uint32(len(body)))
+	return append(buf, body...)
+}
+
+// errTornEntry - entry is not fully written or damaged
+var errTornEntry = fmt.Errorf("torn entry")
+
+// readEntry - reads next entry of segment. Returns io.EOF at the end of segment and errTornEntry if entry is damaged
+func readEntry(r *bufio.Reader) (*logEntry, int64, error) {
+	header := make([]byte, entryHeaderSize)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if n == 0 && err == io.EOF {
+			return nil, 0, io.EOF
+		}
+		return nil, 0, errTornEntry
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	if length < 9 || length > 1<<31 {
+		return nil, 0, errTornEntry
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, 0, errTornEntry
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header) {
+		return nil, 0, errTornEntry
+	}
+
+	e := &logEntry{kind: entryKind(body[0]), id: binary.BigEndian.Uint64(body[1:])}
+	rest := body[9:]
+	switch e.kind {
+	case entryBind:
+		e.name = string(rest)
+	case entryData, entryWrite:
+		if len(rest) < 8 {
+			return nil, 0, errTornEntry
+		}
+		e.offset = int64(binary.BigEndian.Uint64(rest))
+		e.data = rest[8:]
+	case entryExtents:
+		var ok bool
+		if e.extents, ok = decodeExtents(rest); !ok {
+			return nil, 0, errTornEntry
+		}
+	case entryRename:
+		if len(rest) < 2 || len(rest) < 2+int(binary.BigEndian.Uint16(rest)) {
+			return nil, 0, errTornEntry
+		}
+		nameLen := int(binary.BigEndian.Uint16(rest))
+		e.name, e.newName = string(rest[2:2+nameLen]), string(rest[2+nameLen:])
+	default:
+		return nil, 0, errTornEntry
+	}
+	return e, int64(entryHeaderSize + length), nil
+}
+
+// segment - file of log storage
+type segment struct {
+	id   uint32
+	file *os.File
+	size int64 // bytes of entries in the file
+	live int64 // bytes of data entries which are not overwritten
+}
+
+func segmentPath(dir string, id uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%08d%s", segmentPrefix, id, segmentSuffix))
+}
+
+// listSegments - returns ids of segments in dir in order they were written
+func listSegments(dir string) ([]uint32, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint32
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint32(id))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
@@ -0,0 +1,82 @@
+package peer
+
+import (
+	"fmt"
+	"github.com/alikhil/distributed-fs/utils"
+	"sort"
+	"strings"
+)
+
+// Storage - keeps files of the peer. LocalFS checks names, tracks written ranges and serves rpc calls of master,
+// storage only keeps bytes of the files and their written ranges. Names given to storage are already checked
+type Storage interface {
+	// Create - creates empty file. Existing file is truncated and its written ranges are dropped
+	Create(name string) error
+	// Delete - removes the file and its written ranges. Fails with CodeNotFound if file does not exist
+	Delete(name string) error
+	Exists(name string) (bool, error)
+	// ReadAt - fills p with bytes of the file starting from off. Bytes after the end of the file are zeros.
+	// Fails with CodeNotFound if file does not exist
+	ReadAt(name string, p []byte, off int64) error
+	// WriteAt - writes p to the file at off and adds the range to written ranges of the file. The range is saved
+	// together with the data, so a crash does not lose it while keeping the data. File is created if it does not
+	// exist; gap after its end is left a hole
+	WriteAt(name string, p []byte, off int64) error
+	// Rename - moves the file and its written ranges to new name replacing file with that name
+	Rename(name, newName string) error
+	// List - returns files which names start with prefix sorted by name
+	List(prefix string) ([]utils.FileInfo, error)
+	// Stat - returns size of the file. Fails with CodeNotFound if file does not exist
+	Stat(name string) (utils.FileInfo, error)
+	// ReadExtents - returns written ranges of the file
+	ReadExtents(name string) ([]utils.Extent, error)
+	// WriteExtents - replaces written ranges of the file, so converted file keeps holes of the source
+	WriteExtents(name string, ex []utils.Extent) error
+	// Sync - makes all written data durable
+	Sync() error
+	// DiskUsage - returns bytes taken by stored data
+	DiskUsage() int64
+	Close() error
+}
+
+// StorageOptions - settings of storage. Every backend uses only settings it knows
+type StorageOptions struct {
+	Dir         string // directory of the storage
+	MemoryLimit int64  // bytes memory backend may take; writes which need more fail with CodeNoSpace. Unlimited if 0
+	Snapshot    bool   // memory backend saves files to Dir on sync and close and loads them on open
+	SegmentSize int64  // bytes after which log backend starts new segment; 64MB if 0
+}
+
+// OpenStorageFunc - opens storage of backend
+type OpenStorageFunc func(opts StorageOptions) (Storage, error)
+
+// backends - storages which peer can be started with; selected by -backend flag
+var backends = map[string]OpenStorageFunc{
+	"bolt":   func(opts StorageOptions) (Storage, error) { return NewBoltStorage(opts) },
+	"dir":    func(opts StorageOptions) (Storage, error) { return NewDirStorage(opts.Dir) },
+	"log":    func(opts StorageOptions) (Storage, error) { return NewLogStorage(opts) },
+	"memory": func(opts StorageOptions) (Storage, error) { return NewMemoryStorage(opts) },
+}
+
+// Backends - returns names of known storage backends
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OpenStorage - opens storage of backend with given name
+func OpenStorage(backend string, opts StorageOptions) (Storage, error) {
+	open, ok := backends[backend]
+	if !ok {
+		return nil, utils.Errorf(utils.CodeInvalidArgument, "unknown storage backend %q; use one of %s", backend, strings.Join(Backends(), ", "))
+	}
+	storage, err := open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s storage in %s: %w", backend, opts.Dir, err)
+	}
+	return storage, nil
+}
@@ -0,0 +1,104 @@
+package peer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeStriped - writes records of a file striped over peers which are kept by the first peer
+func writeStriped(t *testing.T, s Storage, name string, records int) []bool {
+	t.Helper()
+	const recordSize, peers = 8, 3
+	written := make([]bool, records*peers*recordSize)
+	for k := 0; k < records; k++ {
+		off := int64(k * peers * recordSize)
+		if err := s.WriteAt(name, []byte("12345678"), off); err != nil {
+			t.Fatal(err)
+		}
+		for i := off; i < off+recordSize; i++ {
+			written[i] = true
+		}
+	}
+	return written
+}
+
+func TestStoragesKeepExtentsWithData(t *testing.T) {
+	for _, backend := range Backends() {
+		t.Run(backend, func(t *testing.T) {
+			opts := StorageOptions{Dir: t.TempDir(), Snapshot: true}
+			s, err := OpenStorage(backend, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// more records than dir storage appends before it rewrites ranges
+			written := writeStriped(t, s, "a", 3*appendedExtentsLimit)
+			// ranges are not saved by WriteExtents, so they are kept only if WriteAt saved them
+			if err = s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if s, err = OpenStorage(backend, opts); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			ex, err := s.ReadExtents("a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ex) != 1 {
+				t.Fatalf("striped records are kept as %d entries, want one run", len(ex))
+			}
+			checkCovers(t, ex, written)
+		})
+	}
+}
+
+func TestDirStorageKeepsUntrackedFileWritten(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDirStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// file written before ranges were tracked has no file of ranges
+	if err = os.WriteFile(filepath.Join(dir, "a"), []byte("0123"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteAt("a", []byte("89"), 8); err != nil {
+		t.Fatal(err)
+	}
+	ex, err := s.ReadExtents("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCovers(t, ex, []bool{true, true, true, true, false, false, false, false, true, true})
+}
+
+func TestLogStorageCompactionKeepsExtents(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLogStorage(StorageOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := writeStriped(t, s, "a", 10)
+	if err = s.WriteAt("b", []byte("x"), 0); err != nil {
+		t.Fatal(err)
+	}
+	// data copied by compaction does not mark ranges by itself, so ranges are saved before it
+	if err = s.compact(sealSegment(t, s)); err != nil {
+		t.Fatalf("failed to compact segment: %v", err)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err = NewLogStorage(StorageOptions{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ex, err := s.ReadExtents("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCovers(t, ex, written)
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Admin api of master is served over http as json, so it's described here to be shared by master and its clients
+
+// ClusterStatus - state of the cluster returned by GET /api/cluster of master admin api
+type ClusterStatus struct {
+	ReadyToUse  bool         `json:"ready_to_use"`
+	PeersCount  int          `json:"peers_count"`
+	Consistency string       `json:"consistency"`
+	Peers       []PeerStatus `json:"peers"`
+}
+
+// PeerStatus - state of one peer as it's seen by master. Slot is index of the peer which owns records with id % peers_count == slot
+type PeerStatus struct {
+	Slot           int        `json:"slot"`
+	Endpoint       string     `json:"endpoint"`
+	Status         string     `json:"status"` // unknown, connected, disconnected or removed
+	Draining       bool       `json:"draining"`
+	LastPing       *time.Time `json:"last_ping,omitempty"` // time of the last successful ping
+	LatencySeconds float64    `json:"latency_seconds"`     // duration of the last successful ping
+	Files          int        `json:"files"`
+	DiskUsageBytes int64      `json:"disk_usage_bytes"`
+	Error          string     `json:"error,omitempty"` // why files and disk usage are unknown
+}
+
+// AdminClient - client of admin api of master
+type AdminClient struct {
+	URL    string       // base url of admin api, e.g. http://10.91.41.109:5080
+	Token  string       // sent as bearer token if authentication is enabled in master
+	Client *http.Client // http.DefaultClient is used if nil
+}
+
+// ClusterStatus - returns state of the cluster
+func (admin *AdminClient) ClusterStatus() (*ClusterStatus, error) {
+	return admin.call(http.MethodGet, "/api/cluster", nil)
+}
+
+// DrainPeer - stops changes of records owned by the peer. Returns new state of the cluster
+func (admin *AdminClient) DrainPeer(endpoint string) (*ClusterStatus, error) {
+	return admin.call(http.MethodPost, "/api/peers/drain", url.Values{"peer": {endpoint}})
+}
+
+// UndrainPeer - allows changes of records owned by drained peer again. Returns new state of the cluster
+func (admin *AdminClient) UndrainPeer(endpoint string) (*ClusterStatus, error) {
+	return admin.call(http.MethodPost, "/api/peers/undrain", url.Values{"peer": {endpoint}})
+}
+
+// RemovePeer - stops drained peer and frees its slot for a new peer. Returns new state of the cluster
+func (admin *AdminClient) RemovePeer(endpoint string) (*ClusterStatus, error) {
+	return admin.call(http.MethodPost, "/api/peers/remove", url.Values{"peer": {endpoint}})
+}
+
+func (admin *AdminClient) call(method, path string, query url.Values) (*ClusterStatus, error) {
+	target := strings.TrimSuffix(admin.URL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return nil, Errorf(CodeInvalidArgument, "invalid admin url: %v", err)
+	}
+	if admin.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+admin.Token)
+	}
+
+	client := admin.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &Error{Code: CodeUnavailable, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// master answers with error encoded as in rpc
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, parseError(strings.TrimSpace(string(body)))
+	}
+	var status ClusterStatus
+	if err = json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, &Error{Code: CodeUnavailable, Err: err}
+	}
+	return &status, nil
+}
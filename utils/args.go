@@ -1,10 +1,35 @@
 package utils
 
+import (
+	"time"
+)
+
+// CallContext - data about the call passed by client along with arguments
+type CallContext struct {
+	Token     string            // signed token of the client, see NewToken
+	Trace     map[string]string // trace context of the caller, see SetContext
+	RequestID string            // id of the request which is logged by all the nodes serving it
+}
+
+// IOFileArgs - represents structure which passed via rpc
+type IOFileArgs struct {
+	Filename *string
+	CallContext
+}
+
+// IOMappingsArgs - represents structure which passed via rpc
+type IOMappingsArgs struct {
+	Mappings *map[string]int32
+	CallContext
+}
+
 // IOReadArgs - represents structure which passed via rpc
 type IOReadArgs struct {
-	Filename *string
-	Offset   int32
-	Count    int32
+	Filename   *string
+	Offset     int32
+	Count      int32
+	FailOnHole bool // if true reading never written bytes fails with ErrNotWritten
+	CallContext
 }
 
 // IOWriteArgs - represents structure which passed via rpc
@@ -12,4 +37,148 @@ type IOWriteArgs struct {
 	Filename *string
 	Offset   int32
 	Data     *[]byte
+	CallContext
+}
+
+// IOAppendArgs - represents structure which passed via rpc
+type IOAppendArgs struct {
+	Filename *string
+	Data     *[]byte
+	CallContext
+}
+
+// IOAppendResult - position assigned by master to appended records
+type IOAppendResult struct {
+	FirstID int32
+	Offset  int32
+}
+
+// IORecordsArgs - represents structure which passed via rpc
+type IORecordsArgs struct {
+	Filename   *string
+	IDs        *[]int64
+	FailOnHole bool
+	CallContext
+}
+
+// IOPeerRecordsArgs - records of the file kept by one peer which are read or written directly in one call.
+// Records are given by their offsets in the file
+type IOPeerRecordsArgs struct {
+	Filename   *string
+	Offsets    []int32
+	RecordSize int32
+	Data       *[]byte // records to write one after another in the order of offsets; nil for reads
+	FailOnHole bool
+	CallContext
+}
+
+// IOSeekArgs - represents structure which passed via rpc
+type IOSeekArgs struct {
+	Filename *string
+	ID       int64
+	CallContext
+}
+
+// IOListArgs - represents structure which passed via rpc
+type IOListArgs struct {
+	Prefix string // only files which names start with it are listed
+	CallContext
+}
+
+// IORecordSizeArgs - represents structure which passed via rpc
+type IORecordSizeArgs struct {
+	Filename   *string
+	RecordSize int32
+	CallContext
+}
+
+// IORenameArgs - represents structure which passed via rpc
+type IORenameArgs struct {
+	Filename    *string
+	NewFilename *string
+	CallContext
+}
+
+// FileInfo - name and size of a file. Record size is 0 if master does not know it
+type FileInfo struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	RecordSize int32  `json:"record_size"`
+}
+
+// Extent - range of bytes [Start, End) of a file stored in peer. Storage of peer keeps ranges of records striped
+// over peers as one run: Count ranges of the same length, each Stride bytes after the previous one. Peer returns
+// runs to master as plain ranges, which have Count 0
+type Extent struct {
+	Start  int64
+	End    int64
+	Stride int64
+	Count  int64
+}
+
+// RecordRange - range of record ids [From, To] of a file
+type RecordRange struct {
+	From int64
+	To   int64
+}
+
+// IOPlacementArgs - represents structure which passed via rpc
+type IOPlacementArgs struct {
+	Filename *string
+	Write    bool // placement is requested to write records, not only read them
+	CallContext
+}
+
+// Placement - where records of the file are kept. Record with id is kept by peer Peers[id % len(Peers)]
+// at the same offset as in the file. Tokens[id % len(Peers)] gives access to the file on that peer untill
+// ExpiresAt or untill placement of a newer epoch is given
+type Placement struct {
+	Filename   string
+	RecordSize int32
+	Peers      []string // endpoints where peers serve clients directly
+	Tokens     []string // access tokens, one for each peer, since each peer has its own key
+	ExpiresAt  time.Time
+	Epoch      uint64
+}
+
+// PeerJoinArgs - represents structure which passed via rpc
+type PeerJoinArgs struct {
+	Endpoint     *string
+	JoinToken    string // secret of the cluster which allows to join it as a peer
+	DataEndpoint string // where peer serves clients directly; direct access is disabled if empty
+
+	callerRole string // role of TLS certificate of the caller; set by rpc server, not sent
+}
+
+func (args *PeerJoinArgs) setCallerRole(role string) {
+	args.callerRole = role
+}
+
+// CallerRole - returns role of TLS certificate of the node which sent args; empty if it's not connected over TLS
+func (args *PeerJoinArgs) CallerRole() string {
+	return args.callerRole
+}
+
+// PeerJoinResult - answer of master to joined peer
+type PeerJoinResult struct {
+	ControlKey []byte // secret used by master to sign control calls to the peer
+	AccessKey  []byte // secret used by master to sign access tokens to records kept by the peer
+	Epoch      uint64 // current epoch of placement
+}
+
+// ControlArgs - represents structure which passed via rpc
+type ControlArgs struct {
+	Token string // token of master signed by control key
+}
+
+// EpochArgs - represents structure which passed via rpc
+type EpochArgs struct {
+	Epoch uint64 // access tokens given with placement of an older epoch are rejected
+	ControlArgs
+}
+
+// PeerStats - usage of storage of the peer, returned to master
+type PeerStats struct {
+	Files     int   // number of stored files
+	DiskUsage int64 // disk space taken by files of the peer
 }
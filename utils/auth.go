@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Tokens are JWTs signed with HS256 by secret shared between master and whoever issues tokens,
+// so master verifies them offline
+
+// TokenClaims - claims of the client token
+type TokenClaims struct {
+	Subject    string `json:"sub"`
+	IssuedAt   int64  `json:"iat"`
+	ExpiresAt  int64  `json:"exp,omitempty"`
+	File       string `json:"file,omitempty"`        // file which access token is given for
+	Access     string `json:"access,omitempty"`      // AccessRead or AccessWrite; set only in access tokens
+	Epoch      uint64 `json:"epoch,omitempty"`       // epoch of placement which access token is given with
+	RecordSize int32  `json:"record_size,omitempty"` // record size of the file in placement which access token is given with
+}
+
+// Kinds of access to records of a file on peers granted by access token
+const (
+	AccessRead  = "read"
+	AccessWrite = "write" // allows reading too
+)
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func signToken(secret []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewToken - issues token for subject. Token never expires if ttl is 0
+func NewToken(secret []byte, subject string, ttl time.Duration) (string, error) {
+	return newToken(secret, TokenClaims{Subject: subject}, ttl)
+}
+
+// NewAccessToken - issues token which lets subject access records of recordSize bytes of the file on peers directly
+// while placement of epoch is actual. Master signs it by the key it gives to peers on join
+func NewAccessToken(secret []byte, subject, file, access string, recordSize int32, epoch uint64, ttl time.Duration) (string, error) {
+	return newToken(secret, TokenClaims{Subject: subject, File: file, Access: access, RecordSize: recordSize, Epoch: epoch}, ttl)
+}
+
+func newToken(secret []byte, claims TokenClaims, ttl time.Duration) (string, error) {
+	claims.IssuedAt = time.Now().Unix()
+	if ttl > 0 {
+		claims.ExpiresAt = time.Now().Add(ttl).Unix()
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + signToken(secret, unsigned), nil
+}
+
+// VerifyToken - checks signature and expiration of the token and returns its claims
+func VerifyToken(secret []byte, token string) (*TokenClaims, error) {
+	if token == "" {
+		return nil, NewError(CodeUnauthenticated, "token is missing")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, NewError(CodeUnauthenticated, "malformed token")
+	}
+	expected := signToken(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return nil, NewError(CodeUnauthenticated, "invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, NewError(CodeUnauthenticated, "malformed token")
+	}
+	var claims TokenClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, NewError(CodeUnauthenticated, "malformed token")
+	}
+	if claims.ExpiresAt != 0 && time.Now().Unix() > claims.ExpiresAt {
+		return nil, NewError(CodeUnauthenticated, "token is expired")
+	}
+	if claims.Subject == "" {
+		return nil, NewError(CodeUnauthenticated, "token has no subject")
+	}
+	return &claims, nil
+}
+
+// VerifyAccessToken - checks that token is valid access token to the file which allows access
+func VerifyAccessToken(secret []byte, token, file, access string) (*TokenClaims, error) {
+	claims, err := VerifyToken(secret, token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.File == "" || claims.File != file {
+		return nil, Errorf(CodePermissionDenied, "token does not give access to file(%s)", file)
+	}
+	if claims.Access != access && claims.Access != AccessWrite {
+		return nil, Errorf(CodePermissionDenied, "token does not give %s access to file(%s)", access, file)
+	}
+	return claims, nil
+}
+
+// ReadSecretFile - reads secret from file ignoring surrounding white spaces
+func ReadSecretFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret in %s is empty", path)
+	}
+	return secret, nil
+}
@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"context"
+	"crypto/tls"
 	"net/rpc"
+	"sync"
 )
 
 // DFSClient is used in TBMS project, so it's left for backward compatability.
@@ -13,18 +16,18 @@ type DFSClient struct {
 
 func (dfs *DFSClient) InitRecordMappings(mp *map[string]int32) error {
 	ok := false
-	return dfs.Client.Call("RemoteIO.InitRecordMappings", mp, &ok)
+	return dfs.Client.Call("RemoteIO.InitRecordMappings", &IOMappingsArgs{Mappings: mp}, &ok)
 }
 
 func (dfs *DFSClient) FileExists(fname string) bool {
 	ok := false
-	err := dfs.Client.Call("RemoteIO.FileExists", &fname, &ok)
+	err := dfs.Client.Call("RemoteIO.FileExists", &IOFileArgs{Filename: &fname}, &ok)
 	return ok && err == nil
 }
 
 func (dfs *DFSClient) DeleteFile(fname string) bool {
 	ok := false
-	return dfs.Client.Call("RemoteIO.DeleteFile", &fname, &ok) == nil && ok
+	return dfs.Client.Call("RemoteIO.DeleteFile", &IOFileArgs{Filename: &fname}, &ok) == nil && ok
 }
 
 func (dfs *DFSClient) ReadBytes(fname string, offset, count int32) ([]byte, bool) {
@@ -41,41 +44,161 @@ func (dfs *DFSClient) WriteBytes(fname string, offset int32, data *[]byte) bool
 
 func (dfs *DFSClient) CreateFile(fname string) bool {
 	ok := false
-	return dfs.Client.Call("RemoteIO.CreateFile", &fname, &ok) == nil && ok
+	return dfs.Client.Call("RemoteIO.CreateFile", &IOFileArgs{Filename: &fname}, &ok) == nil && ok
 }
 
 type RemoteDFS struct {
 	Client *rpc.Client
+	// FailOnHoles - if true reading of never written records fails with ErrNotWritten instead of returning zeros
+	FailOnHoles bool
+	// Token - sent with every call to authenticate client, see NewToken
+	Token string
+	// Context - trace and request id of it are continued by every call, so calls are seen as parts of caller's trace.
+	// If request id is not set in Context, every call gets the new one
+	Context context.Context
+	// Direct - if true records are read and written on peers directly, master only gives their placement.
+	// Placements are cached untill peers reject them as stale. Peers should be started with -data-port
+	Direct bool
+	// PeerTLS - used to connect to peers directly if not nil
+	PeerTLS *tls.Config
+
+	recordSizes     map[string]int32
+	recordSizesLock sync.Mutex
+
+	peers     map[string]*rpc.Client // connections to peers used in direct mode by endpoint
+	peersLock sync.Mutex
+
+	placements     map[placementKey]*Placement // placements used in direct mode
+	epoch          uint64                      // the latest epoch of placement given by master
+	placementsLock sync.Mutex
 }
 
+func (dfs *RemoteDFS) callContext() CallContext {
+	call := CallContext{Token: dfs.Token}
+	if dfs.Context != nil {
+		call.SetContext(dfs.Context)
+	}
+	if call.RequestID == "" {
+		call.RequestID = NewRequestID()
+	}
+	return call
+}
+
+// InitRecordMappings - sets record sizes of the files. Record sizes of other files are kept
 func (dfs *RemoteDFS) InitRecordMappings(mp *map[string]int32) error {
 	ok := false
-	return dfs.Client.Call("RemoteIO.InitRecordMappings", mp, &ok)
+	err := DecodeError(dfs.Client.Call("RemoteIO.InitRecordMappings", &IOMappingsArgs{Mappings: mp, CallContext: dfs.callContext()}, &ok))
+	if err == nil {
+		dfs.recordSizesLock.Lock()
+		if dfs.recordSizes == nil {
+			dfs.recordSizes = make(map[string]int32, len(*mp))
+		}
+		for fname, size := range *mp {
+			dfs.recordSizes[fname] = size
+		}
+		dfs.recordSizesLock.Unlock()
+		for fname := range *mp {
+			dfs.forgetPlacement(fname)
+		}
+	}
+	return err
 }
 
 func (dfs *RemoteDFS) FileExists(fname string) error {
 	ok := false
-	return dfs.Client.Call("RemoteIO.FileExists", &fname, &ok)
+	return DecodeError(dfs.Client.Call("RemoteIO.FileExists", &IOFileArgs{Filename: &fname, CallContext: dfs.callContext()}, &ok))
 }
 
 func (dfs *RemoteDFS) DeleteFile(fname string) error {
 	ok := false
-	return dfs.Client.Call("RemoteIO.DeleteFile", &fname, &ok)
+	return DecodeError(dfs.Client.Call("RemoteIO.DeleteFile", &IOFileArgs{Filename: &fname, CallContext: dfs.callContext()}, &ok))
 }
 
 func (dfs *RemoteDFS) ReadBytes(fname string, offset, count int32) ([]byte, error) {
+	if dfs.Direct {
+		return dfs.directReadBytes(fname, offset, count)
+	}
 	data := make([]byte, count, count)
 
-	err := dfs.Client.Call("RemoteIO.ReadBytes", &IOReadArgs{Offset: offset, Count: count, Filename: &fname}, &data)
-	return data, err
+	err := dfs.Client.Call("RemoteIO.ReadBytes", &IOReadArgs{Offset: offset, Count: count, Filename: &fname, FailOnHole: dfs.FailOnHoles, CallContext: dfs.callContext()}, &data)
+	return data, DecodeError(err)
 }
 
 func (dfs *RemoteDFS) WriteBytes(fname string, offset int32, data *[]byte) error {
+	if dfs.Direct {
+		return dfs.directWriteBytes(fname, offset, data)
+	}
 	ok := false
-	return dfs.Client.Call("RemoteIO.WriteBytes", &IOWriteArgs{Offset: offset, Data: data, Filename: &fname}, &ok)
+	return DecodeError(dfs.Client.Call("RemoteIO.WriteBytes", &IOWriteArgs{Offset: offset, Data: data, Filename: &fname, CallContext: dfs.callContext()}, &ok))
 }
 
 func (dfs *RemoteDFS) CreateFile(fname string) error {
 	ok := false
-	return dfs.Client.Call("RemoteIO.CreateFile", &fname, &ok)
+	return DecodeError(dfs.Client.Call("RemoteIO.CreateFile", &IOFileArgs{Filename: &fname, CallContext: dfs.callContext()}, &ok))
+}
+
+// AppendRecords - writes data right after the last record of the file.
+// Returns id of the first appended record and its offset.
+func (dfs *RemoteDFS) AppendRecords(fname string, data *[]byte) (firstID, offset int32, err error) {
+	var res IOAppendResult
+	err = dfs.Client.Call("RemoteIO.AppendRecords", &IOAppendArgs{Data: data, Filename: &fname, CallContext: dfs.callContext()}, &res)
+	return res.FirstID, res.Offset, DecodeError(err)
+}
+
+// ListFiles - returns files which names start with prefix sorted by name
+func (dfs *RemoteDFS) ListFiles(prefix string) ([]FileInfo, error) {
+	var files []FileInfo
+	err := dfs.Client.Call("RemoteIO.ListFiles", &IOListArgs{Prefix: prefix, CallContext: dfs.callContext()}, &files)
+	return files, DecodeError(err)
+}
+
+// StatFile - returns size and record size of the file. Cached record size of the file is updated by it
+func (dfs *RemoteDFS) StatFile(fname string) (FileInfo, error) {
+	var info FileInfo
+	err := DecodeError(dfs.Client.Call("RemoteIO.StatFile", &IOFileArgs{Filename: &fname, CallContext: dfs.callContext()}, &info))
+	if err != nil || info.RecordSize <= 0 {
+		dfs.forgetRecordSize(fname)
+		return info, err
+	}
+	// record size given with stat is the current one, so it replaces cached size
+	dfs.recordSizesLock.Lock()
+	if dfs.recordSizes == nil {
+		dfs.recordSizes = make(map[string]int32)
+	}
+	dfs.recordSizes[fname] = info.RecordSize
+	dfs.recordSizesLock.Unlock()
+	return info, nil
+}
+
+// SetRecordSize - sets record size of the file without changing record sizes of other files
+func (dfs *RemoteDFS) SetRecordSize(fname string, size int32) error {
+	ok := false
+	err := DecodeError(dfs.Client.Call("RemoteIO.SetRecordSize", &IORecordSizeArgs{Filename: &fname, RecordSize: size, CallContext: dfs.callContext()}, &ok))
+	if err == nil {
+		dfs.recordSizesLock.Lock()
+		if dfs.recordSizes == nil {
+			dfs.recordSizes = make(map[string]int32)
+		}
+		dfs.recordSizes[fname] = size
+		dfs.recordSizesLock.Unlock()
+		dfs.forgetPlacement(fname)
+	}
+	return err
+}
+
+// RenameFile - gives the file new name. Fails with ErrAlreadyExists if file with new name exists
+func (dfs *RemoteDFS) RenameFile(fname, newName string) error {
+	ok := false
+	err := DecodeError(dfs.Client.Call("RemoteIO.RenameFile", &IORenameArgs{Filename: &fname, NewFilename: &newName, CallContext: dfs.callContext()}, &ok))
+	if err == nil {
+		dfs.recordSizesLock.Lock()
+		if size, found := dfs.recordSizes[fname]; found {
+			dfs.recordSizes[newName] = size
+			delete(dfs.recordSizes, fname)
+		}
+		dfs.recordSizesLock.Unlock()
+		dfs.forgetPlacement(fname)
+		dfs.forgetPlacement(newName)
+	}
+	return err
 }
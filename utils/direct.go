@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"math"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// placementExpiryMargin - cached placement is asked again when its token expires sooner,
+// so token is not expired on peers which clocks are ahead
+const placementExpiryMargin = 2 * time.Second
+
+// MaxPeerCallBytes - most bytes read or written by one call to a peer, unless one record is bigger.
+// Records of a peer which do not fit are read or written by several calls
+const MaxPeerCallBytes = 64 << 20
+
+// placementKey - placements to read and to write the file are cached separately
+type placementKey struct {
+	filename string
+	write    bool
+}
+
+// Placement - asks master which peers keep records of the file and for token to read them, or to write them
+// if write is set, on peers directly
+func (dfs *RemoteDFS) Placement(fname string, write bool) (*Placement, error) {
+	var placement Placement
+	err := dfs.Client.Call("RemoteIO.Placement", &IOPlacementArgs{Filename: &fname, Write: write, CallContext: dfs.callContext()}, &placement)
+	if err != nil {
+		return nil, DecodeError(err)
+	}
+	if len(placement.Peers) == 0 || len(placement.Tokens) != len(placement.Peers) || placement.RecordSize <= 0 {
+		return nil, Errorf(CodeUnknown, "master returned invalid placement of file(%s)", fname)
+	}
+	return &placement, nil
+}
+
+// cachedPlacement - returns placement of the file cached before unless it's stale or about to expire.
+// Placement to write the file is used to read it too
+func (dfs *RemoteDFS) cachedPlacement(fname string, write bool) (*Placement, error) {
+	deadline := time.Now().Add(placementExpiryMargin)
+	dfs.placementsLock.Lock()
+	for _, key := range []placementKey{{fname, write}, {fname, true}} {
+		if p, ok := dfs.placements[key]; ok && p.Epoch >= dfs.epoch && p.ExpiresAt.After(deadline) {
+			dfs.placementsLock.Unlock()
+			return p, nil
+		}
+	}
+	dfs.placementsLock.Unlock()
+
+	p, err := dfs.Placement(fname, write)
+	if err != nil {
+		return nil, err
+	}
+	dfs.placementsLock.Lock()
+	defer dfs.placementsLock.Unlock()
+	if p.Epoch > dfs.epoch {
+		dfs.epoch = p.Epoch
+	}
+	if dfs.placements == nil {
+		dfs.placements = make(map[placementKey]*Placement)
+	}
+	dfs.placements[placementKey{fname, write}] = p
+	return p, nil
+}
+
+// forgetPlacement - drops cached placements of the file, so they are asked from master on next call
+func (dfs *RemoteDFS) forgetPlacement(fname string) {
+	dfs.placementsLock.Lock()
+	defer dfs.placementsLock.Unlock()
+	delete(dfs.placements, placementKey{fname, false})
+	delete(dfs.placements, placementKey{fname, true})
+}
+
+// withPlacement - calls peers with cached placement of the file. If peers reject it as stale or expired, or peer
+// is unavailable and may be replaced, placement is asked from master again and call is retried once.
+// Calls should be safe to repeat
+func (dfs *RemoteDFS) withPlacement(fname string, write bool, call func(p *Placement) error) error {
+	p, err := dfs.cachedPlacement(fname, write)
+	if err != nil {
+		return err
+	}
+	err = call(p)
+	switch CodeOf(err) {
+	case CodeStaleEpoch, CodeUnauthenticated, CodePeerUnavailable:
+	default:
+		return err
+	}
+	dfs.forgetPlacement(fname)
+	if p, err = dfs.cachedPlacement(fname, write); err != nil {
+		return err
+	}
+	return call(p)
+}
+
+// PeerOf - returns endpoint of the peer which keeps record with id
+func (p *Placement) PeerOf(id int64) string {
+	return p.Peers[id%int64(len(p.Peers))]
+}
+
+// TokenOf - returns access token to the peer which keeps record with id
+func (p *Placement) TokenOf(id int64) string {
+	return p.Tokens[id%int64(len(p.Tokens))]
+}
+
+// Close - closes connections to master and to peers
+func (dfs *RemoteDFS) Close() error {
+	dfs.peersLock.Lock()
+	for endpoint, client := range dfs.peers {
+		client.Close()
+		delete(dfs.peers, endpoint)
+	}
+	dfs.peersLock.Unlock()
+	return dfs.Client.Close()
+}
+
+// peer - returns connection to the peer. Connections are kept, so every peer is dialed once
+func (dfs *RemoteDFS) peer(endpoint string) (*rpc.Client, error) {
+	dfs.peersLock.Lock()
+	defer dfs.peersLock.Unlock()
+	if client, ok := dfs.peers[endpoint]; ok {
+		return client, nil
+	}
+	client, ok := GetRemoteClientTLS(endpoint, dfs.PeerTLS)
+	if !ok {
+		return nil, Errorf(CodePeerUnavailable, "failed to connect to peer(%s)", endpoint)
+	}
+	if dfs.peers == nil {
+		dfs.peers = make(map[string]*rpc.Client)
+	}
+	dfs.peers[endpoint] = client
+	return client, nil
+}
+
+// callPeer - calls data service of the peer. Broken connection is dropped, so the peer is dialed again next time
+func (dfs *RemoteDFS) callPeer(endpoint, method string, args, reply interface{}) error {
+	client, err := dfs.peer(endpoint)
+	if err != nil {
+		return err
+	}
+	err = client.Call("PeerData."+method, args, reply)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(rpc.ServerError); ok {
+		return DecodeError(err)
+	}
+	dfs.peersLock.Lock()
+	if dfs.peers[endpoint] == client {
+		delete(dfs.peers, endpoint)
+	}
+	dfs.peersLock.Unlock()
+	client.Close()
+	return &Error{Code: CodePeerUnavailable, Err: err}
+}
+
+// peerCallContext - context of the call to peer which keeps record with id; it carries access token to this peer
+// instead of token of the client
+func (dfs *RemoteDFS) peerCallContext(p *Placement, id int64) CallContext {
+	call := dfs.callContext()
+	call.Token = p.TokenOf(id)
+	return call
+}
+
+// peerBatch - records of the call of client which are kept by one peer
+type peerBatch struct {
+	slot    int64   // index of the peer in placement
+	indexes []int   // positions of the records in the call of client
+	offsets []int32 // offsets of the records in the file
+}
+
+// batchByPeer - groups records with ids by peers which keep them, at most MaxPeerCallBytes in a batch.
+// Ids should be checked to fit int32 offsets
+func batchByPeer(p *Placement, ids []int64) []*peerBatch {
+	maxRecords := max(1, MaxPeerCallBytes/int(p.RecordSize))
+	slots := make([]*peerBatch, len(p.Peers))
+	var batches []*peerBatch
+	for i, id := range ids {
+		slot := id % int64(len(p.Peers))
+		if slots[slot] == nil || len(slots[slot].offsets) == maxRecords {
+			slots[slot] = &peerBatch{slot: slot}
+			batches = append(batches, slots[slot])
+		}
+		slots[slot].indexes = append(slots[slot].indexes, i)
+		slots[slot].offsets = append(slots[slot].offsets, int32(id-1)*p.RecordSize)
+	}
+	return batches
+}
+
+// callBatches - calls peers of all the batches concurrently and waits untill all of them answer.
+// Returns error of the first failed batch
+func callBatches(batches []*peerBatch, call func(b *peerBatch) error) error {
+	if len(batches) == 1 {
+		return call(batches[0])
+	}
+	errs := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, b := range batches {
+		wg.Add(1)
+		go func(i int, b *peerBatch) {
+			defer wg.Done()
+			errs[i] = call(b)
+		}(i, b)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// directRead - reads records with ids from peers which keep them. Records of every peer are read in one call
+func (dfs *RemoteDFS) directRead(p *Placement, ids []int64) ([][]byte, error) {
+	records := make([][]byte, len(ids))
+	err := callBatches(batchByPeer(p, ids), func(b *peerBatch) error {
+		var data []byte
+		args := &IOPeerRecordsArgs{Filename: &p.Filename, Offsets: b.offsets, RecordSize: p.RecordSize, FailOnHole: dfs.FailOnHoles, CallContext: dfs.peerCallContext(p, b.slot)}
+		if err := dfs.callPeer(p.PeerOf(b.slot), "ReadRecords", args, &data); err != nil {
+			return err
+		}
+		if len(data) != len(b.indexes)*int(p.RecordSize) {
+			return ErrRecordSizeMismatch
+		}
+		for i, index := range b.indexes {
+			records[index] = data[i*int(p.RecordSize) : (i+1)*int(p.RecordSize)]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// recordIDs - returns ids of the records of count bytes starting from offset
+func recordIDs(offset, count, recordSize int32) []int64 {
+	ids := make([]int64, count/recordSize)
+	for i := range ids {
+		ids[i] = int64(offset/recordSize) + 1 + int64(i)
+	}
+	return ids
+}
+
+// directReadBytes - reads records in the same way as master does, but from peers directly
+func (dfs *RemoteDFS) directReadBytes(fname string, offset, count int32) (data []byte, err error) {
+	err = dfs.withPlacement(fname, false, func(p *Placement) error {
+		records, err := dfs.directRead(p, recordIDs(offset, count, p.RecordSize))
+		if err != nil {
+			return err
+		}
+		data = make([]byte, 0, count)
+		for _, record := range records {
+			data = append(data, record...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// directWriteBytes - writes records in the same way as master does, but to peers directly.
+// Records of every peer are written in one call
+func (dfs *RemoteDFS) directWriteBytes(fname string, offset int32, data *[]byte) error {
+	return dfs.withPlacement(fname, true, func(p *Placement) error {
+		if offset%p.RecordSize != 0 || int32(len(*data))%p.RecordSize != 0 {
+			return Errorf(CodeInvalidArgument, "%d bytes at offset %d are not whole records of %d bytes of file(%s)", len(*data), offset, p.RecordSize, fname)
+		}
+		return callBatches(batchByPeer(p, recordIDs(offset, int32(len(*data)), p.RecordSize)), func(b *peerBatch) error {
+			records := make([]byte, 0, len(b.indexes)*int(p.RecordSize))
+			for _, index := range b.indexes {
+				records = append(records, (*data)[int32(index)*p.RecordSize:int32(index+1)*p.RecordSize]...)
+			}
+			args := &IOPeerRecordsArgs{Filename: &p.Filename, Offsets: b.offsets, RecordSize: p.RecordSize, Data: &records, CallContext: dfs.peerCallContext(p, b.slot)}
+			ok := false
+			return dfs.callPeer(p.PeerOf(b.slot), "WriteRecords", args, &ok)
+		})
+	})
+}
+
+// directReadRecords - reads records with given ids from peers directly
+func (dfs *RemoteDFS) directReadRecords(fname string, ids []int64) (records [][]byte, err error) {
+	err = dfs.withPlacement(fname, false, func(p *Placement) error {
+		for _, id := range ids {
+			if id < 1 || id > math.MaxInt32/int64(p.RecordSize) {
+				return Errorf(CodeInvalidArgument, "record id %d of file(%s) is out of range", id, fname)
+			}
+		}
+		records, err = dfs.directRead(p, ids)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/rpc"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// ErrorCode - kind of error which is kept when error is passed via rpc
+type ErrorCode byte
+
+const (
+	CodeUnknown ErrorCode = iota
+	CodeNotFound
+	CodeAlreadyExists
+	CodeNotReady
+	CodePeerUnavailable
+	CodeUnavailable
+	CodeChecksumMismatch
+	CodeInvalidArgument
+	CodeNotWritten
+	CodeNoData
+	CodeUnauthenticated
+	CodePermissionDenied
+	CodeNoSpace
+	CodeStaleEpoch
+)
+
+var codeNames = map[ErrorCode]string{
+	CodeUnknown:          "Unknown",
+	CodeNotFound:         "NotFound",
+	CodeAlreadyExists:    "AlreadyExists",
+	CodeNotReady:         "NotReady",
+	CodePeerUnavailable:  "PeerUnavailable",
+	CodeUnavailable:      "Unavailable",
+	CodeChecksumMismatch: "ChecksumMismatch",
+	CodeInvalidArgument:  "InvalidArgument",
+	CodeNotWritten:       "NotWritten",
+	CodeNoData:           "NoData",
+	CodeUnauthenticated:  "Unauthenticated",
+	CodePermissionDenied: "PermissionDenied",
+	CodeNoSpace:          "NoSpace",
+	CodeStaleEpoch:       "StaleEpoch",
+}
+
+func (code ErrorCode) String() string {
+	if name, ok := codeNames[code]; ok {
+		return name
+	}
+	return codeNames[CodeUnknown]
+}
+
+// Sentinel errors. Every error with the code matches its sentinel with errors.Is
+var (
+	ErrNotFound         = errors.New("file not found")
+	ErrAlreadyExists    = errors.New("file already exists")
+	ErrNotReady         = errors.New("dfs is not ready to use")
+	ErrPeerUnavailable  = errors.New("peer is unavailable")
+	ErrUnavailable      = errors.New("remote node is unavailable")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	// ErrNotWritten - returned on reading of records which were never written, if RemoteDFS.FailOnHoles is set
+	ErrNotWritten = errors.New("record was never written")
+	// ErrNoData - returned by SeekData when there is no written records after given id
+	ErrNoData = errors.New("no written records after given id")
+	// ErrUnauthenticated - token of the client is missing or invalid
+	ErrUnauthenticated = errors.New("client is not authenticated")
+	// ErrPermissionDenied - client is not allowed to do operation with the file
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrNoSpace - storage of peer is full
+	ErrNoSpace = errors.New("no space left on peer")
+	// ErrStaleEpoch - placement of the file which client used was changed since master gave it
+	ErrStaleEpoch = errors.New("placement is stale")
+)
+
+var codeSentinels = map[ErrorCode]error{
+	CodeNotFound:         ErrNotFound,
+	CodeAlreadyExists:    ErrAlreadyExists,
+	CodeNotReady:         ErrNotReady,
+	CodePeerUnavailable:  ErrPeerUnavailable,
+	CodeUnavailable:      ErrUnavailable,
+	CodeChecksumMismatch: ErrChecksumMismatch,
+	CodeInvalidArgument:  ErrInvalidArgument,
+	CodeNotWritten:       ErrNotWritten,
+	CodeNoData:           ErrNoData,
+	CodeUnauthenticated:  ErrUnauthenticated,
+	CodePermissionDenied: ErrPermissionDenied,
+	CodeNoSpace:          ErrNoSpace,
+	CodeStaleEpoch:       ErrStaleEpoch,
+}
+
+// Error - error with code. Its text starts with the code in square brackets,
+// so the code is restored by DecodeError on the other side of rpc
+type Error struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[%s] %v", e.Code, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is - reports whether target is the sentinel error of the code
+func (e *Error) Is(target error) bool {
+	sentinel, ok := codeSentinels[e.Code]
+	return ok && target == sentinel
+}
+
+// NewError - returns error with code and message
+func NewError(code ErrorCode, msg string) error {
+	return &Error{Code: code, Err: errors.New(msg)}
+}
+
+// Errorf - formats error with code
+func Errorf(code ErrorCode, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// CodeOf - returns code of the error. Errors of os package and full disk are mapped to corresponding codes
+func CodeOf(err error) ErrorCode {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	for code, sentinel := range codeSentinels {
+		if errors.Is(err, sentinel) {
+			return code
+		}
+	}
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return CodeNotFound
+	case errors.Is(err, os.ErrExist):
+		return CodeAlreadyExists
+	case errors.Is(err, syscall.ENOSPC):
+		return CodeNoSpace
+	}
+	return CodeUnknown
+}
+
+// EncodeError - prepares error to be returned from rpc method, so its code is not lost
+func EncodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		if coded == err {
+			return err
+		}
+		// keep the code in front of the text even if error was wrapped
+		return &Error{Code: coded.Code, Err: err}
+	}
+	if code := CodeOf(err); code != CodeUnknown {
+		return &Error{Code: code, Err: err}
+	}
+	return err
+}
+
+// DecodeError - restores code of the error returned by rpc call.
+// Errors of transport itself are reported as ErrUnavailable
+func DecodeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	serr, ok := err.(rpc.ServerError)
+	if !ok {
+		return &Error{Code: CodeUnavailable, Err: err}
+	}
+	return parseError(string(serr))
+}
+
+// parseError - restores error from its text produced by Error.Error
+func parseError(msg string) error {
+	if strings.HasPrefix(msg, "[") {
+		if end := strings.Index(msg, "] "); end > 0 {
+			for code, name := range codeNames {
+				if name == msg[1:end] {
+					return &Error{Code: code, Err: errors.New(msg[end+2:])}
+				}
+			}
+		}
+	}
+	return &Error{Code: CodeUnknown, Err: errors.New(msg)}
+}
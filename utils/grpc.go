@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"os"
+	"strings"
+)
+
+// GRPCChunkSize - maximal size of data sent in one message of grpc stream
+const GRPCChunkSize = 1 << 20
+
+var grpcCodes = map[ErrorCode]codes.Code{
+	CodeUnknown:          codes.Unknown,
+	CodeNotFound:         codes.NotFound,
+	CodeAlreadyExists:    codes.AlreadyExists,
+	CodeNotReady:         codes.FailedPrecondition,
+	CodePeerUnavailable:  codes.Unavailable,
+	CodeUnavailable:      codes.Unavailable,
+	CodeChecksumMismatch: codes.DataLoss,
+	CodeInvalidArgument:  codes.InvalidArgument,
+	CodeNotWritten:       codes.NotFound,
+	CodeNoData:           codes.OutOfRange,
+	CodeUnauthenticated:  codes.Unauthenticated,
+	CodePermissionDenied: codes.PermissionDenied,
+	CodeNoSpace:          codes.ResourceExhausted,
+	CodeStaleEpoch:       codes.Aborted,
+}
+
+// grpcTokenKey - metadata key in which client token is sent
+const grpcTokenKey = "authorization"
+
+// grpcRequestIDKey - metadata key in which id of the request is sent
+const grpcRequestIDKey = "x-request-id"
+
+// GRPCCallContext - extracts data about the call from metadata of incoming grpc request
+func GRPCCallContext(ctx context.Context) CallContext {
+	var call CallContext
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(grpcTokenKey); len(values) > 0 {
+			call.Token = strings.TrimPrefix(values[0], "Bearer ")
+		}
+		if values := md.Get(grpcRequestIDKey); len(values) > 0 {
+			call.RequestID = values[0]
+		}
+		for _, key := range tracePropagator.Fields() {
+			if values := md.Get(key); len(values) > 0 {
+				if call.Trace == nil {
+					call.Trace = make(map[string]string)
+				}
+				call.Trace[key] = values[0]
+			}
+		}
+	}
+	return call
+}
+
+// withCallContext - adds data about the call to metadata of outgoing grpc request
+func withCallContext(ctx context.Context, call CallContext) context.Context {
+	if call.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, grpcTokenKey, "Bearer "+call.Token)
+	}
+	if call.RequestID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, grpcRequestIDKey, call.RequestID)
+	}
+	for key, value := range call.Trace {
+		ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+	}
+	return ctx
+}
+
+// RunGRPC runs grpc server on the port. Blocks untill server is stopped
+func RunGRPC(server *grpc.Server, port int) {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		Logger(LogRPC).Error("failed to listen for grpc connections", "port", port, "err", err)
+		os.Exit(1)
+	}
+	Logger(LogRPC).Info("started listening for grpc connections", "endpoint", fmt.Sprintf("%s:%v", GetIPAddress(), port))
+	err = server.Serve(l)
+	if err != nil {
+		Logger(LogRPC).Error("grpc server stopped", "err", err)
+		return
+	}
+	Logger(LogRPC).Info("grpc server stopped")
+}
+
+// GetGRPCClient - returns grpc connection to endpoint
+func GetGRPCClient(endpoint string) (*grpc.ClientConn, error) {
+	return GetGRPCClientTLS(endpoint, nil)
+}
+
+// GetGRPCClientTLS - returns grpc connection to endpoint over TLS if config is not nil
+func GetGRPCClientTLS(endpoint string, config *tls.Config) (*grpc.ClientConn, error) {
+	if config != nil {
+		return grpc.NewClient(endpoint, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	}
+	return grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// NewGRPCServer - returns grpc server which accepts only TLS connections if config is not nil.
+// Served requests are recorded to metrics if they are not nil
+func NewGRPCServer(config *tls.Config, metrics *RPCMetrics) *grpc.Server {
+	var opts []grpc.ServerOption
+	if config != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config)))
+	}
+	if metrics != nil {
+		opts = append(opts, metrics.GRPCServerOptions()...)
+	}
+	return grpc.NewServer(opts...)
+}
+
+// GRPCError - converts error to grpc status. Message keeps dfs error code, so it's restored by DecodeGRPCError
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(grpcCodes[CodeOf(err)], EncodeError(err).Error())
+}
+
+// DecodeGRPCError - restores dfs error from grpc status
+func DecodeGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return &Error{Code: CodeUnavailable, Err: err}
+	}
+	decoded := parseError(st.Message())
+	if CodeOf(decoded) == CodeUnknown {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+			return &Error{Code: CodeUnavailable, Err: errors.New(st.Message())}
+		}
+	}
+	return decoded
+}
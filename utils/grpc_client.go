@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"context"
+	"github.com/alikhil/distributed-fs/dfspb"
+	"google.golang.org/grpc"
+	"io"
+	"time"
+)
+
+// DefaultGRPCTimeout - deadline of every call made by GRPCRemoteDFS if other is not set
+const DefaultGRPCTimeout = 30 * time.Second
+
+// GRPCRemoteDFS - client of master node which uses grpc api instead of net/rpc.
+// Large ranges are read and written with streams
+type GRPCRemoteDFS struct {
+	Client dfspb.RemoteIOClient
+	// Timeout - deadline of each call including streaming ones
+	Timeout time.Duration
+	// FailOnHoles - if true reading of never written records fails with ErrNotWritten instead of returning zeros
+	FailOnHoles bool
+	// Token - sent with every call to authenticate client
+	Token string
+	// Context - parent of every call: its trace is continued and its cancelation cancels calls
+	Context context.Context
+}
+
+// NewGRPCRemoteDFS - returns client of master working over connection
+func NewGRPCRemoteDFS(conn *grpc.ClientConn) *GRPCRemoteDFS {
+	return &GRPCRemoteDFS{Client: dfspb.NewRemoteIOClient(conn), Timeout: DefaultGRPCTimeout}
+}
+
+func (dfs *GRPCRemoteDFS) context() (context.Context, context.CancelFunc) {
+	timeout := dfs.Timeout
+	if timeout <= 0 {
+		timeout = DefaultGRPCTimeout
+	}
+	ctx := dfs.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	call := CallContext{Token: dfs.Token}
+	call.SetContext(ctx)
+	if call.RequestID == "" {
+		call.RequestID = NewRequestID()
+	}
+	return context.WithTimeout(withCallContext(ctx, call), timeout)
+}
+
+func (dfs *GRPCRemoteDFS) InitRecordMappings(mp map[string]int32) error {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	_, err := dfs.Client.InitRecordMappings(ctx, &dfspb.RecordMappings{RecordSizes: mp})
+	return DecodeGRPCError(err)
+}
+
+func (dfs *GRPCRemoteDFS) FileExists(fname string) (bool, error) {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	res, err := dfs.Client.FileExists(ctx, &dfspb.FileRequest{Filename: fname})
+	if err != nil {
+		return false, DecodeGRPCError(err)
+	}
+	return res.Exists, nil
+}
+
+func (dfs *GRPCRemoteDFS) CreateFile(fname string) error {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	_, err := dfs.Client.CreateFile(ctx, &dfspb.FileRequest{Filename: fname})
+	return DecodeGRPCError(err)
+}
+
+func (dfs *GRPCRemoteDFS) DeleteFile(fname string) error {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	_, err := dfs.Client.DeleteFile(ctx, &dfspb.FileRequest{Filename: fname})
+	return DecodeGRPCError(err)
+}
+
+// ReadBytes - reads count bytes from offset. Data is received by chunks
+func (dfs *GRPCRemoteDFS) ReadBytes(fname string, offset, count int64) ([]byte, error) {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	stream, err := dfs.Client.ReadBytes(ctx, &dfspb.ReadRequest{Filename: fname, Offset: offset, Count: count, FailOnHole: dfs.FailOnHoles})
+	if err != nil {
+		return nil, DecodeGRPCError(err)
+	}
+
+	// count is not trusted for preallocation: server validates it
+	capacity := count
+	if capacity < 0 || capacity > GRPCChunkSize {
+		capacity = GRPCChunkSize
+	}
+	data := make([]byte, 0, capacity)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, DecodeGRPCError(err)
+		}
+		data = append(data, chunk.Data...)
+	}
+}
+
+// WriteBytes - writes data from offset. Data is sent by chunks of GRPCChunkSize
+func (dfs *GRPCRemoteDFS) WriteBytes(fname string, offset int64, data []byte) error {
+	recordSize, err := dfs.RecordSize(fname)
+	if err != nil {
+		return err
+	}
+	// chunks should contain whole records
+	chunkSize := GRPCChunkSize / int(recordSize) * int(recordSize)
+	if chunkSize == 0 {
+		chunkSize = int(recordSize)
+	}
+
+	ctx, cancel := dfs.context()
+	defer cancel()
+	stream, err := dfs.Client.WriteBytes(ctx)
+	if err != nil {
+		return DecodeGRPCError(err)
+	}
+	for off := 0; off < len(data); off += chunkSize {
+		end := off + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		err = stream.Send(&dfspb.Chunk{Filename: fname, Offset: offset + int64(off), Data: data[off:end]})
+		if err != nil {
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return DecodeGRPCError(err)
+}
+
+func (dfs *GRPCRemoteDFS) AppendRecords(fname string, data []byte) (firstID, offset int64, err error) {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	res, err := dfs.Client.AppendRecords(ctx, &dfspb.AppendRequest{Filename: fname, Data: data})
+	if err != nil {
+		return 0, 0, DecodeGRPCError(err)
+	}
+	return res.FirstId, res.Offset, nil
+}
+
+// ReadRecords - reads records with arbitrary ids. Records are returned in the same order as ids
+func (dfs *GRPCRemoteDFS) ReadRecords(fname string, ids []int64) ([][]byte, error) {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	stream, err := dfs.Client.ReadRecords(ctx, &dfspb.RecordsRequest{Filename: fname, Ids: ids, FailOnHole: dfs.FailOnHoles})
+	if err != nil {
+		return nil, DecodeGRPCError(err)
+	}
+
+	records := make([][]byte, 0, len(ids))
+	for {
+		record, err := stream.Recv()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, DecodeGRPCError(err)
+		}
+		records = append(records, record.Data)
+	}
+}
+
+func (dfs *GRPCRemoteDFS) RecordSize(fname string) (int32, error) {
+	ctx, cancel := dfs.context()
+	defer cancel()
+	res, err := dfs.Client.RecordSize(ctx, &dfspb.FileRequest{Filename: fname})
+	if err != nil {
+		return 0, DecodeGRPCError(err)
+	}
+	return res.RecordSize, nil
+}
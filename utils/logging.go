@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Subsystems of the nodes which have their own log levels
+const (
+	LogRPC     = "rpc"     // served calls and rpc servers
+	LogHealth  = "health"  // peers joining master and health checks of them
+	LogStorage = "storage" // files stored by peers
+)
+
+// logLevels - current levels of subsystems. Levels are changed at runtime, loggers see it immediately
+var logLevels = map[string]*slog.LevelVar{
+	LogRPC:     new(slog.LevelVar),
+	LogHealth:  new(slog.LevelVar),
+	LogStorage: new(slog.LevelVar),
+}
+
+var logging struct {
+	sync.RWMutex
+	loggers map[string]*slog.Logger
+}
+
+func init() {
+	// untill Setup is called subsystems write to standard log
+	logging.loggers = newLoggers(slog.Default().Handler())
+}
+
+// levelHandler - passes to next handler only records which are enabled by level
+type levelHandler struct {
+	level slog.Leveler
+	next  slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
+
+func newLoggers(handler slog.Handler) map[string]*slog.Logger {
+	loggers := make(map[string]*slog.Logger, len(logLevels))
+	for subsystem, level := range logLevels {
+		loggers[subsystem] = slog.New(&levelHandler{level: level, next: handler}).With("subsystem", subsystem)
+	}
+	return loggers
+}
+
+// setupLoggers - makes loggers of all the subsystems write to handler. Other logs, including standard log package, are written with defaultLevel
+func setupLoggers(handler slog.Handler, defaultLevel slog.Leveler) {
+	logging.Lock()
+	logging.loggers = newLoggers(handler)
+	logging.Unlock()
+	slog.SetDefault(slog.New(&levelHandler{level: defaultLevel, next: handler}))
+}
+
+// Logger - returns logger of subsystem
+func Logger(subsystem string) *slog.Logger {
+	logging.RLock()
+	defer logging.RUnlock()
+	if logger, ok := logging.loggers[subsystem]; ok {
+		return logger
+	}
+	return slog.Default().With("subsystem", subsystem)
+}
+
+// RequestLogger - returns logger of subsystem which adds id of the request served in ctx to every record
+func RequestLogger(ctx context.Context, subsystem string) *slog.Logger {
+	logger := Logger(subsystem)
+	if id := RequestIDOf(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	return logger
+}
+
+// SetLogLevel - changes level of the subsystem, e.g. SetLogLevel("rpc", "debug")
+func SetLogLevel(subsystem, level string) error {
+	levelVar, ok := logLevels[subsystem]
+	if !ok {
+		return Errorf(CodeInvalidArgument, "unknown log subsystem %s", subsystem)
+	}
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return Errorf(CodeInvalidArgument, "invalid log level %s of subsystem %s", level, subsystem)
+	}
+	levelVar.Set(parsed)
+	return nil
+}
+
+// LogLevels - returns current levels of all the subsystems
+func LogLevels() map[string]string {
+	levels := make(map[string]string, len(logLevels))
+	for subsystem, level := range logLevels {
+		levels[subsystem] = level.Level().String()
+	}
+	return levels
+}
+
+// setLogLevels - applies levels in form rpc=debug,health=warn
+func setLogLevels(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return Errorf(CodeInvalidArgument, "log level %q should be in form subsystem=level", item)
+		}
+		if err := SetLogLevel(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LogFlags - settings of logging of the node
+type LogFlags struct {
+	Format string
+	Level  string
+	Levels string
+}
+
+// RegisterLogFlags - registers flags of logging; should be called before flag.Parse
+func RegisterLogFlags() *LogFlags {
+	flags := &LogFlags{}
+	flag.StringVar(&flags.Format, "log-format", "text", "format of logs: text or json")
+	flag.StringVar(&flags.Level, "log-level", "info", "level of logs of all subsystems: debug, info, warn or error")
+	subsystems := make([]string, 0, len(logLevels))
+	for subsystem := range logLevels {
+		subsystems = append(subsystems, subsystem)
+	}
+	sort.Strings(subsystems)
+	flag.StringVar(&flags.Levels, "log-levels", "", fmt.Sprintf("levels of subsystems (%s) overriding -log-level, e.g. rpc=debug,health=warn", strings.Join(subsystems, ", ")))
+	return flags
+}
+
+// Setup - makes all the loggers write to w in chosen format with chosen levels
+func (flags *LogFlags) Setup(w io.Writer) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(flags.Level)); err != nil {
+		return Errorf(CodeInvalidArgument, "invalid log level %s", flags.Level)
+	}
+	for _, levelVar := range logLevels {
+		levelVar.Set(level)
+	}
+	if err := setLogLevels(flags.Levels); err != nil {
+		return err
+	}
+
+	// levels are checked by levelHandler, so handler itself passes everything
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch flags.Format {
+	case "text":
+		setupLoggers(slog.NewTextHandler(w, opts), level)
+	case "json":
+		setupLoggers(slog.NewJSONHandler(w, opts), level)
+	default:
+		return Errorf(CodeInvalidArgument, "unknown log format %s", flags.Format)
+	}
+	return nil
+}
+
+// LogLevelHandler - shows levels of subsystems as json on GET
+// and changes them on POST or PUT with query like ?rpc=debug&health=warn
+func LogLevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			for subsystem, values := range req.URL.Query() {
+				if err := SetLogLevel(subsystem, values[len(values)-1]); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				Logger(subsystem).Info("log level changed", "level", values[len(values)-1])
+			}
+		default:
+			http.Error(w, "only GET, POST and PUT are allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(LogLevels())
+	})
+}
+
+type requestIDKey struct{}
+
+// NewRequestID - returns random id of the request
+func NewRequestID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// WithRequestID - returns context of serving the request with id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDOf - returns id of the request served in ctx or empty string
+func RequestIDOf(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Fatal - logs error which does not let node work and exits
+func Fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
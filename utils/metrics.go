@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewMetricsRegistry - returns registry with metrics of go runtime and process (including open file descriptors)
+func NewMetricsRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return reg
+}
+
+// RunMetrics serves metrics of the registry on /metrics of the port and log levels on /loglevel. Blocks untill server fails
+func RunMetrics(reg *prometheus.Registry, port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	mux.Handle("/loglevel", LogLevelHandler())
+
+	Logger(LogRPC).Info("started listening for metrics scrapes", "endpoint", fmt.Sprintf("%s:%v", GetIPAddress(), port))
+	err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+	Logger(LogRPC).Error("metrics server stopped", "err", err)
+}
+
+// RPCMetrics - counts requests served by node and measures their latency
+type RPCMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewRPCMetrics - creates rpc metrics and registers them in reg
+func NewRPCMetrics(reg prometheus.Registerer) *RPCMetrics {
+	m := &RPCMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "dfs_rpc_requests_total",
+			Help: "Number of served rpc requests by method and result code.",
+		}, []string{"transport", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "dfs_rpc_duration_seconds",
+			Help:    "Latency of served rpc requests.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 4, 9),
+		}, []string{"transport", "method"}),
+	}
+	reg.MustRegister(m.requests, m.duration)
+	return m
+}
+
+// observe - records request which started at start. Method is named as in net/rpc: Service.Method
+func (m *RPCMetrics) observe(transport, method string, err error, start time.Time) {
+	code := "OK"
+	if err != nil {
+		code = CodeOf(err).String()
+	}
+	m.requests.WithLabelValues(transport, method, code).Inc()
+	m.duration.WithLabelValues(transport, method).Observe(time.Since(start).Seconds())
+}
+
+// grpcMethodName - converts /dfspb.RemoteIO/ReadBytes to RemoteIO.ReadBytes
+func grpcMethodName(fullMethod string) string {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		name = name[dot+1:]
+	}
+	return strings.Replace(name, "/", ".", 1)
+}
+
+// GRPCServerOptions - returns interceptors which record requests of grpc server
+func (m *RPCMetrics) GRPCServerOptions() []grpc.ServerOption {
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe("grpc", grpcMethodName(info.FullMethod), DecodeGRPCError(err), start)
+		return resp, err
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe("grpc", grpcMethodName(info.FullMethod), DecodeGRPCError(err), start)
+		return err
+	}
+	return []grpc.ServerOption{grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream)}
+}
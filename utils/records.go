@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"fmt"
+)
+
+// ErrInvalidRecordID - record ids start from 1
+var ErrInvalidRecordID = NewError(CodeInvalidArgument, "record id should be positive")
+
+// ErrRecordSizeMismatch - payload length differs from record size of the file
+var ErrRecordSizeMismatch = NewError(CodeInvalidArgument, "payload size does not match record size of the file")
+
+// scanBatchSize - number of records requested from master at once by RecordScanner
+const scanBatchSize = 64
+
+// RecordSize - returns record size of the file. Sizes are cached by client untill a record call fails in a way
+// which changed record size would cause, see recordCallDone
+func (dfs *RemoteDFS) RecordSize(fname string) (int32, error) {
+	dfs.recordSizesLock.Lock()
+	size, ok := dfs.recordSizes[fname]
+	dfs.recordSizesLock.Unlock()
+	if ok {
+		return size, nil
+	}
+
+	err := dfs.Client.Call("RemoteIO.RecordSize", &IOFileArgs{Filename: &fname, CallContext: dfs.callContext()}, &size)
+	if err != nil {
+		return 0, DecodeError(err)
+	}
+
+	dfs.recordSizesLock.Lock()
+	if dfs.recordSizes == nil {
+		dfs.recordSizes = make(map[string]int32)
+	}
+	dfs.recordSizes[fname] = size
+	dfs.recordSizesLock.Unlock()
+	return size, nil
+}
+
+// forgetRecordSize - drops cached record size of the file, so it's asked from master next time
+func (dfs *RemoteDFS) forgetRecordSize(fname string) {
+	dfs.recordSizesLock.Lock()
+	defer dfs.recordSizesLock.Unlock()
+	delete(dfs.recordSizes, fname)
+}
+
+// recordCallDone - drops cached record size of the file if record call failed because of it: other client
+// may have changed record size of the file or deleted it, so records are misaligned now
+func (dfs *RemoteDFS) recordCallDone(fname string, err error) error {
+	switch CodeOf(err) {
+	case CodeInvalidArgument, CodeNotFound:
+		dfs.forgetRecordSize(fname)
+	}
+	return err
+}
+
+// recordOffset - returns offset of the record and record size of the file
+func (dfs *RemoteDFS) recordOffset(fname string, id int64) (offset, size int32, err error) {
+	if id < 1 {
+		return 0, 0, ErrInvalidRecordID
+	}
+	size, err = dfs.RecordSize(fname)
+	if err != nil {
+		return 0, 0, err
+	}
+	if id-1 > int64((1<<31-1)/size) {
+		return 0, 0, Errorf(CodeInvalidArgument, "record id %d of file(%s) is out of range", id, fname)
+	}
+	return int32(id-1) * size, size, nil
+}
+
+// ReadRecord - reads record with given id. Ids start from 1
+func (dfs *RemoteDFS) ReadRecord(fname string, id int64) ([]byte, error) {
+	offset, size, err := dfs.recordOffset(fname, id)
+	if err != nil {
+		return nil, err
+	}
+	record, err := dfs.ReadBytes(fname, offset, size)
+	if err == nil && int32(len(record)) != size {
+		err = ErrRecordSizeMismatch
+	}
+	if err != nil {
+		return nil, dfs.recordCallDone(fname, err)
+	}
+	return record, nil
+}
+
+// WriteRecord - overwrites record with given id. Length of data should be equal to record size of the file
+func (dfs *RemoteDFS) WriteRecord(fname string, id int64, data []byte) error {
+	offset, size, err := dfs.recordOffset(fname, id)
+	if err != nil {
+		return err
+	}
+	if int32(len(data)) != size {
+		return fmt.Errorf("%w: got %d bytes, file(%s) has records of %d bytes", ErrRecordSizeMismatch, len(data), fname, size)
+	}
+	return dfs.recordCallDone(fname, dfs.WriteBytes(fname, offset, &data))
+}
+
+// ReadRecords - reads records with arbitrary ids. Records are returned in the same order as ids
+func (dfs *RemoteDFS) ReadRecords(fname string, ids []int64) ([][]byte, error) {
+	for _, id := range ids {
+		if id < 1 {
+			return nil, ErrInvalidRecordID
+		}
+	}
+	if dfs.Direct {
+		return dfs.directReadRecords(fname, ids)
+	}
+	size, err := dfs.RecordSize(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	var records [][]byte
+	err = dfs.Client.Call("RemoteIO.ReadRecords", &IORecordsArgs{Filename: &fname, IDs: &ids, FailOnHole: dfs.FailOnHoles, CallContext: dfs.callContext()}, &records)
+	if err != nil {
+		return nil, dfs.recordCallDone(fname, DecodeError(err))
+	}
+	for _, record := range records {
+		if int32(len(record)) != size {
+			return nil, dfs.recordCallDone(fname, ErrRecordSizeMismatch)
+		}
+	}
+	return records, nil
+}
+
+// RecordScanner - iterates over records of the file in range of ids
+//
+//	scanner := dfs.ScanRecords("users", 1, 100)
+//	for scanner.Next() {
+//		id, record := scanner.ID(), scanner.Record()
+//	}
+//	err := scanner.Err()
+type RecordScanner struct {
+	dfs   *RemoteDFS
+	fname string
+	next  int64
+	to    int64
+
+	batch   [][]byte
+	batchID int64
+	pos     int
+	err     error
+}
+
+// ScanRecords - returns scanner over records with ids from `from` to `to` inclusively
+func (dfs *RemoteDFS) ScanRecords(fname string, from, to int64) *RecordScanner {
+	scanner := &RecordScanner{dfs: dfs, fname: fname, next: from, to: to, pos: -1}
+	if from < 1 {
+		scanner.err = ErrInvalidRecordID
+	}
+	return scanner
+}
+
+// Next - moves scanner to the next record. Returns false when range is over or error occured
+func (s *RecordScanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	s.pos++
+	if s.pos < len(s.batch) {
+		return true
+	}
+	if s.next > s.to {
+		return false
+	}
+
+	count := s.to - s.next + 1
+	if count > scanBatchSize {
+		count = scanBatchSize
+	}
+	ids := make([]int64, count)
+	for i := range ids {
+		ids[i] = s.next + int64(i)
+	}
+
+	s.batch, s.err = s.dfs.ReadRecords(s.fname, ids)
+	if s.err != nil {
+		return false
+	}
+	s.batchID = s.next
+	s.next += count
+	s.pos = 0
+	return len(s.batch) > 0
+}
+
+// ID - returns id of the current record
+func (s *RecordScanner) ID() int64 {
+	return s.batchID + int64(s.pos)
+}
+
+// Record - returns content of the current record
+func (s *RecordScanner) Record() []byte {
+	return s.batch[s.pos]
+}
+
+// Err - returns first error occured during scanning
+func (s *RecordScanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/gob"
+	"io"
+	"net/http"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// gobServerCodec - the same codec which net/rpc uses by default. net/rpc does not export it,
+// but it's needed to wrap it with metricsCodec
+type gobServerCodec struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+	closed bool
+}
+
+func newGobServerCodec(conn io.ReadWriteCloser) *gobServerCodec {
+	buf := bufio.NewWriter(conn)
+	return &gobServerCodec{rwc: conn, dec: gob.NewDecoder(conn), enc: gob.NewEncoder(buf), encBuf: buf}
+}
+
+func (c *gobServerCodec) ReadRequestHeader(r *rpc.Request) error {
+	return c.dec.Decode(r)
+}
+
+func (c *gobServerCodec) ReadRequestBody(body interface{}) error {
+	return c.dec.Decode(body)
+}
+
+func (c *gobServerCodec) WriteResponse(r *rpc.Response, body interface{}) (err error) {
+	if err = c.enc.Encode(r); err != nil {
+		if c.encBuf.Flush() == nil {
+			Logger(LogRPC).Error("gob error encoding response", "err", err)
+			c.Close()
+		}
+		return
+	}
+	if err = c.enc.Encode(body); err != nil {
+		if c.encBuf.Flush() == nil {
+			Logger(LogRPC).Error("gob error encoding body", "err", err)
+			c.Close()
+		}
+		return
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *gobServerCodec) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rwc.Close()
+}
+
+// metricsCodec - records every request of net/rpc connection when response is written
+type metricsCodec struct {
+	rpc.ServerCodec
+	metrics *RPCMetrics
+
+	started     map[uint64]time.Time // start time of requests by their sequence number
+	startedLock sync.Mutex
+}
+
+func (c *metricsCodec) ReadRequestHeader(r *rpc.Request) error {
+	err := c.ServerCodec.ReadRequestHeader(r)
+	if err == nil {
+		c.startedLock.Lock()
+		c.started[r.Seq] = time.Now()
+		c.startedLock.Unlock()
+	}
+	return err
+}
+
+func (c *metricsCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	c.startedLock.Lock()
+	start, ok := c.started[r.Seq]
+	delete(c.started, r.Seq)
+	c.startedLock.Unlock()
+
+	if ok {
+		var err error
+		if r.Error != "" {
+			err = parseError(r.Error)
+		}
+		c.metrics.observe("rpc", r.ServiceMethod, err, start)
+	}
+	return c.ServerCodec.WriteResponse(r, body)
+}
+
+// callerArgs - args of rpc which need role of the caller. Role is set by server from certificate of TLS connection,
+// so the caller can not forge it
+type callerArgs interface {
+	setCallerRole(role string)
+}
+
+// roleCodec - passes role of the certificate of TLS connection to args which need it
+type roleCodec struct {
+	rpc.ServerCodec
+	role string
+}
+
+func (c *roleCodec) ReadRequestBody(body interface{}) error {
+	err := c.ServerCodec.ReadRequestBody(body)
+	if args, ok := body.(callerArgs); ok && err == nil {
+		args.setCallerRole(c.role)
+	}
+	return err
+}
+
+// rpcHandler - serves net/rpc over http as rpc.Server does, but passes role of TLS certificate of the caller
+// to args and records requests to metrics if they are not nil
+type rpcHandler struct {
+	server  *rpc.Server
+	metrics *RPCMetrics
+}
+
+func (h *rpcHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "CONNECT" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		io.WriteString(w, "405 must CONNECT\n")
+		return
+	}
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		Logger(LogRPC).Error("failed to hijack rpc connection", "remote", req.RemoteAddr, "err", err)
+		return
+	}
+	io.WriteString(conn, "HTTP/1.0 200 Connected to Go RPC\n\n")
+
+	var codec rpc.ServerCodec = newGobServerCodec(conn)
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		// handshake is done while request is read, so certificate is known
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			codec = &roleCodec{ServerCodec: codec, role: RoleOf(certs[0])}
+		}
+	}
+	if h.metrics != nil {
+		codec = &metricsCodec{ServerCodec: codec, metrics: h.metrics, started: make(map[uint64]time.Time)}
+	}
+	h.server.ServeCodec(codec)
+}
@@ -0,0 +1,22 @@
+package utils
+
+// SeekData - returns id of the first written record of the file starting from id
+func (dfs *RemoteDFS) SeekData(fname string, id int64) (int64, error) {
+	var res int64
+	err := dfs.Client.Call("RemoteIO.SeekData", &IOSeekArgs{Filename: &fname, ID: id, CallContext: dfs.callContext()}, &res)
+	return res, DecodeError(err)
+}
+
+// SeekHole - returns id of the first never written record of the file starting from id
+func (dfs *RemoteDFS) SeekHole(fname string, id int64) (int64, error) {
+	var res int64
+	err := dfs.Client.Call("RemoteIO.SeekHole", &IOSeekArgs{Filename: &fname, ID: id, CallContext: dfs.callContext()}, &res)
+	return res, DecodeError(err)
+}
+
+// DataRanges - returns all ranges of record ids of the file which were ever written
+func (dfs *RemoteDFS) DataRanges(fname string) ([]RecordRange, error) {
+	var ranges []RecordRange
+	err := dfs.Client.Call("RemoteIO.DataRanges", &IOFileArgs{Filename: &fname, CallContext: dfs.callContext()}, &ranges)
+	return ranges, DecodeError(err)
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/rpc"
+)
+
+// Roles of cluster nodes. Role is kept in OrganizationalUnit of node certificate
+const (
+	RoleMaster = "master"
+	RolePeer   = "peer"
+	RoleClient = "client"
+)
+
+// TLSFiles - paths to cluster CA and certificate of the node. TLS is disabled when they are empty
+type TLSFiles struct {
+	CA   string
+	Cert string
+	Key  string
+}
+
+// RegisterTLSFlags - adds -tls-ca, -tls-cert and -tls-key flags to command line
+func RegisterTLSFlags() *TLSFiles {
+	files := &TLSFiles{}
+	flag.StringVar(&files.CA, "tls-ca", "", "path to CA certificate of the cluster; enables mutual TLS")
+	flag.StringVar(&files.Cert, "tls-cert", "", "path to certificate of this node")
+	flag.StringVar(&files.Key, "tls-key", "", "path to private key of this node")
+	return files
+}
+
+// Enabled - checks if any of TLS files is set
+func (files *TLSFiles) Enabled() bool {
+	return files != nil && (files.CA != "" || files.Cert != "" || files.Key != "")
+}
+
+func (files *TLSFiles) load() (*x509.CertPool, tls.Certificate, error) {
+	if files.CA == "" || files.Cert == "" || files.Key == "" {
+		return nil, tls.Certificate{}, errors.New("all of CA, certificate and key should be set to use TLS")
+	}
+	caPEM, err := ioutil.ReadFile(files.CA)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, tls.Certificate{}, fmt.Errorf("no certificates found in %s", files.CA)
+	}
+	cert, err := tls.LoadX509KeyPair(files.Cert, files.Key)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+	return pool, cert, nil
+}
+
+// RoleOf - returns role of the node which owns certificate
+func RoleOf(cert *x509.Certificate) string {
+	if len(cert.Subject.OrganizationalUnit) == 0 {
+		return ""
+	}
+	return cert.Subject.OrganizationalUnit[0]
+}
+
+// verifyRole - checks that certificate of the other side has one of allowed roles
+func verifyRole(allowed []string) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("tls: no certificate presented")
+		}
+		role := RoleOf(cs.PeerCertificates[0])
+		for _, r := range allowed {
+			if r == role {
+				return nil
+			}
+		}
+		return fmt.Errorf("tls: certificate role %q is not allowed; expected one of %v", role, allowed)
+	}
+}
+
+// ServerConfig - returns config for listener which accepts only nodes with allowed roles
+func (files *TLSFiles) ServerConfig(allowedRoles ...string) (*tls.Config, error) {
+	pool, cert, err := files.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates:     []tls.Certificate{cert},
+		ClientAuth:       tls.RequireAndVerifyClientCert,
+		ClientCAs:        pool,
+		MinVersion:       tls.VersionTLS12,
+		VerifyConnection: verifyRole(allowedRoles),
+	}, nil
+}
+
+// ClientConfig - returns config for connection to node with one of serverRoles.
+// Nodes are identified by role in certificate signed by cluster CA, not by host name,
+// since peers register with endpoints they detect themselves
+func (files *TLSFiles) ClientConfig(serverRoles ...string) (*tls.Config, error) {
+	pool, cert, err := files.load()
+	if err != nil {
+		return nil, err
+	}
+	checkRole := verifyRole(serverRoles)
+	return &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true, // chain is verified below without host name
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("tls: no certificate presented")
+			}
+			intermediates := x509.NewCertPool()
+			for _, c := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         pool,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			if err != nil {
+				return err
+			}
+			return checkRole(cs)
+		},
+	}, nil
+}
+
+// dialHTTPTLS - same as rpc.DialHTTP but over TLS connection
+func dialHTTPTLS(endpoint string, config *tls.Config) (*rpc.Client, error) {
+	conn, err := tls.Dial("tcp", endpoint, config)
+	if err != nil {
+		return nil, err
+	}
+	io.WriteString(conn, "CONNECT "+rpc.DefaultRPCPath+" HTTP/1.0\n\n")
+
+	// rpc server answers with this status on successful connect
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	if err == nil && resp.Status == "200 Connected to Go RPC" {
+		return rpc.NewClient(conn), nil
+	}
+	if err == nil {
+		err = errors.New("unexpected HTTP response: " + resp.Status)
+	}
+	conn.Close()
+	return nil, &net.OpError{Op: "dial-http", Net: "tcp " + endpoint, Addr: nil, Err: err}
+}
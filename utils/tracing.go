@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"context"
+	"flag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Trace context is passed between nodes in CallContext.Trace as w3c traceparent/tracestate,
+// so it works with net/rpc as well as with grpc metadata
+
+const tracerName = "github.com/alikhil/distributed-fs"
+
+var tracePropagator = propagation.TraceContext{}
+
+// TracingFlags - where spans of the node are exported
+type TracingFlags struct {
+	OTLPEndpoint string
+	File         string
+}
+
+// RegisterTracingFlags - registers flags for exporting spans; should be called before flag.Parse
+func RegisterTracingFlags() *TracingFlags {
+	flags := &TracingFlags{}
+	flag.StringVar(&flags.OTLPEndpoint, "otlp-endpoint", "", "url of OTLP collector to which spans are exported over grpc, e.g. http://localhost:4317")
+	flag.StringVar(&flags.File, "trace-file", "", "file to which spans are written as json lines for offline use")
+	return flags
+}
+
+// Enabled - returns true if spans should be exported anywhere
+func (flags *TracingFlags) Enabled() bool {
+	return flags.OTLPEndpoint != "" || flags.File != ""
+}
+
+// Setup - makes tracer provider of service exporting to enabled exporters global.
+// Returned function flushes not yet exported spans and should be called before exit
+func (flags *TracingFlags) Setup(service string) (func(), error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", service))),
+	}
+	if flags.OTLPEndpoint != "" {
+		exporter, err := otlptracegrpc.New(context.Background(), otlptracegrpc.WithEndpointURL(flags.OTLPEndpoint))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	var file *os.File
+	if flags.File != "" {
+		var err error
+		file, err = os.OpenFile(flags.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			slog.Error("failed to flush spans", "err", err)
+		}
+		if file != nil {
+			file.Close()
+		}
+	}, nil
+}
+
+// Tracer - returns tracer of dfs. Spans are not recorded untill tracer provider is set up
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// SetContext - puts trace context and request id of ctx to the call, so callee continues them
+func (call *CallContext) SetContext(ctx context.Context) {
+	call.RequestID = RequestIDOf(ctx)
+	carrier := propagation.MapCarrier{}
+	tracePropagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		call.Trace = nil
+		return
+	}
+	call.Trace = carrier
+}
+
+// Context - returns context carrying trace and request id sent by caller
+func (call *CallContext) Context() context.Context {
+	ctx := tracePropagator.Extract(context.Background(), propagation.MapCarrier(call.Trace))
+	if call.RequestID != "" {
+		ctx = WithRequestID(ctx, call.RequestID)
+	}
+	return ctx
+}
+
+// StartSpan - starts span of served call which continues trace of the caller.
+// If caller did not send request id, the new one is generated
+func StartSpan(call *CallContext, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx := call.Context()
+	if call.RequestID == "" {
+		ctx = WithRequestID(ctx, NewRequestID())
+	}
+	attrs = append(attrs, attribute.String("dfs.request_id", RequestIDOf(ctx)))
+	return Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// EndSpan - marks span as failed if err is not nil and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// FileAttribute - attribute of span with name of dfs file
+func FileAttribute(fname string) attribute.KeyValue {
+	return attribute.String("dfs.file", fname)
+}
@@ -1,8 +1,9 @@
 package utils
 
 import (
+	"crypto/tls"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/rpc"
@@ -18,7 +19,7 @@ func GetRPCPort() int {
 	if ok {
 		pi, err := strconv.Atoi(p)
 		if err != nil {
-			log.Printf("UTILS: Error on parsing RPC_PORT: %v", err)
+			slog.Warn("failed to parse RPC_PORT", "err", err)
 			return defaultPort
 		}
 		return pi
@@ -33,7 +34,7 @@ func GetIPAddress() string {
 
 	defer conn.Close()
 	if err != nil {
-		log.Printf("UTILS: failed to get ip addrres")
+		slog.Warn("failed to get ip address", "err", err)
 		return ""
 	}
 	localAddr := conn.LocalAddr().(*net.UDPAddr)
@@ -42,38 +43,64 @@ func GetIPAddress() string {
 
 // RunRPC runs rpc listener binded to specefic object
 func RunRPC(nameToRegister string, bindTo interface{}, port int, running *bool, rpcListener **net.Listener) {
-	rpcServer := rpc.NewServer()
-	rpcServer.RegisterName(nameToRegister, bindTo)
+	RunRPCWithTLS(nameToRegister, bindTo, port, running, rpcListener, nil, nil)
+}
 
-	rpcServer.HandleHTTP(rpc.DefaultRPCPath, rpc.DefaultDebugPath)
+// RunRPCWithTLS runs rpc listener which accepts only TLS connections if config is not nil.
+// Served requests are recorded to metrics if they are not nil
+func RunRPCWithTLS(nameToRegister string, bindTo interface{}, port int, running *bool, rpcListener **net.Listener, config *tls.Config, metrics *RPCMetrics) {
+	mux := NewRPCHandler(nameToRegister, bindTo, metrics)
 
 	*running = true
 	for *running {
 		l, e := net.Listen("tcp", fmt.Sprintf(":%d", port))
 		*rpcListener = &l
 		if e != nil {
-			log.Fatalf("RPC: there was an error in listening for http connection on port %v: %v", port, e)
+			Logger(LogRPC).Error("failed to listen for rpc connections", "port", port, "err", e)
+			os.Exit(1)
+		}
+		if config != nil {
+			l = tls.NewListener(l, config)
+			*rpcListener = &l
 		}
-		log.Printf("RPC: Started listening for new http connections in endpoint %s:%v", GetIPAddress(), port)
-		err := http.Serve(l, nil)
+		Logger(LogRPC).Info("started listening for rpc connections", "endpoint", fmt.Sprintf("%s:%v", GetIPAddress(), port))
+		err := http.Serve(l, mux)
 		if err != nil {
-			log.Println("RPC: Error serving connection.")
+			Logger(LogRPC).Debug("rpc listener closed", "err", err)
 			continue
 		}
-
-		log.Println("RPC: Serving new connection.")
 	}
-	log.Printf("RPC: rpc server stopped")
+	Logger(LogRPC).Info("rpc server stopped")
+}
+
+// NewRPCHandler - returns http handler which serves rpc calls to object bound to the name.
+// Served requests are recorded to metrics if they are not nil
+func NewRPCHandler(nameToRegister string, bindTo interface{}, metrics *RPCMetrics) http.Handler {
+	rpcServer := rpc.NewServer()
+	rpcServer.RegisterName(nameToRegister, bindTo)
+
+	mux := http.NewServeMux()
+	mux.Handle(rpc.DefaultRPCPath, &rpcHandler{server: rpcServer, metrics: metrics})
+	return mux
 }
 
 // GetRemoteClient - returns rpc client connected to endpoint
 func GetRemoteClient(endpoint string) (*rpc.Client, bool) {
+	return GetRemoteClientTLS(endpoint, nil)
+}
+
+// GetRemoteClientTLS - returns rpc client connected to endpoint over TLS if config is not nil
+func GetRemoteClientTLS(endpoint string, config *tls.Config) (*rpc.Client, bool) {
 
 	c := make(chan error, 1)
 	var client *rpc.Client
 	var err error
 	go func() {
-		client, err = rpc.DialHTTP("tcp", endpoint)
+		if config != nil {
+			client, err = dialHTTPTLS(endpoint, config)
+		} else {
+			client, err = rpc.DialHTTP("tcp", endpoint)
+		}
 		c <- err
 	}()
 